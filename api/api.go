@@ -0,0 +1,266 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	attachmentLib "todolist/attachment"
+	commentLib "todolist/comment"
+	"todolist/config"
+	"todolist/history"
+	listLib "todolist/list"
+	"todolist/permission"
+	taskLib "todolist/task"
+	"todolist/timer"
+	"todolist/undo"
+	userLib "todolist/user"
+	"todolist/utils"
+	viewLib "todolist/view"
+	workspaceLib "todolist/workspace"
+)
+
+const (
+	USER_HEADER = "X-User-Id"
+)
+
+func NewHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/undo", post(replayHandler(undo.Undo)))
+	mux.HandleFunc("/redo", post(replayHandler(undo.Redo)))
+	mux.HandleFunc("/trash", get(trashHandler))
+	mux.HandleFunc("/trash/restore", post(restoreHandler))
+	mux.HandleFunc("/tasks", get(tasksHandler))
+	mux.HandleFunc("/tasks/assigned", get(assignedHandler))
+	mux.HandleFunc("/tasks/view", get(viewHandler))
+	mux.HandleFunc("/views", get(savedViewsHandler))
+	mux.HandleFunc("/views/save", post(saveViewHandler))
+	mux.HandleFunc("/views/delete", post(deleteViewHandler))
+	mux.HandleFunc("/views/run", get(runViewHandler))
+	mux.HandleFunc("/tasks/assign", post(assignHandler))
+	mux.HandleFunc("/tasks/status", post(taskHandler(statusHandler)))
+	mux.HandleFunc("/tasks/status-history", get(taskHandler(statusHistoryHandler)))
+	mux.HandleFunc("/tasks/estimate", post(taskHandler(estimateHandler)))
+	mux.HandleFunc("/tasks/priority", post(taskHandler(priorityHandler)))
+	mux.HandleFunc("/lists", get(listsHandler))
+	mux.HandleFunc("/lists/create", post(createListHandler))
+	mux.HandleFunc("/lists/delete", post(listHandler(deleteListHandler)))
+	mux.HandleFunc("/lists/members", get(listHandler(membersHandler)))
+	mux.HandleFunc("/lists/share", post(listHandler(shareHandler)))
+	mux.HandleFunc("/lists/unshare", post(listHandler(unshareHandler)))
+	mux.HandleFunc("/lists/invite", post(listHandler(inviteHandler)))
+	mux.HandleFunc("/lists/workflow", get(listHandler(workflowHandler)))
+	mux.HandleFunc("/lists/workflow/set", post(listHandler(setWorkflowHandler)))
+	mux.HandleFunc("/lists/board", get(listHandler(boardHandler)))
+	mux.HandleFunc("/invitations/accept", post(acceptHandler))
+	mux.HandleFunc("/workspaces", get(workspacesHandler))
+	mux.HandleFunc("/workspaces/create", post(createWorkspaceHandler))
+	mux.HandleFunc("/workspaces/delete", post(workspaceHandler(deleteWorkspaceHandler)))
+	mux.HandleFunc("/workspaces/members", get(workspaceHandler(workspaceMembersHandler)))
+	mux.HandleFunc("/workspaces/add", post(workspaceHandler(addMemberHandler)))
+	mux.HandleFunc("/workspaces/invited", get(invitedWorkspacesHandler))
+	mux.HandleFunc("/workspaces/accept", post(workspaceHandler(acceptWorkspaceHandler)))
+	mux.HandleFunc("/workspaces/remove", post(workspaceHandler(removeMemberHandler)))
+	mux.HandleFunc("/users", get(userHandler))
+	mux.HandleFunc("/users/capacity", post(capacityHandler))
+	mux.HandleFunc("/plan", get(planHandler))
+	mux.HandleFunc("/comments", get(commentsHandler))
+	mux.HandleFunc("/comments/create", post(createCommentHandler))
+	mux.HandleFunc("/comments/edit", post(commentHandler(editCommentHandler)))
+	mux.HandleFunc("/comments/delete", post(commentHandler(deleteCommentHandler)))
+	mux.HandleFunc("/checklist", get(taskHandler(checklistHandler)))
+	mux.HandleFunc("/checklist/add", post(taskHandler(addItemHandler)))
+	mux.HandleFunc("/checklist/toggle", post(taskHandler(toggleItemHandler)))
+	mux.HandleFunc("/checklist/move", post(taskHandler(moveItemHandler)))
+	mux.HandleFunc("/checklist/remove", post(taskHandler(removeItemHandler)))
+	mux.HandleFunc("/checklist/auto-complete", post(taskHandler(autoCompleteHandler)))
+	mux.HandleFunc("/timer", get(runningHandler))
+	mux.HandleFunc("/timer/start", post(startTimerHandler))
+	mux.HandleFunc("/timer/stop", post(stopTimerHandler))
+	mux.HandleFunc("/timer/report", get(reportHandler))
+	mux.HandleFunc("/time-entries", get(taskHandler(entriesHandler)))
+	mux.HandleFunc("/time-entries/create", post(taskHandler(createEntryHandler)))
+	mux.HandleFunc("/time-entries/delete", post(deleteEntryHandler))
+	mux.HandleFunc("/attachments", get(attachmentsHandler))
+	mux.HandleFunc("/attachments/create", post(attachHandler))
+	mux.HandleFunc("/attachments/download", get(attachmentHandler(downloadHandler)))
+	mux.HandleFunc("/attachments/delete", post(attachmentHandler(detachHandler)))
+
+	return mux
+}
+
+func Serve(addr string) error {
+	go purgeTrash(time.Hour)
+
+	return http.ListenAndServe(addr, NewHandler())
+}
+
+func purgeTrash(interval time.Duration) {
+	for range time.Tick(interval) {
+		_, err := taskLib.PurgeExpired(config.Current.TrashRetention())
+
+		if err != nil {
+			log.Println("api: purging the trash failed:", err)
+		}
+	}
+}
+
+func method(name string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != name {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("Method %s not allowed", r.Method))
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+func get(handler http.HandlerFunc) http.HandlerFunc {
+	return method(http.MethodGet, handler)
+}
+
+func post(handler http.HandlerFunc) http.HandlerFunc {
+	return method(http.MethodPost, handler)
+}
+
+func userId(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.Header.Get(USER_HEADER), 10, 64)
+
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("Missing or invalid %s header", USER_HEADER)
+	}
+
+	return id, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	if errs, ok := utils.AsValidationErrors(err); ok {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":  "Validation failed",
+			"fields": errs.Fields(),
+		})
+		return
+	}
+
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// statusOf returns the HTTP status matching an error of the data packages.
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, permission.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, taskLib.ErrTaskNotFound), errors.Is(err, userLib.ErrUserNotFound), errors.Is(err, listLib.ErrListNotFound), errors.Is(err, listLib.ErrInvitationNotFound), errors.Is(err, workspaceLib.ErrWorkspaceNotFound), errors.Is(err, workspaceLib.ErrInvitationNotFound), errors.Is(err, commentLib.ErrCommentNotFound), errors.Is(err, attachmentLib.ErrAttachmentNotFound), errors.Is(err, taskLib.ErrItemNotFound), errors.Is(err, timer.ErrEntryNotFound), errors.Is(err, timer.ErrTimerNotRunning), errors.Is(err, viewLib.ErrViewNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, timer.ErrTimerRunning):
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
+}
+
+func replayHandler(replay func(int64, int) ([]history.Entry, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := userId(r)
+
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		count := 1
+
+		if value := r.URL.Query().Get("count"); value != "" {
+			count, err = strconv.Atoi(value)
+
+			if err != nil || count <= 0 {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("Invalid count %s", value))
+				return
+			}
+		}
+
+		entries, err := replay(id, count)
+
+		if errors.Is(err, undo.ErrNothingToUndo) || errors.Is(err, undo.ErrNothingToRedo) {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, entries)
+	}
+}
+
+func trashHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	tasks, err := taskLib.GetDeletedTasksByUserIdContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if tasks == nil {
+		tasks = []taskLib.Task{}
+	}
+
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func restoreHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	taskId, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("Invalid task id %s", r.URL.Query().Get("id")))
+		return
+	}
+
+	task, err := taskLib.GetTaskContext(r.Context(), taskId)
+
+	if err != nil && !errors.Is(err, taskLib.ErrTaskNotFound) {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err != nil || task.UserId != id || !task.IsDeleted() {
+		writeError(w, http.StatusNotFound, fmt.Errorf("Task %d is not in the trash", taskId))
+		return
+	}
+
+	err = task.RestoreContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, task)
+}
@@ -0,0 +1,765 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	attachmentLib "todolist/attachment"
+	"todolist/history"
+	listLib "todolist/list"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+	"todolist/utils"
+	viewLib "todolist/view"
+	workspaceLib "todolist/workspace"
+)
+
+func request(method string, path string, userId string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+
+	if userId != "" {
+		req.Header.Set(USER_HEADER, userId)
+	}
+
+	rec := httptest.NewRecorder()
+	NewHandler().ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestUndoRedo(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := taskLib.NewTask("TestTask")
+	task.UserId = 1
+	task.Save()
+
+	rec := request(http.MethodPost, "/undo", "1")
+
+	if rec.Code != http.StatusOK {
+		t.Fatal("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+
+	var entries []history.Entry
+	json.NewDecoder(rec.Body).Decode(&entries)
+
+	if len(entries) != 1 || entries[0].Action != history.CREATE_ACTION {
+		t.Error("The creation should be undone but got", entries)
+	}
+	if taskLib.IsTaskExist(task.Id) {
+		t.Error("Task should be deleted")
+	}
+
+	rec = request(http.MethodPost, "/undo", "1")
+
+	if rec.Code != http.StatusConflict {
+		t.Error("Status should be 409 but is", rec.Code)
+	}
+
+	rec = request(http.MethodPost, "/redo?count=1", "1")
+
+	if rec.Code != http.StatusOK {
+		t.Fatal("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+	if !taskLib.IsTaskExist(task.Id) {
+		t.Error("Task should be created again")
+	}
+}
+
+func TestUndoErrors(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	if rec := request(http.MethodGet, "/undo", "1"); rec.Code != http.StatusMethodNotAllowed {
+		t.Error("Status should be 405 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/undo", ""); rec.Code != http.StatusUnauthorized {
+		t.Error("Status should be 401 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/undo?count=abc", "1"); rec.Code != http.StatusBadRequest {
+		t.Error("Status should be 400 but is", rec.Code)
+	}
+}
+
+func TestTrashRestore(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := taskLib.NewTask("TestTask")
+	task.UserId = 1
+	task.Save()
+	task.Delete()
+
+	rec := request(http.MethodGet, "/trash", "1")
+
+	var trash []taskLib.Task
+	json.NewDecoder(rec.Body).Decode(&trash)
+
+	if len(trash) != 1 || trash[0].Id != task.Id {
+		t.Fatal("Trash should hold task", task.Id, "but has", trash)
+	}
+
+	if rec := request(http.MethodPost, "/trash/restore?id=1", "2"); rec.Code != http.StatusNotFound {
+		t.Error("Status should be 404 for another user but is", rec.Code)
+	}
+
+	rec = request(http.MethodPost, "/trash/restore?id=1", "1")
+
+	if rec.Code != http.StatusOK {
+		t.Fatal("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+	if !taskLib.IsTaskExist(task.Id) {
+		t.Error("Task should be restored")
+	}
+}
+
+func TestWriteValidationError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	task := taskLib.NewTask("")
+
+	writeError(rec, http.StatusInternalServerError, task.Validate())
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Error("Status should be 422 but is", rec.Code)
+	}
+
+	var body struct {
+		Fields map[string][]string `json:"fields"`
+	}
+	json.NewDecoder(rec.Body).Decode(&body)
+
+	if len(body.Fields["name"]) != 1 {
+		t.Error("Name should be reported but got", body.Fields)
+	}
+}
+
+func TestSharing(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	for _, email := range []string{"owner@example.com", "editor@example.com"} {
+		user := userLib.NewUser("John", "Doe", email, nil)
+		user.Birthdate = time.Now().AddDate(-30, 0, 0)
+		user.Save()
+	}
+
+	rec := request(http.MethodPost, "/lists/create?name=Groceries", "1")
+
+	if rec.Code != http.StatusCreated {
+		t.Fatal("Status should be 201 but is", rec.Code, rec.Body.String())
+	}
+
+	if rec := request(http.MethodPost, "/lists/create", "1"); rec.Code != http.StatusUnprocessableEntity {
+		t.Error("Status should be 422 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/lists/share?list=1&user=1&role=viewer", "2"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/lists/share?list=1&user=3&role=viewer", "1"); rec.Code != http.StatusNotFound {
+		t.Error("Status should be 404 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/lists/share?list=9&user=2&role=viewer", "1"); rec.Code != http.StatusNotFound {
+		t.Error("Status should be 404 but is", rec.Code)
+	}
+
+	rec = request(http.MethodPost, "/lists/share?list=1&user=2&role=editor", "1")
+
+	if rec.Code != http.StatusOK {
+		t.Fatal("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+
+	task := taskLib.NewTask("Milk")
+	task.UserId = 1
+	task.ListId = 1
+	task.Save()
+
+	rec = request(http.MethodGet, "/tasks", "2")
+
+	var tasks []taskLib.Task
+	json.NewDecoder(rec.Body).Decode(&tasks)
+
+	if len(tasks) != 1 || tasks[0].Name != "Milk" {
+		t.Error("Editor should see the shared task but got", tasks)
+	}
+
+	rec = request(http.MethodGet, "/lists", "2")
+
+	var lists []listLib.List
+	json.NewDecoder(rec.Body).Decode(&lists)
+
+	if len(lists) != 1 || lists[0].Name != "Groceries" {
+		t.Error("Editor should see the shared list but got", lists)
+	}
+
+	if rec := request(http.MethodPost, "/lists/invite?list=1&email=guest@example.com&role=viewer", "1"); rec.Code != http.StatusCreated {
+		t.Error("Status should be 201 but is", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodPost, "/invitations/accept?token=unknown", "2"); rec.Code != http.StatusNotFound {
+		t.Error("Status should be 404 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/lists/unshare?list=1&user=2", "2"); rec.Code != http.StatusNoContent {
+		t.Error("Status should be 204 but is", rec.Code)
+	}
+	if rec := request(http.MethodGet, "/lists/members?list=1", "2"); rec.Code != http.StatusNotFound {
+		t.Error("Status should be 404 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/lists/delete?list=1", "1"); rec.Code != http.StatusNoContent {
+		t.Error("Status should be 204 but is", rec.Code)
+	}
+}
+
+func TestAssign(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	for _, email := range []string{"owner@example.com", "assignee@example.com"} {
+		user := userLib.NewUser("John", "Doe", email, nil)
+		user.Birthdate = time.Now().AddDate(-30, 0, 0)
+		user.Save()
+	}
+
+	task := taskLib.NewTask("Report")
+	task.UserId = 1
+	task.Save()
+
+	if rec := request(http.MethodPost, "/tasks/assign?id=1&user=1", "2"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/tasks/assign?id=1&user=3", "1"); rec.Code != http.StatusNotFound {
+		t.Error("Status should be 404 but is", rec.Code)
+	}
+
+	rec := request(http.MethodPost, "/tasks/assign?id=1&user=2", "1")
+
+	if rec.Code != http.StatusOK {
+		t.Fatal("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+
+	rec = request(http.MethodGet, "/tasks/assigned", "2")
+
+	var tasks []taskLib.Task
+	json.NewDecoder(rec.Body).Decode(&tasks)
+
+	if len(tasks) != 1 || tasks[0].Name != "Report" {
+		t.Error("Assignee should see the assigned task but got", tasks)
+	}
+
+	if rec := request(http.MethodPost, "/tasks/assign?id=1", "1"); rec.Code != http.StatusOK {
+		t.Error("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+
+	rec = request(http.MethodGet, "/tasks/assigned", "2")
+	tasks = nil
+	json.NewDecoder(rec.Body).Decode(&tasks)
+
+	if len(tasks) != 0 {
+		t.Error("Unassigned task should not be listed but got", tasks)
+	}
+}
+
+func TestWorkspaces(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	for _, email := range []string{"admin@example.com", "member@example.com", "outsider@example.com"} {
+		user := userLib.NewUser("John", "Doe", email, nil)
+		user.Birthdate = time.Now().AddDate(-30, 0, 0)
+		user.Save()
+	}
+
+	if rec := request(http.MethodPost, "/workspaces/create?name=Acme", "1"); rec.Code != http.StatusCreated {
+		t.Fatal("Status should be 201 but is", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodPost, "/workspaces/add?workspace=1&user=2&role=owner", "1"); rec.Code != http.StatusUnprocessableEntity {
+		t.Error("Status should be 422 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/workspaces/add?workspace=1&user=2&role=member", "3"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/workspaces/add?workspace=9&user=2&role=member", "1"); rec.Code != http.StatusNotFound {
+		t.Error("Status should be 404 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/workspaces/add?workspace=1&user=2&role=member", "1"); rec.Code != http.StatusOK {
+		t.Fatal("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodPost, "/lists/create?name=Roadmap&workspace=1", "3"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := request(http.MethodGet, "/users?id=2", "1"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/lists/create?name=Roadmap&workspace=1", "2"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+
+	rec := request(http.MethodGet, "/workspaces/invited", "2")
+
+	var invited []workspaceLib.Workspace
+	json.NewDecoder(rec.Body).Decode(&invited)
+
+	if len(invited) != 1 || invited[0].Id != 1 {
+		t.Error("Member should be invited to the workspace but got", invited)
+	}
+
+	if rec := request(http.MethodPost, "/workspaces/accept?workspace=1", "3"); rec.Code != http.StatusNotFound {
+		t.Error("Status should be 404 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/workspaces/accept?workspace=1", "2"); rec.Code != http.StatusOK {
+		t.Fatal("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodPost, "/lists/create?name=Roadmap&workspace=1", "2"); rec.Code != http.StatusCreated {
+		t.Fatal("Status should be 201 but is", rec.Code, rec.Body.String())
+	}
+
+	rec = request(http.MethodGet, "/lists", "1")
+
+	var lists []listLib.List
+	json.NewDecoder(rec.Body).Decode(&lists)
+
+	if len(lists) != 1 || lists[0].WorkspaceId != 1 {
+		t.Error("Admin should see the workspace list but got", lists)
+	}
+
+	if rec := request(http.MethodGet, "/users?id=2", "1"); rec.Code != http.StatusOK {
+		t.Error("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodGet, "/users?id=2", "3"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/workspaces/delete?workspace=1", "2"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/workspaces/delete?workspace=1", "1"); rec.Code != http.StatusNoContent {
+		t.Error("Status should be 204 but is", rec.Code)
+	}
+}
+
+func postBody(path string, userId string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(USER_HEADER, userId)
+
+	rec := httptest.NewRecorder()
+	NewHandler().ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestComments(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	for _, email := range []string{"owner@example.com", "outsider@example.com"} {
+		user := userLib.NewUser("John", "Doe", email, nil)
+		user.Birthdate = time.Now().AddDate(-30, 0, 0)
+		user.Save()
+	}
+
+	task := taskLib.NewTask("Report")
+	task.UserId = 1
+	task.Save()
+
+	if rec := postBody("/comments/create?task=1", "1", "Draft is *ready*"); rec.Code != http.StatusCreated {
+		t.Fatal("Status should be 201 but is", rec.Code, rec.Body.String())
+	}
+	if rec := postBody("/comments/create?task=1", "1", " "); rec.Code != http.StatusUnprocessableEntity {
+		t.Error("Status should be 422 but is", rec.Code)
+	}
+	if rec := postBody("/comments/create?task=1", "2", "Hello"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := postBody("/comments/edit?id=1", "1", "Draft is *done*"); rec.Code != http.StatusOK {
+		t.Error("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+	if rec := postBody("/comments/edit?id=9", "1", "Hello"); rec.Code != http.StatusNotFound {
+		t.Error("Status should be 404 but is", rec.Code)
+	}
+	if rec := request(http.MethodGet, "/comments?task=1", "2"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+
+	rec := request(http.MethodGet, "/comments?task=1", "1")
+
+	var comments []map[string]any
+	json.NewDecoder(rec.Body).Decode(&comments)
+
+	if len(comments) != 1 || comments[0]["body"] != "Draft is *done*" {
+		t.Error("Thread should have the edited comment but got", comments)
+	}
+
+	if rec := request(http.MethodPost, "/comments/delete?id=1", "2"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/comments/delete?id=1", "1"); rec.Code != http.StatusNoContent {
+		t.Error("Status should be 204 but is", rec.Code)
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	defer func(store attachmentLib.Store) { attachmentLib.StoreInstance = store }(attachmentLib.StoreInstance)
+	attachmentLib.StoreInstance = attachmentLib.NewLocalStore(t.TempDir())
+
+	task := taskLib.NewTask("Report")
+	task.UserId = 1
+	task.Save()
+
+	if rec := postBody("/attachments/create?task=1&name=report.md", "2", "# Report"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := postBody("/attachments/create?task=1&name=report.md", "1", "# Report"); rec.Code != http.StatusCreated {
+		t.Fatal("Status should be 201 but is", rec.Code, rec.Body.String())
+	}
+
+	rec := request(http.MethodGet, "/attachments/download?id=1", "1")
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "# Report" || rec.Header().Get("Content-Type") != "text/markdown; charset=utf-8" {
+		t.Error("Download should return the file but got", rec.Code, rec.Header(), rec.Body.String())
+	}
+
+	if rec := request(http.MethodGet, "/attachments/download?id=1", "2"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := request(http.MethodGet, "/attachments/download?id=9", "1"); rec.Code != http.StatusNotFound {
+		t.Error("Status should be 404 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/attachments/delete?id=1", "1"); rec.Code != http.StatusNoContent {
+		t.Error("Status should be 204 but is", rec.Code)
+	}
+
+	rec = request(http.MethodGet, "/attachments?task=1", "1")
+
+	if rec.Body.String() != "[]\n" {
+		t.Error("Task should have no attachment but got", rec.Body.String())
+	}
+}
+
+func TestChecklist(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := taskLib.NewTask("Trip")
+	task.UserId = 1
+	task.Save()
+
+	if rec := request(http.MethodPost, "/checklist/auto-complete?task=1&enabled=true", "1"); rec.Code != http.StatusOK {
+		t.Fatal("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+	for _, text := range []string{"Book train", "Pack"} {
+		if rec := postBody("/checklist/add?task=1", "1", text); rec.Code != http.StatusCreated {
+			t.Fatal("Status should be 201 but is", rec.Code, rec.Body.String())
+		}
+	}
+	if rec := postBody("/checklist/add?task=1", "1", ""); rec.Code != http.StatusUnprocessableEntity {
+		t.Error("Status should be 422 but is", rec.Code)
+	}
+	if rec := postBody("/checklist/add?task=1", "2", "Hello"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/checklist/move?task=1&item=2&position=0", "1"); rec.Code != http.StatusOK {
+		t.Error("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodPost, "/checklist/toggle?task=1&item=9", "1"); rec.Code != http.StatusNotFound {
+		t.Error("Status should be 404 but is", rec.Code)
+	}
+
+	request(http.MethodPost, "/checklist/toggle?task=1&item=1", "1")
+	rec := request(http.MethodPost, "/checklist/toggle?task=1&item=2", "1")
+
+	var updated taskLib.Task
+	json.NewDecoder(rec.Body).Decode(&updated)
+
+	if !updated.Completed || updated.ChecklistChecked != 2 {
+		t.Error("Task should be completed with its checklist but got", updated)
+	}
+
+	request(http.MethodPost, "/checklist/remove?task=1&item=1", "1")
+	rec = request(http.MethodGet, "/checklist?task=1", "1")
+
+	var items []taskLib.ChecklistItem
+	json.NewDecoder(rec.Body).Decode(&items)
+
+	if len(items) != 1 || items[0].Text != "Pack" || items[0].Position != 0 {
+		t.Error("Checklist should only hold the moved item but got", items)
+	}
+}
+
+func TestWorkflow(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	request(http.MethodPost, "/lists/create?name=Sprint", "1")
+
+	task := taskLib.NewTask("Ship it")
+	task.UserId = 1
+	task.ListId = 1
+	task.Save()
+
+	body := `{"statuses": [{"name": "todo"}, {"name": "doing", "wip_limit": 1}, {"name": "done", "terminal": true}], "transitions": [{"from": "todo", "to": "doing"}, {"from": "doing", "to": "done"}]}`
+
+	if rec := postBody("/lists/workflow/set?list=1", "2", body); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := postBody("/lists/workflow/set?list=1", "1", `{"statuses": []}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Error("Status should be 422 but is", rec.Code)
+	}
+	if rec := postBody("/lists/workflow/set?list=1", "1", body); rec.Code != http.StatusOK {
+		t.Fatal("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodPost, "/tasks/status?task=1&status=done", "1"); rec.Code != http.StatusUnprocessableEntity {
+		t.Error("Status should be 422 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/tasks/status?task=1&status=doing", "1"); rec.Code != http.StatusOK {
+		t.Error("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodGet, "/lists/board?list=1", "2"); rec.Code != http.StatusNotFound {
+		t.Error("Status should be 404 but is", rec.Code)
+	}
+
+	var board []taskLib.BoardColumn
+	json.NewDecoder(request(http.MethodGet, "/lists/board?list=1", "1").Body).Decode(&board)
+
+	if len(board) != 3 || len(board[1].Tasks) != 1 || board[1].Status.WIPLimit != 1 {
+		t.Error("Board should have the task in doing but got", board)
+	}
+
+	var changes []taskLib.StatusChange
+	json.NewDecoder(request(http.MethodGet, "/tasks/status-history?task=1", "1").Body).Decode(&changes)
+
+	if len(changes) != 2 || changes[1].To != "doing" {
+		t.Error("Status history should end in doing but got", changes)
+	}
+}
+
+func TestTimer(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := taskLib.NewTask("Website")
+	task.UserId = 1
+	task.Label = "acme"
+	task.Save()
+
+	if rec := request(http.MethodGet, "/timer", "1"); rec.Code != http.StatusNotFound {
+		t.Error("Status should be 404 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/timer/start?task=1&note=Kickoff", "2"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/timer/start?task=1&note=Kickoff", "1"); rec.Code != http.StatusCreated {
+		t.Fatal("Status should be 201 but is", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodPost, "/timer/start?task=1", "1"); rec.Code != http.StatusConflict {
+		t.Error("Status should be 409 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/timer/stop", "1"); rec.Code != http.StatusOK {
+		t.Error("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodPost, "/time-entries/create?task=1&start=2026-03-03T09:00:00Z&end=2026-03-03T08:00:00Z", "1"); rec.Code != http.StatusUnprocessableEntity {
+		t.Error("Status should be 422 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/time-entries/create?task=1&start=2026-03-03T09:00:00Z&end=2026-03-03T11:00:00Z", "1"); rec.Code != http.StatusCreated {
+		t.Error("Status should be 201 but is", rec.Code, rec.Body.String())
+	}
+
+	var entries []map[string]any
+	json.NewDecoder(request(http.MethodGet, "/time-entries?task=1", "1").Body).Decode(&entries)
+
+	if len(entries) != 2 {
+		t.Error("Task should have 2 entries but got", entries)
+	}
+
+	rec := request(http.MethodGet, "/timer/report?from=2026-03-02T00:00:00Z&to=2026-03-09T00:00:00Z", "1")
+
+	var report struct {
+		Total  time.Duration
+		Labels []struct {
+			Label    string
+			Duration time.Duration
+		}
+	}
+	json.NewDecoder(rec.Body).Decode(&report)
+
+	if report.Total != 2*time.Hour || len(report.Labels) != 1 || report.Labels[0].Label != "acme" {
+		t.Error("Report should count 2 hours of acme but got", rec.Body.String())
+	}
+
+	if rec := request(http.MethodPost, "/time-entries/delete?id=2", "2"); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/time-entries/delete?id=2", "1"); rec.Code != http.StatusNoContent {
+		t.Error("Status should be 204 but is", rec.Code)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	user := userLib.NewUser("John", "Doe", "john@example.com", nil)
+	user.Birthdate = time.Now().AddDate(-30, 0, 0)
+	user.Save()
+
+	task := taskLib.NewTask("Report")
+	task.UserId = 1
+	task.Save()
+
+	if rec := request(http.MethodPost, "/tasks/estimate?task=1&estimate=soon", "1"); rec.Code != http.StatusBadRequest {
+		t.Error("Status should be 400 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/tasks/estimate?task=1&estimate=2pt", "1"); rec.Code != http.StatusOK {
+		t.Error("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodGet, "/plan", "1"); rec.Code != http.StatusUnprocessableEntity {
+		t.Error("Status should be 422 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/users/capacity?minutes=2000", "1"); rec.Code != http.StatusUnprocessableEntity {
+		t.Error("Status should be 422 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/users/capacity?minutes=60&point_minutes=45", "1"); rec.Code != http.StatusOK {
+		t.Error("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+
+	var plan taskLib.Plan
+	rec := request(http.MethodGet, "/plan?days=3", "1")
+	json.NewDecoder(rec.Body).Decode(&plan)
+
+	if rec.Code != http.StatusOK {
+		t.Fatal("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+
+	if len(plan.Days) != 3 || plan.Days[0].Planned != 60 || plan.Days[1].Planned != 30 {
+		t.Error("Plan should spread 90 minutes over 2 days but got", plan.Days)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	for _, name := range []string{"Someday", "Outage"} {
+		task := taskLib.NewTask(name)
+		task.UserId = 1
+		task.Save()
+	}
+
+	if rec := request(http.MethodPost, "/tasks/priority?task=2&priority=urgent", "1"); rec.Code != http.StatusBadRequest {
+		t.Error("Status should be 400 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/tasks/priority?task=2&priority=high", "1"); rec.Code != http.StatusOK {
+		t.Error("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodGet, "/tasks?sort=name", "1"); rec.Code != http.StatusUnprocessableEntity {
+		t.Error("Status should be 422 but is", rec.Code)
+	}
+
+	var tasks []taskLib.Task
+	json.NewDecoder(request(http.MethodGet, "/tasks", "1").Body).Decode(&tasks)
+
+	if len(tasks) != 2 || tasks[0].Id != 2 || tasks[0].Priority != taskLib.HIGH_PRIORITY {
+		t.Error("Most urgent task should come first but got", tasks)
+	}
+}
+
+func TestView(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	user := userLib.NewUser("John", "Doe", "john@example.com", nil)
+	user.Birthdate = time.Now().AddDate(-30, 0, 0)
+	user.Save()
+
+	for i, date := range []time.Time{time.Now().AddDate(0, 0, -1), {}} {
+		task := taskLib.NewTask(fmt.Sprintf("Task %d", i+1))
+		task.UserId = 1
+		task.EndDate = date
+		task.Save()
+	}
+
+	if rec := request(http.MethodGet, "/tasks/view?view=tomorrow", "1"); rec.Code != http.StatusUnprocessableEntity {
+		t.Error("Status should be 422 but is", rec.Code)
+	}
+
+	cases := map[string]int{"overdue": 1, "no-date": 2, "completed": 0}
+
+	for view, expected := range cases {
+		var tasks []taskLib.Task
+		rec := request(http.MethodGet, "/tasks/view?view="+view, "1")
+		json.NewDecoder(rec.Body).Decode(&tasks)
+
+		if rec.Code != http.StatusOK {
+			t.Fatal("Status should be 200 but is", rec.Code, rec.Body.String())
+		}
+
+		if expected == 0 && len(tasks) != 0 || expected != 0 && (len(tasks) != 1 || tasks[0].Id != int64(expected)) {
+			t.Error("View", view, "should have task", expected, "but got", tasks)
+		}
+	}
+}
+
+func TestSavedViews(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	user := userLib.NewUser("John", "Doe", "john@example.com", nil)
+	user.Birthdate = time.Now().AddDate(-30, 0, 0)
+	user.Save()
+
+	for _, label := range []string{"work", "home"} {
+		task := taskLib.NewTask("Task " + label)
+		task.UserId = 1
+		task.Label = label
+		task.Save()
+	}
+
+	if rec := postBody("/views/save", "1", `{"name": "today"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Error("Status should be 422 but is", rec.Code)
+	}
+	if rec := postBody("/views/save", "1", `{"name": "work", "filter": "#work", "columns": ["id", "name"]}`); rec.Code != http.StatusCreated {
+		t.Fatal("Status should be 201 but is", rec.Code, rec.Body.String())
+	}
+	if rec := postBody("/views/save", "2", `{"id": 1, "name": "mine"}`); rec.Code != http.StatusForbidden {
+		t.Error("Status should be 403 but is", rec.Code)
+	}
+	if rec := postBody("/views/save", "1", `{"id": 1, "name": "work", "group_by": "label"}`); rec.Code != http.StatusOK {
+		t.Error("Status should be 200 but is", rec.Code, rec.Body.String())
+	}
+
+	var views []viewLib.View
+	json.NewDecoder(request(http.MethodGet, "/views", "1").Body).Decode(&views)
+
+	if len(views) != 1 || views[0].GroupBy != "label" || views[0].Filter != "" {
+		t.Error("Views should hold the updated view but got", views)
+	}
+
+	var groups []viewLib.Group
+	rec := request(http.MethodGet, "/views/run?name=work", "1")
+	json.NewDecoder(rec.Body).Decode(&groups)
+
+	if rec.Code != http.StatusOK || len(groups) != 2 || groups[0].Key != "home" || groups[1].Key != "work" {
+		t.Error("View should group the tasks by label but got", rec.Code, groups)
+	}
+
+	groups = nil
+	json.NewDecoder(request(http.MethodGet, "/views/run?name=no-date", "1").Body).Decode(&groups)
+
+	if len(groups) != 1 || len(groups[0].Tasks) != 2 {
+		t.Error("Smart view should be a single group but got", groups)
+	}
+
+	if rec := request(http.MethodPost, "/views/delete?name=work", "2"); rec.Code != http.StatusNotFound {
+		t.Error("Status should be 404 but is", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/views/delete?name=work", "1"); rec.Code != http.StatusNoContent {
+		t.Error("Status should be 204 but is", rec.Code)
+	}
+	if rec := request(http.MethodGet, "/views/run?name=work", "1"); rec.Code != http.StatusNotFound {
+		t.Error("Status should be 404 but is", rec.Code)
+	}
+}
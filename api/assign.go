@@ -0,0 +1,94 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+)
+
+func assignedHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	tasks, err := taskLib.GetTasksByAssigneeIdContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = taskLib.SortTasks(tasks, r.URL.Query().Get("sort"), time.Now())
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	if tasks == nil {
+		tasks = []taskLib.Task{}
+	}
+
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+// assignHandler assigns the task given by the id parameter to the user
+// parameter, an empty user unassigns it.
+func assignHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	taskId, err := queryId(r, "id")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var assigneeId int64
+
+	if r.URL.Query().Get("user") != "" {
+		assigneeId, err = queryId(r, "user")
+
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	user, err := userLib.GetUserContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	task, err := taskLib.GetTaskAsContext(r.Context(), taskId, id)
+
+	if err == nil {
+		err = user.AssignTaskContext(r.Context(), &task, assigneeId)
+	}
+
+	// the task is assigned even when the assignee could not be notified
+	if errors.Is(err, taskLib.ErrAssigneeNotNotified) {
+		log.Println("api:", err)
+		err = nil
+	}
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, task)
+}
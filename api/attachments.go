@@ -0,0 +1,137 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	attachmentLib "todolist/attachment"
+	taskLib "todolist/task"
+)
+
+func attachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	taskId, err := queryId(r, "task")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = taskLib.GetTaskAsContext(r.Context(), taskId, id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	attachments, err := attachmentLib.GetAttachmentsByTaskIdContext(r.Context(), taskId)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if attachments == nil {
+		attachments = []attachmentLib.Attachment{}
+	}
+
+	writeJSON(w, http.StatusOK, attachments)
+}
+
+// attachHandler stores the request body as the file given by the name
+// parameter on the task given by the task parameter.
+func attachHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	taskId, err := queryId(r, "task")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	attachment, err := attachmentLib.AttachContext(r.Context(), taskId, id, r.URL.Query().Get("name"), r.Body)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, attachment)
+}
+
+func downloadHandler(w http.ResponseWriter, r *http.Request, userId int64, attachment attachmentLib.Attachment) {
+	_, err := taskLib.GetTaskAsContext(r.Context(), attachment.TaskId, userId)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	blob, err := attachment.Open()
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", attachment.MimeType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(attachment.Name))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, blob)
+}
+
+func detachHandler(w http.ResponseWriter, r *http.Request, userId int64, attachment attachmentLib.Attachment) {
+	err := attachment.DeleteContext(r.Context(), userId)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// attachmentHandler loads the attachment given by the id parameter before
+// calling handler with the user of the request.
+func attachmentHandler(handler func(w http.ResponseWriter, r *http.Request, userId int64, attachment attachmentLib.Attachment)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := userId(r)
+
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		attachmentId, err := queryId(r, "id")
+
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		attachment, err := attachmentLib.GetAttachmentContext(r.Context(), attachmentId)
+
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+
+		handler(w, r, id, attachment)
+	}
+}
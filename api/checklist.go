@@ -0,0 +1,139 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	taskLib "todolist/task"
+)
+
+func checklistHandler(w http.ResponseWriter, r *http.Request, userId int64, task taskLib.Task) {
+	items, err := task.ChecklistContext(r.Context())
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if items == nil {
+		items = []taskLib.ChecklistItem{}
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+// addItemHandler appends the request body as an item of the checklist.
+func addItemHandler(w http.ResponseWriter, r *http.Request, userId int64, task taskLib.Task) {
+	text, err := readBody(r)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	item, err := task.AddItemContext(r.Context(), userId, text)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, item)
+}
+
+func toggleItemHandler(w http.ResponseWriter, r *http.Request, userId int64, task taskLib.Task) {
+	editItem(w, r, func(itemId int64) error {
+		return task.ToggleItemContext(r.Context(), userId, itemId)
+	}, &task)
+}
+
+// moveItemHandler moves the item to the position parameter, counted from 0.
+func moveItemHandler(w http.ResponseWriter, r *http.Request, userId int64, task taskLib.Task) {
+	position, err := strconv.Atoi(r.URL.Query().Get("position"))
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	editItem(w, r, func(itemId int64) error {
+		return task.MoveItemContext(r.Context(), userId, itemId, position)
+	}, &task)
+}
+
+func removeItemHandler(w http.ResponseWriter, r *http.Request, userId int64, task taskLib.Task) {
+	editItem(w, r, func(itemId int64) error {
+		return task.RemoveItemContext(r.Context(), userId, itemId)
+	}, &task)
+}
+
+// editItem calls edit with the item parameter and answers with the task,
+// which holds the new checklist counts.
+func editItem(w http.ResponseWriter, r *http.Request, edit func(itemId int64) error, task *taskLib.Task) {
+	itemId, err := queryId(r, "item")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = edit(itemId)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, task)
+}
+
+// autoCompleteHandler sets whether checking the whole checklist completes
+// the task from the enabled parameter.
+func autoCompleteHandler(w http.ResponseWriter, r *http.Request, userId int64, task taskLib.Task) {
+	enabled, err := strconv.ParseBool(strings.TrimSpace(r.URL.Query().Get("enabled")))
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	task.AutoComplete = enabled
+
+	err = task.SaveContext(r.Context(), userId)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, task)
+}
+
+// taskHandler loads the task given by the task parameter, when the user of
+// the request may read it, before calling handler.
+func taskHandler(handler func(w http.ResponseWriter, r *http.Request, userId int64, task taskLib.Task)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := userId(r)
+
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		taskId, err := queryId(r, "task")
+
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		task, err := taskLib.GetTaskAsContext(r.Context(), taskId, id)
+
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+
+		handler(w, r, id, task)
+	}
+}
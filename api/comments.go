@@ -0,0 +1,146 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	commentLib "todolist/comment"
+	taskLib "todolist/task"
+)
+
+func commentsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	taskId, err := queryId(r, "task")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = taskLib.GetTaskAsContext(r.Context(), taskId, id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	comments, err := commentLib.GetCommentsByTaskIdContext(r.Context(), taskId)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if comments == nil {
+		comments = []commentLib.Comment{}
+	}
+
+	writeJSON(w, http.StatusOK, comments)
+}
+
+// createCommentHandler posts the Markdown request body on the task given by
+// the task parameter.
+func createCommentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	taskId, err := queryId(r, "task")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	body, err := readBody(r)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	comment := commentLib.NewComment(taskId, id, body)
+
+	err = comment.SaveContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, comment)
+}
+
+func editCommentHandler(w http.ResponseWriter, r *http.Request, userId int64, comment commentLib.Comment) {
+	body, err := readBody(r)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	comment.Body = body
+
+	err = comment.SaveContext(r.Context(), userId)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, comment)
+}
+
+func deleteCommentHandler(w http.ResponseWriter, r *http.Request, userId int64, comment commentLib.Comment) {
+	err := comment.DeleteContext(r.Context(), userId)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// commentHandler loads the comment given by the id parameter before calling
+// handler with the user of the request.
+func commentHandler(handler func(w http.ResponseWriter, r *http.Request, userId int64, comment commentLib.Comment)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := userId(r)
+
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		commentId, err := queryId(r, "id")
+
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		comment, err := commentLib.GetCommentContext(r.Context(), commentId)
+
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+
+		handler(w, r, id, comment)
+	}
+}
+
+func readBody(r *http.Request) (string, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, commentLib.MAX_BODY_LENGTH+1))
+
+	return string(body), err
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	listLib "todolist/list"
+	taskLib "todolist/task"
+)
+
+// tasksHandler lists the tasks visible to the user in the order of the sort
+// parameter, the most urgent first by default.
+func tasksHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	tasks, err := taskLib.GetVisibleTasksContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = taskLib.SortTasks(tasks, r.URL.Query().Get("sort"), time.Now())
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	if tasks == nil {
+		tasks = []taskLib.Task{}
+	}
+
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func listsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	lists, err := listLib.GetListsByUserIdContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if lists == nil {
+		lists = []listLib.List{}
+	}
+
+	writeJSON(w, http.StatusOK, lists)
+}
+
+func createListHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	list := listLib.NewList(r.URL.Query().Get("name"), id)
+
+	if r.URL.Query().Get("workspace") != "" {
+		list.WorkspaceId, err = queryId(r, "workspace")
+
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	err = list.SaveContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, list)
+}
+
+func queryId(r *http.Request, name string) (int64, error) {
+	value := r.URL.Query().Get(name)
+	id, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("Invalid %s id %s", name, value)
+	}
+
+	return id, nil
+}
+
+// listHandler loads the list given by the list parameter before calling
+// handler with the user of the request.
+func listHandler(handler func(w http.ResponseWriter, r *http.Request, userId int64, list listLib.List)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := userId(r)
+
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		listId, err := queryId(r, "list")
+
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		list, err := listLib.GetListContext(r.Context(), listId)
+
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+
+		handler(w, r, id, list)
+	}
+}
+
+func membersHandler(w http.ResponseWriter, r *http.Request, userId int64, list listLib.List) {
+	role, err := list.RoleContext(r.Context(), userId)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if role == "" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %d", listLib.ErrListNotFound, list.Id))
+		return
+	}
+
+	members, err := list.MembersContext(r.Context())
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, members)
+}
+
+func shareHandler(w http.ResponseWriter, r *http.Request, userId int64, list listLib.List) {
+	memberId, err := queryId(r, "user")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = list.ShareContext(r.Context(), userId, memberId, r.URL.Query().Get("role"))
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	membersHandler(w, r, userId, list)
+}
+
+func unshareHandler(w http.ResponseWriter, r *http.Request, userId int64, list listLib.List) {
+	memberId, err := queryId(r, "user")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = list.UnshareContext(r.Context(), userId, memberId)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func inviteHandler(w http.ResponseWriter, r *http.Request, userId int64, list listLib.List) {
+	invitation, err := list.InviteContext(r.Context(), userId, r.URL.Query().Get("email"), r.URL.Query().Get("role"))
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, invitation)
+}
+
+func deleteListHandler(w http.ResponseWriter, r *http.Request, userId int64, list listLib.List) {
+	err := list.DeleteContext(r.Context(), userId)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func acceptHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	list, err := listLib.AcceptInvitationContext(r.Context(), r.URL.Query().Get("token"), id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+)
+
+// estimateHandler sets the estimate of the task from the estimate parameter,
+// e.g. 90, 1h30m or 3pt, 0 removes it.
+func estimateHandler(w http.ResponseWriter, r *http.Request, userId int64, task taskLib.Task) {
+	estimate, unit, err := taskLib.ParseEstimate(r.URL.Query().Get("estimate"))
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	task.Estimate, task.EstimateUnit = estimate, unit
+
+	err = task.SaveContext(r.Context(), userId)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, task)
+}
+
+// priorityHandler sets the priority of the task from the priority parameter,
+// a level name or its number.
+func priorityHandler(w http.ResponseWriter, r *http.Request, userId int64, task taskLib.Task) {
+	priority, err := taskLib.ParsePriority(r.URL.Query().Get("priority"))
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	task.Priority = priority
+
+	err = task.SaveContext(r.Context(), userId)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, task)
+}
+
+// capacityHandler sets the daily capacity of the user from the minutes
+// parameter and, when given, the minutes a point stands for.
+func capacityHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	minutes, err := strconv.Atoi(strings.TrimSpace(r.URL.Query().Get("minutes")))
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("Invalid minutes: %w", err))
+		return
+	}
+
+	user, err := userLib.GetUserContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	user.Preferences.DailyCapacity = minutes
+
+	if value := strings.TrimSpace(r.URL.Query().Get("point_minutes")); value != "" {
+		user.Preferences.PointMinutes, err = strconv.Atoi(value)
+
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("Invalid point_minutes: %w", err))
+			return
+		}
+	}
+
+	err = user.SaveContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	user.Password = ""
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+// planHandler proposes a schedule of the open tasks of the user over the
+// number of days given by the days parameter, 7 by default.
+func planHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	days := 7
+
+	if value := strings.TrimSpace(r.URL.Query().Get("days")); value != "" {
+		days, err = strconv.Atoi(value)
+
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("Invalid days: %w", err))
+			return
+		}
+	}
+
+	user, err := userLib.GetUserContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	plan, err := user.PlanContext(r.Context(), time.Now(), days)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, plan)
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+	taskLib "todolist/task"
+	"todolist/timer"
+)
+
+func runningHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	entry, err := timer.RunningContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// startTimerHandler starts a timer on the task given by the task parameter,
+// with the note parameter.
+func startTimerHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	taskId, err := queryId(r, "task")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	entry, err := timer.StartContext(r.Context(), taskId, id, r.URL.Query().Get("note"))
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+func stopTimerHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	entry, err := timer.StopContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+func entriesHandler(w http.ResponseWriter, r *http.Request, userId int64, task taskLib.Task) {
+	entries, err := timer.GetEntriesByTaskIdContext(r.Context(), task.Id)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if entries == nil {
+		entries = []timer.Entry{}
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// createEntryHandler records the time from the start to the end parameters,
+// written in RFC 3339, on the task.
+func createEntryHandler(w http.ResponseWriter, r *http.Request, userId int64, task taskLib.Task) {
+	start, err := queryTime(r, "start")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	end, err := queryTime(r, "end")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	entry := timer.NewEntry(task.Id, userId, end, end.Sub(start))
+	entry.Note = r.URL.Query().Get("note")
+
+	err = entry.SaveContext(r.Context(), userId)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+func deleteEntryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	entryId, err := queryId(r, "id")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	entry, err := timer.GetEntryContext(r.Context(), entryId)
+
+	if err == nil {
+		err = entry.DeleteContext(r.Context(), id)
+	}
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reportHandler sums the time of the user between the from and to
+// parameters, written in RFC 3339.
+func reportHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	from, err := queryTime(r, "from")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	to, err := queryTime(r, "to")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := timer.GetReportContext(r.Context(), id, from, to)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func queryTime(r *http.Request, name string) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	date, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid %s %q, expected RFC 3339", name, value)
+	}
+
+	return date, nil
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+	viewLib "todolist/view"
+)
+
+// viewHandler lists the tasks of the smart view given by the view parameter.
+func viewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	user, err := userLib.GetUserContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	tasks, err := user.ViewContext(r.Context(), r.URL.Query().Get("view"), time.Now())
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func savedViewsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	views, err := viewLib.GetViewsByUserIdContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if views == nil {
+		views = []viewLib.View{}
+	}
+
+	writeJSON(w, http.StatusOK, views)
+}
+
+// saveViewHandler creates the view given as JSON body, or updates it when
+// the body carries its id.
+func saveViewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	view := viewLib.NewView(id, "")
+
+	err = json.NewDecoder(r.Body).Decode(&view)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("Invalid view: %w", err))
+		return
+	}
+
+	status := http.StatusOK
+
+	if view.Id == 0 {
+		status = http.StatusCreated
+	}
+
+	view.UserId = id
+
+	err = view.SaveContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, status, view)
+}
+
+func deleteViewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	view, err := viewLib.GetViewByNameContext(r.Context(), id, r.URL.Query().Get("name"))
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	err = view.DeleteContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// runViewHandler lists the groups of tasks of the view given by the name
+// parameter, a smart view comes as a single group.
+func runViewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+
+	if taskLib.IsView(name) {
+		user, err := userLib.GetUserContext(r.Context(), id)
+
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+
+		tasks, err := user.ViewContext(r.Context(), name, time.Now())
+
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, []viewLib.Group{{Tasks: tasks}})
+		return
+	}
+
+	view, err := viewLib.GetViewByNameContext(r.Context(), id, name)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	groups, err := view.RunContext(r.Context(), time.Now())
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, groups)
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	listLib "todolist/list"
+	taskLib "todolist/task"
+)
+
+func workflowHandler(w http.ResponseWriter, r *http.Request, userId int64, list listLib.List) {
+	err := requireMember(r, userId, list)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	workflow, err := taskLib.GetWorkflowContext(r.Context(), list.Id)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, workflow)
+}
+
+// setWorkflowHandler replaces the workflow of the list with the JSON request
+// body.
+func setWorkflowHandler(w http.ResponseWriter, r *http.Request, userId int64, list listLib.List) {
+	var workflow taskLib.Workflow
+
+	err := json.NewDecoder(r.Body).Decode(&workflow)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("Invalid workflow: %w", err))
+		return
+	}
+
+	workflow.ListId = list.Id
+
+	err = workflow.SaveContext(r.Context(), userId)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, workflow)
+}
+
+func boardHandler(w http.ResponseWriter, r *http.Request, userId int64, list listLib.List) {
+	err := requireMember(r, userId, list)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	board, err := taskLib.GetBoardContext(r.Context(), list.Id)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, board)
+}
+
+// statusHandler moves the task to the status parameter.
+func statusHandler(w http.ResponseWriter, r *http.Request, userId int64, task taskLib.Task) {
+	err := task.MoveContext(r.Context(), userId, r.URL.Query().Get("status"))
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, task)
+}
+
+func statusHistoryHandler(w http.ResponseWriter, r *http.Request, userId int64, task taskLib.Task) {
+	changes, err := task.StatusHistoryContext(r.Context())
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if changes == nil {
+		changes = []taskLib.StatusChange{}
+	}
+
+	writeJSON(w, http.StatusOK, changes)
+}
+
+// requireMember returns ErrListNotFound when the list is not shared with
+// the user.
+func requireMember(r *http.Request, userId int64, list listLib.List) error {
+	role, err := list.RoleContext(r.Context(), userId)
+
+	if err != nil {
+		return err
+	}
+
+	if role == "" {
+		return fmt.Errorf("%w: %d", listLib.ErrListNotFound, list.Id)
+	}
+
+	return nil
+}
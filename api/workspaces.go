@@ -0,0 +1,211 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	userLib "todolist/user"
+	workspaceLib "todolist/workspace"
+)
+
+func workspacesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	workspaces, err := workspaceLib.GetWorkspacesByUserIdContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if workspaces == nil {
+		workspaces = []workspaceLib.Workspace{}
+	}
+
+	writeJSON(w, http.StatusOK, workspaces)
+}
+
+// invitedWorkspacesHandler returns the workspaces the user was added to and
+// has not accepted yet.
+func invitedWorkspacesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	workspaces, err := workspaceLib.GetInvitedWorkspacesContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if workspaces == nil {
+		workspaces = []workspaceLib.Workspace{}
+	}
+
+	writeJSON(w, http.StatusOK, workspaces)
+}
+
+func createWorkspaceHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	workspace := workspaceLib.NewWorkspace(r.URL.Query().Get("name"))
+
+	err = workspace.SaveContext(r.Context(), id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, workspace)
+}
+
+// workspaceHandler loads the workspace given by the workspace parameter
+// before calling handler with the user of the request.
+func workspaceHandler(handler func(w http.ResponseWriter, r *http.Request, userId int64, workspace workspaceLib.Workspace)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := userId(r)
+
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		workspaceId, err := queryId(r, "workspace")
+
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		workspace, err := workspaceLib.GetWorkspaceContext(r.Context(), workspaceId)
+
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+
+		handler(w, r, id, workspace)
+	}
+}
+
+func deleteWorkspaceHandler(w http.ResponseWriter, r *http.Request, userId int64, workspace workspaceLib.Workspace) {
+	err := workspace.DeleteContext(r.Context(), userId)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func workspaceMembersHandler(w http.ResponseWriter, r *http.Request, userId int64, workspace workspaceLib.Workspace) {
+	role, err := workspace.RoleContext(r.Context(), userId)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if role == "" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %d", workspaceLib.ErrWorkspaceNotFound, workspace.Id))
+		return
+	}
+
+	members, err := workspace.MembersContext(r.Context())
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, members)
+}
+
+func addMemberHandler(w http.ResponseWriter, r *http.Request, userId int64, workspace workspaceLib.Workspace) {
+	memberId, err := queryId(r, "user")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = workspace.AddMemberContext(r.Context(), userId, memberId, r.URL.Query().Get("role"))
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	workspaceMembersHandler(w, r, userId, workspace)
+}
+
+func acceptWorkspaceHandler(w http.ResponseWriter, r *http.Request, userId int64, workspace workspaceLib.Workspace) {
+	err := workspace.AcceptContext(r.Context(), userId)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, workspace)
+}
+
+func removeMemberHandler(w http.ResponseWriter, r *http.Request, userId int64, workspace workspaceLib.Workspace) {
+	memberId, err := queryId(r, "user")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = workspace.RemoveMemberContext(r.Context(), userId, memberId)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func userHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := userId(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	otherId, err := queryId(r, "id")
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := userLib.GetUserAsContext(r.Context(), otherId, id)
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	user.Password = ""
+
+	writeJSON(w, http.StatusOK, user)
+}
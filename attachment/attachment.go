@@ -0,0 +1,393 @@
+package attachment
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"todolist/config"
+	taskLib "todolist/task"
+	"todolist/utils"
+)
+
+type Attachment struct {
+	Id         int64     `json:"id"`
+	TaskId     int64     `json:"task_id"`
+	UploaderId int64     `json:"uploader_id"`
+	Name       string    `json:"name"`
+	MimeType   string    `json:"mime_type"`
+	Size       int64     `json:"size"`
+	Hash       string    `json:"hash"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type AttachmentInterface interface {
+	Open() (io.ReadCloser, error)
+	Delete() error
+	DeleteAs(actorId int64) error
+	DeleteContext(ctx context.Context, actorId int64) error
+}
+
+const (
+	ATTACHMENT_COLUMNS = "id, task_id, uploader_id, name, mime_type, size, hash, created_at"
+)
+
+const (
+	// SNIFF_LENGTH is how many bytes http.DetectContentType looks at
+	SNIFF_LENGTH = 512
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("Attachment not found")
+	errTooLarge           = errors.New("Attachment too large")
+)
+
+// limitReader fails with errTooLarge once more than n bytes were read.
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+
+	if l.n < 0 {
+		return n, errTooLarge
+	}
+
+	return n, err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAttachment(row scanner, attachment *Attachment) error {
+	return row.Scan(
+		&attachment.Id,
+		&attachment.TaskId,
+		&attachment.UploaderId,
+		&attachment.Name,
+		&attachment.MimeType,
+		&attachment.Size,
+		&attachment.Hash,
+		&attachment.CreatedAt,
+	)
+}
+
+func GetAttachment(id int64) (Attachment, error) {
+	return GetAttachmentContext(context.Background(), id)
+}
+
+func GetAttachmentContext(ctx context.Context, id int64) (Attachment, error) {
+	var attachment Attachment
+
+	row := utils.SqliteInstance.DB.QueryRowContext(ctx, "SELECT "+ATTACHMENT_COLUMNS+" FROM attachments WHERE id = ?", id)
+	err := scanAttachment(row, &attachment)
+
+	if err == sql.ErrNoRows {
+		return Attachment{}, fmt.Errorf("%w: %d", ErrAttachmentNotFound, id)
+	}
+
+	if err != nil {
+		return Attachment{}, fmt.Errorf("Scanning attachment %d: %w", id, err)
+	}
+
+	return attachment, nil
+}
+
+func GetAttachmentsByTaskId(taskId int64) ([]Attachment, error) {
+	return GetAttachmentsByTaskIdContext(context.Background(), taskId)
+}
+
+func GetAttachmentsByTaskIdContext(ctx context.Context, taskId int64) ([]Attachment, error) {
+	var attachments []Attachment
+
+	rows, err := utils.SqliteInstance.DB.QueryContext(ctx, "SELECT "+ATTACHMENT_COLUMNS+" FROM attachments WHERE task_id = ? ORDER BY id", taskId)
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying attachments: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var attachment Attachment
+
+		err := scanAttachment(rows, &attachment)
+
+		if err != nil {
+			return nil, fmt.Errorf("Scanning attachment: %w", err)
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// Attach stores the content of r as the file name of the task when actorId
+// may edit the task. The MIME type is sniffed from the content, the
+// extension of name only refines a plain text or binary result.
+func Attach(taskId int64, actorId int64, name string, r io.Reader) (Attachment, error) {
+	return AttachContext(context.Background(), taskId, actorId, name, r)
+}
+
+func AttachContext(ctx context.Context, taskId int64, actorId int64, name string, r io.Reader) (Attachment, error) {
+	name = filepath.Base(strings.TrimSpace(name))
+
+	if name == "." || name == string(filepath.Separator) {
+		return Attachment{}, utils.ValidationErrors{{Field: "name", Message: "is required"}}
+	}
+
+	err := taskLib.AuthorizeWrite(ctx, actorId, taskId)
+
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	reader := bufio.NewReaderSize(r, SNIFF_LENGTH)
+	head, err := reader.Peek(SNIFF_LENGTH)
+
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return Attachment{}, err
+	}
+
+	// a blob found by Put must still be there once its row is inserted
+	blobs.Lock()
+	defer blobs.Unlock()
+
+	maxSize := config.Current.Attachments.MaxSize
+	key, size, err := StoreInstance.Put(&limitReader{r: reader, n: maxSize})
+
+	if errors.Is(err, errTooLarge) {
+		return Attachment{}, utils.ValidationErrors{{Field: "file", Message: fmt.Sprintf("must be at most %d bytes", maxSize)}}
+	}
+
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	attachment := Attachment{
+		TaskId:     taskId,
+		UploaderId: actorId,
+		Name:       name,
+		MimeType:   sniff(name, head),
+		Size:       size,
+		Hash:       key,
+		CreatedAt:  time.Now(),
+	}
+
+	res, err := utils.SqliteInstance.DB.ExecContext(
+		ctx,
+		"INSERT INTO attachments (task_id, uploader_id, name, mime_type, size, hash, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		attachment.TaskId,
+		attachment.UploaderId,
+		attachment.Name,
+		attachment.MimeType,
+		attachment.Size,
+		attachment.Hash,
+		attachment.CreatedAt,
+	)
+
+	if err == nil {
+		attachment.Id, err = res.LastInsertId()
+	}
+
+	if err != nil {
+		return Attachment{}, errors.Join(err, removeOrphan(ctx, key))
+	}
+
+	return attachment, nil
+}
+
+func sniff(name string, head []byte) string {
+	sniffed := http.DetectContentType(head)
+
+	if sniffed != "application/octet-stream" && !strings.HasPrefix(sniffed, "text/plain") {
+		return sniffed
+	}
+
+	if byExtension := mime.TypeByExtension(filepath.Ext(name)); byExtension != "" {
+		return byExtension
+	}
+
+	return sniffed
+}
+
+// blobs serializes the uploads with the removal of the blobs, so that a blob
+// is never removed between the upload of the same content and the insertion
+// of its row.
+var blobs sync.Mutex
+
+// release removes the blob once the transaction exec is committed when no
+// attachment of the transaction uses it anymore.
+func release(ctx context.Context, exec utils.Executor, key string) error {
+	count, err := countByHash(ctx, exec, key)
+
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	return utils.AfterCommit(exec, func() error {
+		blobs.Lock()
+		defer blobs.Unlock()
+
+		return removeOrphan(ctx, key)
+	})
+}
+
+// removeOrphan deletes the blob when no attachment uses it, the caller holds
+// blobs.
+func removeOrphan(ctx context.Context, key string) error {
+	count, err := countByHash(ctx, utils.SqliteInstance.DB, key)
+
+	if err != nil || count > 0 {
+		return err
+	}
+
+	return StoreInstance.Delete(key)
+}
+
+func countByHash(ctx context.Context, exec utils.Executor, key string) (int64, error) {
+	var count int64
+
+	row := exec.QueryRowContext(ctx, "SELECT COUNT(*) FROM attachments WHERE hash = ?", key)
+	err := row.Scan(&count)
+
+	if err != nil {
+		return 0, fmt.Errorf("Counting attachments of blob %s: %w", key, err)
+	}
+
+	return count, nil
+}
+
+func (a *Attachment) Open() (io.ReadCloser, error) {
+	return StoreInstance.Open(a.Hash)
+}
+
+func (a *Attachment) Delete() error {
+	return a.DeleteAs(a.UploaderId)
+}
+
+func (a *Attachment) DeleteAs(actorId int64) error {
+	return a.DeleteContext(context.Background(), actorId)
+}
+
+// DeleteContext removes the attachment when actorId may edit its task, the
+// blob is kept while other attachments share its content.
+func (a *Attachment) DeleteContext(ctx context.Context, actorId int64) (err error) {
+	err = taskLib.AuthorizeWrite(ctx, actorId, a.TaskId)
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := utils.SqliteInstance.BeginTx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil && !tx.Committed {
+			tx.Rollback()
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM attachments WHERE id = ?", a.Id)
+
+	if err != nil {
+		return err
+	}
+
+	err = release(ctx, tx, a.Hash)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteByTaskId removes every attachment of the task, once the task itself
+// is gone.
+func DeleteByTaskId(taskId int64) error {
+	return DeleteByTaskIdContext(context.Background(), taskId)
+}
+
+func DeleteByTaskIdContext(ctx context.Context, taskId int64) (err error) {
+	tx, err := utils.SqliteInstance.BeginTx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil && !tx.Committed {
+			tx.Rollback()
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, "SELECT DISTINCT hash FROM attachments WHERE task_id = ?", taskId)
+
+	if err != nil {
+		return fmt.Errorf("Querying attachments: %w", err)
+	}
+
+	var keys []string
+
+	for rows.Next() {
+		var key string
+
+		err = rows.Scan(&key)
+
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("Scanning attachment: %w", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("Querying attachments: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM attachments WHERE task_id = ?", taskId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		err = release(ctx, tx, key)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,232 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"todolist/config"
+	"todolist/events"
+	"todolist/permission"
+	taskLib "todolist/task"
+	"todolist/utils"
+)
+
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func setup(t *testing.T) *LocalStore {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	store := NewLocalStore(t.TempDir())
+	StoreInstance = store
+
+	t.Cleanup(func() {
+		utils.SqliteInstance.Close()
+	})
+
+	return store
+}
+
+func newTask(userId int64) taskLib.Task {
+	task := taskLib.NewTask("Bug report")
+	task.UserId = userId
+	task.Save()
+
+	return task
+}
+
+func TestLocalStore(t *testing.T) {
+	store := NewLocalStore(t.TempDir())
+
+	key, size, err := store.Put(strings.NewReader("hello"))
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if key != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" || size != 5 {
+		t.Error("Key should be the SHA-256 of the content but got", key, size)
+	}
+
+	again, _, _ := store.Put(strings.NewReader("hello"))
+	entries, _ := os.ReadDir(filepath.Join(store.Root, key[:2]))
+
+	if again != key || len(entries) != 1 {
+		t.Error("Same content should be stored once but got", again, entries)
+	}
+
+	blob, err := store.Open(key)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	content, _ := io.ReadAll(blob)
+	blob.Close()
+
+	if string(content) != "hello" {
+		t.Error("Content should be hello but got", string(content))
+	}
+
+	if _, err := store.Open("../../etc/passwd"); err == nil {
+		t.Error("Should return an error for an invalid key")
+	}
+	if err := store.Delete(key); err != nil {
+		t.Error("Error should be nil but got", err)
+	}
+	if _, err := store.Open(key); !errors.Is(err, ErrBlobNotFound) {
+		t.Error("Error should be", ErrBlobNotFound, "but got", err)
+	}
+	if err := store.Delete(key); err != nil {
+		t.Error("Deleting a missing blob should not fail but got", err)
+	}
+}
+
+func TestAttach(t *testing.T) {
+	setup(t)
+	task := newTask(1)
+
+	attachment, err := Attach(task.Id, 1, "screenshot.bin", bytes.NewReader(png))
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if attachment.MimeType != "image/png" || attachment.Size != int64(len(png)) || attachment.Name != "screenshot.bin" {
+		t.Error("Attachment should be a sniffed PNG but got", attachment)
+	}
+
+	notes, _ := Attach(task.Id, 1, "../notes.csv", strings.NewReader("a,b\n1,2\n"))
+
+	if notes.Name != "notes.csv" || !strings.HasPrefix(notes.MimeType, "text/csv") {
+		t.Error("Plain text should use the extension but got", notes.Name, notes.MimeType)
+	}
+
+	if _, err := Attach(task.Id, 2, "other.png", bytes.NewReader(png)); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Only editors should attach files but got", err)
+	}
+	if _, err := Attach(task.Id, 1, " ", bytes.NewReader(png)); err == nil {
+		t.Error("Should return an error without a name")
+	}
+
+	saved, _ := GetAttachment(attachment.Id)
+	blob, err := saved.Open()
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	content, _ := io.ReadAll(blob)
+	blob.Close()
+
+	if !bytes.Equal(content, png) {
+		t.Error("Content should be the uploaded file but got", content)
+	}
+
+	attachments, _ := GetAttachmentsByTaskId(task.Id)
+
+	if len(attachments) != 2 {
+		t.Error("Task should have 2 attachments but got", attachments)
+	}
+}
+
+func TestAttachMaxSize(t *testing.T) {
+	store := setup(t)
+	task := newTask(1)
+
+	defer func(size int64) { config.Current.Attachments.MaxSize = size }(config.Current.Attachments.MaxSize)
+	config.Current.Attachments.MaxSize = 10
+
+	if _, err := Attach(task.Id, 1, "exact.txt", strings.NewReader("0123456789")); err != nil {
+		t.Error("File of the max size should be accepted but got", err)
+	}
+
+	_, err := Attach(task.Id, 1, "big.txt", strings.NewReader("0123456789+"))
+
+	if errs, ok := utils.AsValidationErrors(err); !ok || errs[0].Field != "file" {
+		t.Error("Too large file should be reported but got", err)
+	}
+
+	entries, _ := os.ReadDir(store.Root)
+
+	if len(entries) != 1 {
+		t.Error("Only the accepted blob should be stored but got", entries)
+	}
+}
+
+func TestDeleteAttachment(t *testing.T) {
+	store := setup(t)
+	task := newTask(1)
+	other := newTask(1)
+
+	first, _ := Attach(task.Id, 1, "a.png", bytes.NewReader(png))
+	second, _ := Attach(other.Id, 1, "b.png", bytes.NewReader(png))
+
+	if first.Hash != second.Hash {
+		t.Fatal("Same content should share a blob")
+	}
+
+	if err := first.DeleteAs(2); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Only editors should delete but got", err)
+	}
+	if err := first.Delete(); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if _, err := store.Open(first.Hash); err != nil {
+		t.Error("Shared blob should be kept but got", err)
+	}
+
+	defer events.BusInstance.Reset()
+	events.Subscribe(events.TASK_PURGED, func(event events.Event) error {
+		return DeleteByTaskId(event.(events.TaskPurged).TaskId)
+	})
+
+	other.Delete()
+
+	if _, err := store.Open(second.Hash); err != nil {
+		t.Error("Trashed task should keep its attachments but got", err)
+	}
+
+	other.Purge()
+
+	if attachments, _ := GetAttachmentsByTaskId(other.Id); len(attachments) != 0 {
+		t.Error("Purged task should have no attachments but got", attachments)
+	}
+	if _, err := store.Open(second.Hash); !errors.Is(err, ErrBlobNotFound) {
+		t.Error("Unused blob should be deleted but got", err)
+	}
+}
+
+func TestReleaseAfterCommit(t *testing.T) {
+	store := setup(t)
+	task := newTask(1)
+	attachment, _ := Attach(task.Id, 1, "a.png", bytes.NewReader(png))
+	ctx := context.Background()
+
+	tx, _ := utils.SqliteInstance.BeginTx(ctx)
+	tx.ExecContext(ctx, "DELETE FROM attachments WHERE id = ?", attachment.Id)
+
+	if err := release(ctx, tx, attachment.Hash); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	tx.Rollback()
+
+	if _, err := store.Open(attachment.Hash); err != nil {
+		t.Error("Blob should be kept when the delete is rolled back but got", err)
+	}
+	if attachments, _ := GetAttachmentsByTaskId(task.Id); len(attachments) != 1 {
+		t.Error("Attachment should be kept when the delete is rolled back but got", attachments)
+	}
+
+	tx, _ = utils.SqliteInstance.BeginTx(ctx)
+	tx.ExecContext(ctx, "DELETE FROM attachments WHERE id = ?", attachment.Id)
+	release(ctx, tx, attachment.Hash)
+	tx.ExecContext(ctx, "INSERT INTO attachments (task_id, hash) VALUES (?, ?)", task.Id, attachment.Hash)
+	tx.Commit()
+
+	if _, err := store.Open(attachment.Hash); err != nil {
+		t.Error("Blob used again before the commit should be kept but got", err)
+	}
+}
@@ -0,0 +1,135 @@
+package attachment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"todolist/config"
+)
+
+// Store keeps the content of the attachments, a blob is known by the key
+// Put returns for it.
+type Store interface {
+	Put(r io.Reader) (key string, size int64, err error)
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// LocalStore is a Store in a directory of the filesystem, blobs are named
+// after the SHA-256 of their content so identical files are stored once.
+type LocalStore struct {
+	Root string
+}
+
+var (
+	ErrBlobNotFound = errors.New("Blob not found")
+)
+
+var StoreInstance Store = NewLocalStore(config.Current.AttachmentsPath())
+
+func NewLocalStore(root string) *LocalStore {
+	return &LocalStore{Root: root}
+}
+
+// path returns where the blob of key lives, spread over directories named
+// after its first two characters.
+func (s *LocalStore) path(key string) (string, error) {
+	decoded, err := hex.DecodeString(key)
+
+	if err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("Invalid blob key %q", key)
+	}
+
+	return filepath.Join(s.Root, key[:2], key), nil
+}
+
+// Put writes r to a temporary file while hashing it, then moves the file to
+// its key unless a blob with the same content already exists.
+func (s *LocalStore) Put(r io.Reader) (string, int64, error) {
+	err := os.MkdirAll(s.Root, 0o755)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	tmp, err := os.CreateTemp(s.Root, ".upload-*")
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	key := hex.EncodeToString(hash.Sum(nil))
+	path, err := s.path(key)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return key, size, nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	err = os.Rename(tmp.Name(), path)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	return key, size, nil
+}
+
+func (s *LocalStore) Open(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, key)
+	}
+
+	return file, err
+}
+
+// Delete removes the blob, deleting a missing blob is not an error.
+func (s *LocalStore) Delete(key string) error {
+	path, err := s.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
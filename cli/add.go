@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"todolist/quickadd"
+	userLib "todolist/user"
+)
+
+func init() {
+	Register(Command{
+		Name:        "add",
+		Usage:       "add <text>",
+		Description: `add a task, e.g. "Call dentist tomorrow 3pm !2 #health @phone"`,
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				return fmt.Errorf("Usage: todolist add <text>")
+			}
+
+			user, err := userLib.GetUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			task, err := quickadd.NewParser(time.Now(), user.Location()).Parse(strings.Join(args, " "))
+
+			if err != nil {
+				return err
+			}
+
+			task.UserId = user.Id
+
+			if task.ListId == 0 {
+				task.ListId, err = user.DefaultListId()
+
+				if err != nil {
+					return err
+				}
+			}
+
+			if task.Priority == 0 {
+				task.Priority = user.Preferences.DefaultPriority
+			}
+
+			err = user.AddTask(task)
+
+			if err != nil {
+				return err
+			}
+
+			err = task.SaveAs(userId)
+
+			if err != nil {
+				return err
+			}
+
+			return renderTask(user.Preferences, task)
+		},
+	})
+}
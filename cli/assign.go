@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+)
+
+func init() {
+	Register(Command{
+		Name:        "assign",
+		Usage:       "assign <task> <user>",
+		Description: "assign a task to a user, 0 to unassign it",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) != 2 {
+				return fmt.Errorf("Usage: todolist assign <task> <user>")
+			}
+
+			taskId, err := parseId(args[0], "task")
+
+			if err != nil {
+				return err
+			}
+
+			assigneeId, err := parseId(args[1], "user")
+
+			if err != nil {
+				return err
+			}
+
+			user, err := userLib.GetUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			task, err := taskLib.GetTaskAs(taskId, userId)
+
+			if err != nil {
+				return err
+			}
+
+			return user.AssignTask(&task, assigneeId)
+		},
+	})
+	Register(Command{
+		Name:        "assigned",
+		Usage:       "assigned",
+		Description: "list the tasks assigned to you",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			tasks, err := taskLib.GetTasksByAssigneeId(userId)
+
+			if err != nil {
+				return err
+			}
+
+			p, err := userPreferences(userId)
+
+			if err != nil {
+				return err
+			}
+
+			return renderTasks(p, tasks)
+		},
+	})
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	attachmentLib "todolist/attachment"
+	taskLib "todolist/task"
+)
+
+func init() {
+	Register(Command{
+		Name:        "attach",
+		Usage:       "attach <task> <file>",
+		Description: "attach a file to a task",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) != 2 {
+				return fmt.Errorf("Usage: todolist attach <task> <file>")
+			}
+
+			taskId, err := parseId(args[0], "task")
+
+			if err != nil {
+				return err
+			}
+
+			file, err := os.Open(args[1])
+
+			if err != nil {
+				return err
+			}
+
+			defer file.Close()
+
+			attachment, err := attachmentLib.Attach(taskId, userId, args[1], file)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(stdout, "[%d] %s (%s, %d bytes)\n", attachment.Id, attachment.Name, attachment.MimeType, attachment.Size)
+
+			return nil
+		},
+	})
+	Register(Command{
+		Name:        "attachments",
+		Usage:       "attachments <task>",
+		Description: "list the files attached to a task",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) != 1 {
+				return fmt.Errorf("Usage: todolist attachments <task>")
+			}
+
+			taskId, err := parseId(args[0], "task")
+
+			if err != nil {
+				return err
+			}
+
+			_, err = taskLib.GetTaskAs(taskId, userId)
+
+			if err != nil {
+				return err
+			}
+
+			attachments, err := attachmentLib.GetAttachmentsByTaskId(taskId)
+
+			if err != nil {
+				return err
+			}
+
+			for _, attachment := range attachments {
+				fmt.Fprintf(stdout, "[%d] %s (%s, %d bytes)\n", attachment.Id, attachment.Name, attachment.MimeType, attachment.Size)
+			}
+
+			return nil
+		},
+	})
+	Register(Command{
+		Name:        "download",
+		Usage:       "download <attachment>",
+		Description: "write an attached file to the standard output",
+		Run: withAttachment("download", func(userId int64, attachment attachmentLib.Attachment) error {
+			_, err := taskLib.GetTaskAs(attachment.TaskId, userId)
+
+			if err != nil {
+				return err
+			}
+
+			blob, err := attachment.Open()
+
+			if err != nil {
+				return err
+			}
+
+			defer blob.Close()
+
+			_, err = io.Copy(stdout, blob)
+
+			return err
+		}),
+	})
+	Register(Command{
+		Name:        "detach",
+		Usage:       "detach <attachment>",
+		Description: "remove a file from its task",
+		Run: withAttachment("detach", func(userId int64, attachment attachmentLib.Attachment) error {
+			return attachment.DeleteAs(userId)
+		}),
+	})
+}
+
+// withAttachment wraps a command taking an attachment id as only argument.
+func withAttachment(name string, run func(userId int64, attachment attachmentLib.Attachment) error) func(int64, []string) error {
+	return func(userId int64, args []string) error {
+		err := requireUser(userId)
+
+		if err != nil {
+			return err
+		}
+
+		if len(args) != 1 {
+			return fmt.Errorf("Usage: todolist %s <attachment>", name)
+		}
+
+		id, err := parseId(args[0], "attachment")
+
+		if err != nil {
+			return err
+		}
+
+		attachment, err := attachmentLib.GetAttachment(id)
+
+		if err != nil {
+			return err
+		}
+
+		return run(userId, attachment)
+	}
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	taskLib "todolist/task"
+)
+
+func init() {
+	Register(Command{
+		Name:        "checklist",
+		Usage:       "checklist <task>",
+		Description: "show the checklist of a task",
+		Run: withTask(1, "checklist <task>", func(userId int64, task taskLib.Task, args []string) error {
+			items, err := task.Checklist()
+
+			if err != nil {
+				return err
+			}
+
+			for _, item := range items {
+				checked := " "
+
+				if item.Checked {
+					checked = "x"
+				}
+
+				fmt.Fprintf(stdout, "%d. [%s] [%d] %s\n", item.Position+1, checked, item.Id, item.Text)
+			}
+
+			if task.ChecklistItems > 0 {
+				fmt.Fprintf(stdout, "%d/%d (%d%%)\n", task.ChecklistChecked, task.ChecklistItems, task.Progress())
+			}
+
+			return nil
+		}),
+	})
+	Register(Command{
+		Name:        "check-add",
+		Usage:       "check-add <task> <text>",
+		Description: "add an item at the end of the checklist of a task",
+		Run: withTask(2, "check-add <task> <text>", func(userId int64, task taskLib.Task, args []string) error {
+			item, err := task.AddItem(userId, strings.Join(args[1:], " "))
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(stdout, "[%d]\n", item.Id)
+
+			return nil
+		}),
+	})
+	Register(Command{
+		Name:        "check",
+		Usage:       "check <task> <item>",
+		Description: "check or uncheck a checklist item",
+		Run: withTask(2, "check <task> <item>", func(userId int64, task taskLib.Task, args []string) error {
+			itemId, err := parseId(args[1], "item")
+
+			if err != nil {
+				return err
+			}
+
+			return task.ToggleItem(userId, itemId)
+		}),
+	})
+	Register(Command{
+		Name:        "check-move",
+		Usage:       "check-move <task> <item> <position>",
+		Description: "move a checklist item, positions start at 1",
+		Run: withTask(3, "check-move <task> <item> <position>", func(userId int64, task taskLib.Task, args []string) error {
+			itemId, err := parseId(args[1], "item")
+
+			if err != nil {
+				return err
+			}
+
+			position, err := strconv.Atoi(args[2])
+
+			if err != nil {
+				return fmt.Errorf("Invalid position %q", args[2])
+			}
+
+			return task.MoveItem(userId, itemId, position-1)
+		}),
+	})
+	Register(Command{
+		Name:        "check-remove",
+		Usage:       "check-remove <task> <item>",
+		Description: "remove an item from the checklist of a task",
+		Run: withTask(2, "check-remove <task> <item>", func(userId int64, task taskLib.Task, args []string) error {
+			itemId, err := parseId(args[1], "item")
+
+			if err != nil {
+				return err
+			}
+
+			return task.RemoveItem(userId, itemId)
+		}),
+	})
+	Register(Command{
+		Name:        "autocomplete",
+		Usage:       "autocomplete <task> on|off",
+		Description: "complete the task when its whole checklist is checked",
+		Run: withTask(2, "autocomplete <task> on|off", func(userId int64, task taskLib.Task, args []string) error {
+			switch args[1] {
+			case "on":
+				task.AutoComplete = true
+			case "off":
+				task.AutoComplete = false
+			default:
+				return fmt.Errorf("Usage: todolist autocomplete <task> on|off")
+			}
+
+			return task.SaveAs(userId)
+		}),
+	})
+}
+
+// withTask wraps a command taking a task id then at least count-1 other
+// arguments, the task is loaded when the user may read it.
+func withTask(count int, usage string, run func(userId int64, task taskLib.Task, args []string) error) func(int64, []string) error {
+	return func(userId int64, args []string) error {
+		err := requireUser(userId)
+
+		if err != nil {
+			return err
+		}
+
+		if len(args) < count {
+			return fmt.Errorf("Usage: todolist %s", usage)
+		}
+
+		taskId, err := parseId(args[0], "task")
+
+		if err != nil {
+			return err
+		}
+
+		task, err := taskLib.GetTaskAs(taskId, userId)
+
+		if err != nil {
+			return err
+		}
+
+		return run(userId, task, args)
+	}
+}
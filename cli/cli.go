@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"todolist/config"
+	taskLib "todolist/task"
+	"todolist/utils"
+)
+
+type Command struct {
+	Name        string
+	Usage       string
+	Description string
+	Run         func(userId int64, args []string) error
+}
+
+var commands = map[string]Command{}
+
+// Setup is called with the loaded configuration before running a command,
+// main uses it to connect the database.
+var Setup func(config.Config) error
+
+func Register(command Command) {
+	commands[command.Name] = command
+}
+
+func Run(args []string) error {
+	flags := flag.NewFlagSet("todolist", flag.ContinueOnError)
+	userId := flags.Int64("user", envUserId(), "id of the user running the command (env TODOLIST_USER)")
+	configPath := flags.String("config", "", "path of the configuration file (env TODOLIST_CONFIG, default "+config.Path()+")")
+	dbPath := flags.String("db", "", "path of the database (env TODOLIST_DB_PATH)")
+	output := flags.String("output", "", "output format of the tasks: "+strings.Join(taskLib.FORMATS, ", ")+" (env TODOLIST_OUTPUT)")
+	flags.Usage = func() { printUsage(flags) }
+
+	err := flags.Parse(args)
+
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		printUsage(flags)
+		return fmt.Errorf("Missing command")
+	}
+
+	command, ok := commands[flags.Arg(0)]
+
+	if !ok {
+		printUsage(flags)
+		return fmt.Errorf("Unknown command %s", flags.Arg(0))
+	}
+
+	cfg, err := config.Load(*configPath)
+
+	if err != nil {
+		return err
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "db":
+			cfg.Database.Path = *dbPath
+		case "output":
+			cfg.Output = *output
+		}
+	})
+
+	if errs := cfg.Validate(); len(errs) > 0 {
+		return errs
+	}
+
+	config.Current = cfg
+
+	if Setup != nil {
+		err = Setup(cfg)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return command.Run(*userId, flags.Args()[1:])
+}
+
+// PrintError writes err to stderr, a validation error is written with one
+// invalid field per line.
+func PrintError(err error) {
+	errs, ok := utils.AsValidationErrors(err)
+
+	if !ok {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, "Validation failed:")
+
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", e.Field, e.Message)
+	}
+}
+
+func envUserId() int64 {
+	id, _ := strconv.ParseInt(os.Getenv("TODOLIST_USER"), 10, 64)
+	return id
+}
+
+func printUsage(flags *flag.FlagSet) {
+	var names []string
+
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fmt.Fprintln(flags.Output(), "Usage: todolist [-user id] [-config path] [-db path] [-output format] <command> [args]")
+	fmt.Fprintln(flags.Output())
+	fmt.Fprintln(flags.Output(), "Commands:")
+
+	for _, name := range names {
+		fmt.Fprintf(flags.Output(), "  %-30s %s\n", commands[name].Usage, commands[name].Description)
+	}
+
+	fmt.Fprintln(flags.Output())
+	flags.PrintDefaults()
+}
+
+func parseId(value string, name string) (int64, error) {
+	id, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("Invalid %s id %s", name, value)
+	}
+
+	return id, nil
+}
+
+func requireUser(userId int64) error {
+	if userId == 0 {
+		return fmt.Errorf("No user given, use -user or TODOLIST_USER")
+	}
+
+	return nil
+}
@@ -0,0 +1,672 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"todolist/config"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+	"todolist/utils"
+)
+
+func TestRunUnknownCommand(t *testing.T) {
+	if err := Run([]string{"unknown"}); err == nil {
+		t.Error("Should return an error")
+	}
+	if err := Run([]string{}); err == nil {
+		t.Error("Should return an error")
+	}
+}
+
+func TestRunUndo(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := taskLib.NewTask("TestTask")
+	task.UserId = 1
+	task.Save()
+
+	if err := Run([]string{"undo"}); err == nil {
+		t.Error("Should return an error without a user")
+	}
+
+	err := Run([]string{"-user", "1", "undo", "-n", "1"})
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if taskLib.IsTaskExist(task.Id) {
+		t.Error("Task should be deleted")
+	}
+
+	err = Run([]string{"-user", "1", "redo"})
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if !taskLib.IsTaskExist(task.Id) {
+		t.Error("Task should be created again")
+	}
+}
+
+func TestRunAdd(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	user := userLib.NewUser("John", "Doe", "john@example.com", nil)
+	user.Birthdate = time.Now().AddDate(-30, 0, 0)
+	user.Preferences.DefaultList = "inbox"
+	user.Preferences.DefaultPriority = 1
+	user.Save()
+
+	utils.SqliteInstance.DB.Exec("INSERT INTO lists (name, owner_id) VALUES ('inbox', ?)", user.Id)
+	utils.SqliteInstance.DB.Exec("INSERT INTO list_members (list_id, user_id, role) VALUES (1, ?, 'owner')", user.Id)
+
+	err := Run([]string{"-user", "1", "add", "Call", "dentist", "tomorrow", "3pm", "@phone"})
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	tasks, _ := taskLib.GetTasksByUserId(user.Id)
+
+	if len(tasks) != 1 {
+		t.Fatal("User should have 1 task but has", len(tasks))
+	}
+	if tasks[0].Name != "Call dentist" || tasks[0].Location != "phone" || tasks[0].EndDate.Hour() != 15 {
+		t.Error("Task should be parsed but is", tasks[0])
+	}
+	if tasks[0].ListId != 1 || tasks[0].Label != "" || tasks[0].Priority != 1 {
+		t.Error("Task should use the user defaults but is", tasks[0])
+	}
+
+	if err := Run([]string{"-user", "1", "add", "tomorrow"}); err == nil {
+		t.Error("Should return an error without a name")
+	}
+}
+
+func TestRunList(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	var buffer bytes.Buffer
+	stdout = &buffer
+	defer func() { stdout = os.Stdout }()
+
+	task := taskLib.NewTask("TestTask")
+	task.UserId = 1
+	task.Label = "work"
+	task.Save()
+
+	err := Run([]string{"-user", "1", "-output", "table=id,name,label", "list"})
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if buffer.String() != "ID  NAME      LABEL\n1   TestTask  work\n" {
+		t.Error("Output should be a table but got", buffer.String())
+	}
+
+	buffer.Reset()
+	err = Run([]string{"-user", "1", "-output", "json", "show", "1"})
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if !strings.Contains(buffer.String(), `"name": "TestTask"`) {
+		t.Error("Output should be JSON but got", buffer.String())
+	}
+
+	if err := Run([]string{"-user", "1", "-output", "xml", "list"}); err == nil {
+		t.Error("Should return an error for an unknown format")
+	}
+	if err := Run([]string{"-user", "2", "show", "1"}); err == nil {
+		t.Error("Should return an error for a task of another user")
+	}
+}
+
+func TestRunConfig(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+	defer func() { config.Current = config.Default() }()
+
+	var buffer bytes.Buffer
+	stdout = &buffer
+	defer func() { stdout = os.Stdout }()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"output": "table=id,name"}`), 0o644)
+
+	task := taskLib.NewTask("TestTask")
+	task.UserId = 1
+	task.Save()
+
+	var setup config.Config
+	Setup = func(cfg config.Config) error {
+		setup = cfg
+		return nil
+	}
+	defer func() { Setup = nil }()
+
+	err := Run([]string{"-user", "1", "-config", path, "-db", "/tmp/other.db", "list"})
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if buffer.String() != "ID  NAME\n1   TestTask\n" {
+		t.Error("Output should use the config file but got", buffer.String())
+	}
+	if setup.Database.Path != "/tmp/other.db" {
+		t.Error("Database path should be", "/tmp/other.db", "but got", setup.Database.Path)
+	}
+
+	buffer.Reset()
+	t.Setenv("TODOLIST_OUTPUT", "markdown")
+
+	err = Run([]string{"-user", "1", "-config", path, "list"})
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if buffer.String() != "- [ ] TestTask\n" {
+		t.Error("Output should use the environment but got", buffer.String())
+	}
+
+	if err := Run([]string{"-config", filepath.Join(t.TempDir(), "missing.json"), "list"}); err == nil {
+		t.Error("Should return an error for a missing config file")
+	}
+}
+
+func TestRunShare(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	var buffer bytes.Buffer
+	stdout = &buffer
+	defer func() { stdout = os.Stdout }()
+
+	for _, email := range []string{"owner@example.com", "viewer@example.com"} {
+		user := userLib.NewUser("John", "Doe", email, nil)
+		user.Birthdate = time.Now().AddDate(-30, 0, 0)
+		user.Save()
+	}
+
+	task := taskLib.NewTask("Milk")
+	task.UserId = 1
+	task.Save()
+
+	for _, args := range [][]string{
+		{"-user", "1", "list-create", "Groceries"},
+		{"-user", "1", "share", "1", "2", "viewer"},
+		{"-user", "1", "move", "1", "1"},
+	} {
+		if err := Run(args); err != nil {
+			t.Fatal("Error should be nil but got", err)
+		}
+	}
+
+	buffer.Reset()
+
+	if err := Run([]string{"-user", "2", "list"}); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if buffer.String() != "[ ] [1] Milk\n" {
+		t.Error("Viewer should see the shared task but got", buffer.String())
+	}
+
+	buffer.Reset()
+	Run([]string{"-user", "2", "lists"})
+
+	if buffer.String() != "[1] Groceries (viewer)\n" {
+		t.Error("Viewer should see the shared list but got", buffer.String())
+	}
+
+	if err := Run([]string{"-user", "2", "move", "1", "0"}); err == nil {
+		t.Error("Viewer should not move the task")
+	}
+	if err := Run([]string{"-user", "2", "share", "1", "2", "owner"}); err == nil {
+		t.Error("Viewer should not share the list")
+	}
+}
+
+func TestRunComments(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	var buffer bytes.Buffer
+	stdout = &buffer
+	defer func() { stdout = os.Stdout }()
+
+	for _, email := range []string{"owner@example.com", "viewer@example.com"} {
+		user := userLib.NewUser("John", "Doe", email, nil)
+		user.Birthdate = time.Now().AddDate(-30, 0, 0)
+		user.Save()
+	}
+
+	task := taskLib.NewTask("Milk")
+	task.UserId = 1
+	task.Save()
+
+	for _, args := range [][]string{
+		{"-user", "1", "comment", "1", "Whole", "or", "**skimmed**?"},
+		{"-user", "1", "comment-edit", "1", "Whole", "milk"},
+	} {
+		if err := Run(args); err != nil {
+			t.Fatal("Error should be nil but got", err)
+		}
+	}
+
+	if err := Run([]string{"-user", "2", "comment", "1", "Hello"}); err == nil {
+		t.Error("User without access should not comment")
+	}
+
+	buffer.Reset()
+	Run([]string{"-user", "1", "list"})
+
+	if buffer.String() != "[ ] [1] Milk (1 comment)\n" {
+		t.Error("List should show the comment count but got", buffer.String())
+	}
+
+	buffer.Reset()
+	Run([]string{"-user", "1", "comments", "1"})
+
+	if !strings.HasPrefix(buffer.String(), "[1] John Doe, ") || !strings.Contains(buffer.String(), "(edited)\nWhole milk\n") {
+		t.Error("Thread should show the edited comment but got", buffer.String())
+	}
+
+	if err := Run([]string{"-user", "1", "comment-delete", "1"}); err != nil {
+		t.Error("Error should be nil but got", err)
+	}
+}
+
+func TestRunChecklist(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	var buffer bytes.Buffer
+	stdout = &buffer
+	defer func() { stdout = os.Stdout }()
+
+	task := taskLib.NewTask("Trip")
+	task.UserId = 1
+	task.Save()
+
+	for _, args := range [][]string{
+		{"-user", "1", "autocomplete", "1", "on"},
+		{"-user", "1", "check-add", "1", "Book", "train"},
+		{"-user", "1", "check-add", "1", "Pack"},
+		{"-user", "1", "check-move", "1", "2", "1"},
+		{"-user", "1", "check", "1", "2"},
+	} {
+		if err := Run(args); err != nil {
+			t.Fatal("Error should be nil but got", err)
+		}
+	}
+
+	if err := Run([]string{"-user", "1", "autocomplete", "1", "maybe"}); err == nil {
+		t.Error("Unknown switch should return an error")
+	}
+
+	buffer.Reset()
+	Run([]string{"-user", "1", "checklist", "1"})
+
+	if buffer.String() != "1. [x] [2] Pack\n2. [ ] [1] Book train\n1/2 (50%)\n" {
+		t.Error("Checklist is wrong:", buffer.String())
+	}
+
+	Run([]string{"-user", "1", "check", "1", "1"})
+
+	if task := taskLib.GetTask(1); !task.Completed {
+		t.Error("Task should be completed with its checklist")
+	}
+}
+
+func TestRunWorkflow(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	var buffer bytes.Buffer
+	stdout = &buffer
+	defer func() { stdout = os.Stdout }()
+
+	user := userLib.NewUser("John", "Doe", "john@example.com", nil)
+	user.Birthdate = time.Now().AddDate(-30, 0, 0)
+	user.Save()
+
+	task := taskLib.NewTask("Ship it")
+	task.UserId = 1
+	task.Save()
+
+	for _, args := range [][]string{
+		{"-user", "1", "list-create", "Sprint"},
+		{"-user", "1", "workflow-set", "1", "todo", "doing:1", "done!"},
+		{"-user", "1", "workflow-allow", "1", "todo", "doing"},
+		{"-user", "1", "workflow-allow", "1", "doing", "done"},
+		{"-user", "1", "move", "1", "1"},
+		{"-user", "1", "status", "1", "doing"},
+	} {
+		if err := Run(args); err != nil {
+			t.Fatal(args, "error should be nil but got", err)
+		}
+	}
+
+	if err := Run([]string{"-user", "1", "status", "1", "todo"}); err == nil {
+		t.Error("Move not allowed should return an error")
+	}
+	if err := Run([]string{"-user", "1", "workflow-set", "1", "todo", "doing:x"}); err == nil {
+		t.Error("Invalid WIP limit should return an error")
+	}
+
+	buffer.Reset()
+	Run([]string{"-user", "1", "workflow", "1"})
+
+	if buffer.String() != "todo\ndoing (WIP 1)\ndone (terminal)\ntodo -> doing\ndoing -> done\n" {
+		t.Error("Workflow is wrong:", buffer.String())
+	}
+
+	buffer.Reset()
+	Run([]string{"-user", "1", "board", "1"})
+
+	if buffer.String() != "== todo (0) ==\n== doing (1/1) ==\n[ ] [1] Ship it\n== done (0) ==\n" {
+		t.Error("Board is wrong:", buffer.String())
+	}
+
+	if err := Run([]string{"-user", "2", "board", "1"}); err == nil {
+		t.Error("Stranger should not see the board")
+	}
+
+	buffer.Reset()
+	Run([]string{"-user", "1", "status-history", "1"})
+
+	if !strings.Contains(buffer.String(), "created -> todo (user 1)\n") || !strings.Contains(buffer.String(), "todo -> doing (user 1)\n") {
+		t.Error("Status history is wrong:", buffer.String())
+	}
+}
+
+func TestRunTimer(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	var buffer bytes.Buffer
+	stdout = &buffer
+	defer func() { stdout = os.Stdout }()
+
+	user := userLib.NewUser("John", "Doe", "john@example.com", nil)
+	user.Birthdate = time.Now().AddDate(-30, 0, 0)
+	user.Preferences.Timezone = "UTC"
+	user.Save()
+
+	task := taskLib.NewTask("Website")
+	task.UserId = 1
+	task.Label = "acme"
+	task.Save()
+
+	for _, args := range [][]string{
+		{"-user", "1", "timer", "start", "1", "Kickoff"},
+		{"-user", "1", "timer", "stop"},
+		{"-user", "1", "timer", "add", "1", "1h30m", "2026-03-03"},
+		{"-user", "1", "timer", "add", "1", "15m", "2026-03-09"},
+	} {
+		if err := Run(args); err != nil {
+			t.Fatal(args, "error should be nil but got", err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-user", "1", "timer", "stop"},
+		{"-user", "1", "timer", "add", "1", "soon"},
+		{"-user", "1", "timer", "report", "March"},
+		{"-user", "1", "timer", "pause"},
+	} {
+		if err := Run(args); err == nil {
+			t.Error(args, "should return an error")
+		}
+	}
+
+	buffer.Reset()
+	Run([]string{"-user", "1", "timer", "report", "2026-03-02", "2026-03-08"})
+
+	if buffer.String() != "2026-03-02 - 2026-03-08\n[1] Website  1h30m\n#acme        1h30m\nTotal        1h30m\n" {
+		t.Errorf("Report is wrong: %q", buffer.String())
+	}
+}
+
+func TestRunPlan(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	var buffer bytes.Buffer
+	stdout = &buffer
+	defer func() { stdout = os.Stdout }()
+
+	user := userLib.NewUser("John", "Doe", "john@example.com", nil)
+	user.Birthdate = time.Now().AddDate(-30, 0, 0)
+	user.Preferences.Timezone = "UTC"
+	user.Save()
+
+	for _, name := range []string{"Report", "Slides", "Call"} {
+		task := taskLib.NewTask(name)
+		task.UserId = 1
+		task.Save()
+	}
+
+	if err := Run([]string{"-user", "1", "plan"}); err == nil {
+		t.Error("Planning without capacity should return an error")
+	}
+
+	for _, args := range [][]string{
+		{"-user", "1", "capacity", "4h"},
+		{"-user", "1", "estimate", "1", "3h"},
+		{"-user", "1", "estimate", "2", "2pt"},
+	} {
+		if err := Run(args); err != nil {
+			t.Fatal(args, "error should be nil but got", err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-user", "1", "estimate", "1", "soon"},
+		{"-user", "1", "capacity", "3pt"},
+		{"-user", "1", "plan", "many"},
+	} {
+		if err := Run(args); err == nil {
+			t.Error(args, "should return an error")
+		}
+	}
+
+	buffer.Reset()
+
+	if err := Run([]string{"-user", "1", "plan", "2"}); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	today := time.Now().UTC()
+	expected := fmt.Sprintf("%s %s  4h00m/4h00m\n  [1] Report  3h00m\n  [2] Slides  1h00m\n%s %s  1h00m/4h00m\n  [2] Slides  1h00m\nUnestimated\n  [3] Call\n", today.Weekday(), today.Format("2006-01-02"), today.AddDate(0, 0, 1).Weekday(), today.AddDate(0, 0, 1).Format("2006-01-02"))
+
+	if buffer.String() != expected {
+		t.Errorf("Plan should be\n%q\nbut got\n%q", expected, buffer.String())
+	}
+}
+
+func TestRunPriority(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	var buffer bytes.Buffer
+	stdout = &buffer
+	defer func() { stdout = os.Stdout }()
+
+	for _, name := range []string{"Someday", "Taxes", "Outage"} {
+		task := taskLib.NewTask(name)
+		task.UserId = 1
+		task.Save()
+	}
+
+	for _, args := range [][]string{
+		{"-user", "1", "priority", "2", "low"},
+		{"-user", "1", "priority", "3", "critical"},
+	} {
+		if err := Run(args); err != nil {
+			t.Fatal(args, "error should be nil but got", err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-user", "1", "priority", "1", "urgent"},
+		{"-user", "1", "list", "name"},
+	} {
+		if err := Run(args); err == nil {
+			t.Error(args, "should return an error")
+		}
+	}
+
+	buffer.Reset()
+
+	if err := Run([]string{"-user", "1", "-output", "table=id,priority", "list"}); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if buffer.String() != "ID  PRIORITY\n3   critical\n2   low\n1   \n" {
+		t.Error("Output should be sorted by urgency but got", buffer.String())
+	}
+
+	buffer.Reset()
+
+	if err := Run([]string{"-user", "1", "-output", "table=id", "list", "id"}); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if buffer.String() != "ID\n1\n2\n3\n" {
+		t.Error("Output should be sorted by id but got", buffer.String())
+	}
+}
+
+func TestRunView(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	var buffer bytes.Buffer
+	stdout = &buffer
+	defer func() { stdout = os.Stdout }()
+
+	user := userLib.NewUser("John", "Doe", "john@example.com", nil)
+	user.Birthdate = time.Now().AddDate(-30, 0, 0)
+	user.Save()
+
+	now := time.Now()
+
+	for i, date := range []time.Time{now, now.AddDate(0, 0, -2), now.AddDate(0, 0, 3), {}} {
+		task := taskLib.NewTask(fmt.Sprintf("Task %d", i+1))
+		task.UserId = 1
+		task.EndDate = date
+		task.Save()
+	}
+
+	done := taskLib.GetTask(4)
+	done.Completed = true
+	done.Save()
+
+	cases := []struct {
+		view     string
+		expected string
+	}{
+		{"today", "ID\n2\n1\n"},
+		{"overdue", "ID\n2\n"},
+		{"upcoming", "ID\n3\n"},
+		{"no-date", "ID\n"},
+		{"completed", "ID\n4\n"},
+	}
+
+	for _, c := range cases {
+		buffer.Reset()
+
+		if err := Run([]string{"-user", "1", "-output", "table=id", "view", c.view}); err != nil {
+			t.Fatal("Error should be nil but got", err)
+		}
+		if buffer.String() != c.expected {
+			t.Errorf("View %s should be %q but got %q", c.view, c.expected, buffer.String())
+		}
+	}
+
+	if err := Run([]string{"-user", "1", "view", "tomorrow"}); err == nil {
+		t.Error("Unknown view should return an error")
+	}
+}
+
+func TestRunSavedView(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	var buffer bytes.Buffer
+	stdout = &buffer
+	defer func() { stdout = os.Stdout }()
+
+	user := userLib.NewUser("John", "Doe", "john@example.com", nil)
+	user.Birthdate = time.Now().AddDate(-30, 0, 0)
+	user.Save()
+
+	for _, label := range []string{"work", "home", "work"} {
+		task := taskLib.NewTask("Task " + label)
+		task.UserId = 1
+		task.Label = label
+		task.Save()
+	}
+
+	for _, args := range [][]string{
+		{"-user", "1", "view-save", "work", "filter=#work"},
+		{"-user", "1", "view-save", "work", "sort=id", "columns=id,label"},
+		{"-user", "1", "view-save", "labels", "group=label", "sort=id", "columns=id"},
+	} {
+		if err := Run(args); err != nil {
+			t.Fatal(args, "error should be nil but got", err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-user", "1", "view-save", "today"},
+		{"-user", "1", "view-save", "bad", "filter=!urgent"},
+		{"-user", "1", "view-save", "bad", "color=red"},
+		{"-user", "1", "view", "missing"},
+	} {
+		if err := Run(args); err == nil {
+			t.Error(args, "should return an error")
+		}
+	}
+
+	buffer.Reset()
+
+	if err := Run([]string{"-user", "1", "views"}); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if buffer.String() != "labels  sort=id group=label columns=id\nwork  filter=\"#work\" sort=id columns=id,label\n" {
+		t.Error("Output should list the views but got", buffer.String())
+	}
+
+	buffer.Reset()
+
+	if err := Run([]string{"-user", "1", "view", "work"}); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if buffer.String() != "ID  LABEL\n1   work\n3   work\n" {
+		t.Error("Output should be the work tasks but got", buffer.String())
+	}
+
+	buffer.Reset()
+
+	if err := Run([]string{"-user", "1", "view", "labels"}); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if buffer.String() != "home (1)\nID\n2\n\nwork (2)\nID\n1\n3\n" {
+		t.Error("Output should be grouped by label but got", buffer.String())
+	}
+
+	if err := Run([]string{"-user", "1", "view-delete", "work"}); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if err := Run([]string{"-user", "1", "view", "work"}); err == nil {
+		t.Error("Deleted view should return an error")
+	}
+}
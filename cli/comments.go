@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	commentLib "todolist/comment"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+)
+
+func init() {
+	Register(Command{
+		Name:        "comments",
+		Usage:       "comments <task>",
+		Description: "show the discussion of a task",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) != 1 {
+				return fmt.Errorf("Usage: todolist comments <task>")
+			}
+
+			taskId, err := parseId(args[0], "task")
+
+			if err != nil {
+				return err
+			}
+
+			_, err = taskLib.GetTaskAs(taskId, userId)
+
+			if err != nil {
+				return err
+			}
+
+			comments, err := commentLib.GetCommentsByTaskId(taskId)
+
+			if err != nil {
+				return err
+			}
+
+			p, err := userPreferences(userId)
+
+			if err != nil {
+				return err
+			}
+
+			for _, comment := range comments {
+				author := fmt.Sprintf("user %d", comment.AuthorId)
+
+				if user, err := userLib.GetUser(comment.AuthorId); err == nil {
+					author = user.Firstname + " " + user.Lastname
+				}
+
+				edited := ""
+
+				if comment.IsEdited() {
+					edited = " (edited)"
+				}
+
+				fmt.Fprintf(stdout, "[%d] %s, %s%s\n%s\n\n", comment.Id, author, p.FormatDate(comment.CreatedAt), edited, comment.Body)
+			}
+
+			return nil
+		},
+	})
+	Register(Command{
+		Name:        "comment",
+		Usage:       "comment <task> <markdown>",
+		Description: "comment on a task, @email mentions notify users",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) < 2 {
+				return fmt.Errorf("Usage: todolist comment <task> <markdown>")
+			}
+
+			taskId, err := parseId(args[0], "task")
+
+			if err != nil {
+				return err
+			}
+
+			comment := commentLib.NewComment(taskId, userId, strings.Join(args[1:], " "))
+
+			err = comment.Save()
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(stdout, "[%d]\n", comment.Id)
+
+			return nil
+		},
+	})
+	Register(Command{
+		Name:        "comment-edit",
+		Usage:       "comment-edit <comment> <markdown>",
+		Description: "replace the body of one of your comments",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) < 2 {
+				return fmt.Errorf("Usage: todolist comment-edit <comment> <markdown>")
+			}
+
+			comment, err := getComment(args[0])
+
+			if err != nil {
+				return err
+			}
+
+			comment.Body = strings.Join(args[1:], " ")
+
+			return comment.SaveAs(userId)
+		},
+	})
+	Register(Command{
+		Name:        "comment-delete",
+		Usage:       "comment-delete <comment>",
+		Description: "delete a comment you wrote or on a task you own",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) != 1 {
+				return fmt.Errorf("Usage: todolist comment-delete <comment>")
+			}
+
+			comment, err := getComment(args[0])
+
+			if err != nil {
+				return err
+			}
+
+			return comment.DeleteAs(userId)
+		},
+	})
+}
+
+func getComment(value string) (commentLib.Comment, error) {
+	id, err := parseId(value, "comment")
+
+	if err != nil {
+		return commentLib.Comment{}, err
+	}
+
+	return commentLib.GetComment(id)
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"todolist/permission"
+	taskLib "todolist/task"
+)
+
+func init() {
+	Register(Command{
+		Name:        "list",
+		Usage:       "list [" + strings.Join(taskLib.SORTS, "|") + "]",
+		Description: "list your tasks and the tasks of the lists shared with you, the most urgent first",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) > 1 {
+				return fmt.Errorf("Usage: todolist list [%s]", strings.Join(taskLib.SORTS, "|"))
+			}
+
+			tasks, err := taskLib.GetVisibleTasks(userId)
+
+			if err != nil {
+				return err
+			}
+
+			by := taskLib.URGENCY_SORT
+
+			if len(args) == 1 {
+				by = args[0]
+			}
+
+			err = taskLib.SortTasks(tasks, by, time.Now())
+
+			if err != nil {
+				return err
+			}
+
+			p, err := userPreferences(userId)
+
+			if err != nil {
+				return err
+			}
+
+			return renderTasks(p, tasks)
+		},
+	})
+	Register(Command{
+		Name:        "priority",
+		Usage:       "priority <task> <" + strings.Join(taskLib.PRIORITIES, "|") + ">",
+		Description: "set the priority of a task",
+		Run: withTask(2, "priority <task> <level>", func(userId int64, task taskLib.Task, args []string) error {
+			priority, err := taskLib.ParsePriority(args[1])
+
+			if err != nil {
+				return err
+			}
+
+			task.Priority = priority
+
+			return task.SaveAs(userId)
+		}),
+	})
+	Register(Command{
+		Name:        "show",
+		Usage:       "show <id>",
+		Description: "show every field of a task",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) != 1 {
+				return fmt.Errorf("Usage: todolist show <id>")
+			}
+
+			id, err := parseId(args[0], "task")
+
+			if err != nil {
+				return err
+			}
+
+			task, err := taskLib.GetTaskAs(id, userId)
+
+			if errors.Is(err, permission.ErrForbidden) || (err == nil && task.IsDeleted()) {
+				err = fmt.Errorf("%w: %d", taskLib.ErrTaskNotFound, id)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			p, err := userPreferences(userId)
+
+			if err != nil {
+				return err
+			}
+
+			return renderTask(p, task)
+		},
+	})
+}
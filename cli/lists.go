@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"fmt"
+	listLib "todolist/list"
+	taskLib "todolist/task"
+)
+
+func init() {
+	Register(Command{
+		Name:        "lists",
+		Usage:       "lists",
+		Description: "list your lists and the lists shared with you",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			lists, err := listLib.GetListsByUserId(userId)
+
+			if err != nil {
+				return err
+			}
+
+			for _, list := range lists {
+				role, err := list.Role(userId)
+
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(stdout, "[%d] %s (%s)\n", list.Id, list.Name, role)
+			}
+
+			return nil
+		},
+	})
+	Register(Command{
+		Name:        "list-create",
+		Usage:       "list-create <name> [workspace]",
+		Description: "create a list you can share, in a workspace when given",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) != 1 && len(args) != 2 {
+				return fmt.Errorf("Usage: todolist list-create <name> [workspace]")
+			}
+
+			list := listLib.NewList(args[0], userId)
+
+			if len(args) == 2 {
+				list.WorkspaceId, err = parseId(args[1], "workspace")
+
+				if err != nil {
+					return err
+				}
+			}
+
+			err = list.Save()
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(stdout, "[%d] %s\n", list.Id, list.Name)
+
+			return nil
+		},
+	})
+	Register(Command{
+		Name:        "list-delete",
+		Usage:       "list-delete <list>",
+		Description: "delete a list, its tasks go back to their users",
+		Run: withList(1, "list-delete <list>", func(userId int64, list listLib.List, args []string) error {
+			return list.DeleteAs(userId)
+		}),
+	})
+	Register(Command{
+		Name:        "members",
+		Usage:       "members <list>",
+		Description: "show who a list is shared with",
+		Run: withList(1, "members <list>", func(userId int64, list listLib.List, args []string) error {
+			role, err := list.Role(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if role == "" {
+				return fmt.Errorf("%w: %d", listLib.ErrListNotFound, list.Id)
+			}
+
+			members, err := list.Members()
+
+			if err != nil {
+				return err
+			}
+
+			for _, member := range members {
+				fmt.Fprintf(stdout, "%d %s\n", member.UserId, member.Role)
+			}
+
+			return nil
+		}),
+	})
+	Register(Command{
+		Name:        "share",
+		Usage:       "share <list> <user> <role>",
+		Description: "share a list with a user as viewer, editor or owner",
+		Run: withList(3, "share <list> <user> <role>", func(userId int64, list listLib.List, args []string) error {
+			memberId, err := parseId(args[1], "user")
+
+			if err != nil {
+				return err
+			}
+
+			return list.Share(userId, memberId, args[2])
+		}),
+	})
+	Register(Command{
+		Name:        "unshare",
+		Usage:       "unshare <list> <user>",
+		Description: "remove a user from a list",
+		Run: withList(2, "unshare <list> <user>", func(userId int64, list listLib.List, args []string) error {
+			memberId, err := parseId(args[1], "user")
+
+			if err != nil {
+				return err
+			}
+
+			return list.Unshare(userId, memberId)
+		}),
+	})
+	Register(Command{
+		Name:        "invite",
+		Usage:       "invite <list> <email> <role>",
+		Description: "invite someone by email to join a list",
+		Run: withList(3, "invite <list> <email> <role>", func(userId int64, list listLib.List, args []string) error {
+			_, err := list.Invite(userId, args[1], args[2])
+			return err
+		}),
+	})
+	Register(Command{
+		Name:        "accept",
+		Usage:       "accept <token>",
+		Description: "join the list of an invitation",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) != 1 {
+				return fmt.Errorf("Usage: todolist accept <token>")
+			}
+
+			list, err := listLib.AcceptInvitation(args[0], userId)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(stdout, "[%d] %s\n", list.Id, list.Name)
+
+			return nil
+		},
+	})
+	Register(Command{
+		Name:        "move",
+		Usage:       "move <task> <list>",
+		Description: "move a task to a list, 0 for none",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) != 2 {
+				return fmt.Errorf("Usage: todolist move <task> <list>")
+			}
+
+			taskId, err := parseId(args[0], "task")
+
+			if err != nil {
+				return err
+			}
+
+			listId, err := parseId(args[1], "list")
+
+			if err != nil {
+				return err
+			}
+
+			task, err := taskLib.GetTaskAs(taskId, userId)
+
+			if err != nil {
+				return err
+			}
+
+			task.ListId = listId
+
+			return task.SaveAs(userId)
+		},
+	})
+}
+
+// withList wraps a command taking a list id as first of count arguments.
+func withList(count int, usage string, run func(userId int64, list listLib.List, args []string) error) func(int64, []string) error {
+	return func(userId int64, args []string) error {
+		err := requireUser(userId)
+
+		if err != nil {
+			return err
+		}
+
+		if len(args) != count {
+			return fmt.Errorf("Usage: todolist %s", usage)
+		}
+
+		listId, err := parseId(args[0], "list")
+
+		if err != nil {
+			return err
+		}
+
+		list, err := listLib.GetList(listId)
+
+		if err != nil {
+			return err
+		}
+
+		return run(userId, list, args)
+	}
+}
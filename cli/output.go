@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"errors"
+	"io"
+	"os"
+	"todolist/config"
+	"todolist/preferences"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+)
+
+var stdout io.Writer = os.Stdout
+
+func renderTasks(p preferences.Preferences, tasks []taskLib.Task) error {
+	renderer, err := taskLib.NewRenderer(config.Current.Output, p)
+
+	if err != nil {
+		return err
+	}
+
+	return renderer.Render(stdout, tasks)
+}
+
+func renderTask(p preferences.Preferences, task taskLib.Task) error {
+	renderer, err := taskLib.NewRenderer(config.Current.Output, p)
+
+	if err != nil {
+		return err
+	}
+
+	return renderer.RenderDetails(stdout, task)
+}
+
+// userPreferences returns the preferences of the user, the defaults when the
+// user has no account.
+func userPreferences(userId int64) (preferences.Preferences, error) {
+	user, err := userLib.GetUser(userId)
+
+	if errors.Is(err, userLib.ErrUserNotFound) {
+		return preferences.Default(), nil
+	}
+
+	if err != nil {
+		return preferences.Preferences{}, err
+	}
+
+	return user.Preferences, nil
+}
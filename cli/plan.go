@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+)
+
+const (
+	DEFAULT_PLAN_DAYS = 7
+)
+
+func init() {
+	Register(Command{
+		Name:        "estimate",
+		Usage:       "estimate <task> <effort>",
+		Description: "estimate a task in minutes, 90 or 1h30m, or in points, 3pt, 0 removes it",
+		Run: withTask(2, "estimate <task> <effort>", func(userId int64, task taskLib.Task, args []string) error {
+			estimate, unit, err := taskLib.ParseEstimate(args[1])
+
+			if err != nil {
+				return err
+			}
+
+			task.Estimate, task.EstimateUnit = estimate, unit
+
+			return task.SaveAs(userId)
+		}),
+	})
+	Register(Command{
+		Name:        "capacity",
+		Usage:       "capacity <effort per day> [minutes per point]",
+		Description: "set how much you plan per day, e.g. 6h, 0 stops planning",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) < 1 || len(args) > 2 {
+				return fmt.Errorf("Usage: todolist capacity <effort per day> [minutes per point]")
+			}
+
+			minutes, unit, err := taskLib.ParseEstimate(args[0])
+
+			if err != nil || unit != taskLib.MINUTES_UNIT {
+				return fmt.Errorf("Invalid capacity %q, e.g. 360 or 6h", args[0])
+			}
+
+			user, err := userLib.GetUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			user.Preferences.DailyCapacity = minutes
+
+			if len(args) == 2 {
+				user.Preferences.PointMinutes, err = strconv.Atoi(args[1])
+
+				if err != nil {
+					return fmt.Errorf("Invalid minutes per point %q", args[1])
+				}
+			}
+
+			return user.SaveAs(userId)
+		},
+	})
+	Register(Command{
+		Name:        "plan",
+		Usage:       "plan [days]",
+		Description: "propose a schedule of your open tasks against your capacity",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			days := DEFAULT_PLAN_DAYS
+
+			if len(args) > 1 {
+				return fmt.Errorf("Usage: todolist plan [days]")
+			}
+
+			if len(args) == 1 {
+				days, err = strconv.Atoi(args[0])
+
+				if err != nil {
+					return fmt.Errorf("Invalid number of days %q", args[0])
+				}
+			}
+
+			user, err := userLib.GetUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			plan, err := user.Plan(time.Now(), days)
+
+			if err != nil {
+				return err
+			}
+
+			printPlan(user, plan)
+
+			return nil
+		},
+	})
+}
+
+func printPlan(user userLib.User, plan taskLib.Plan) {
+	p := user.Preferences
+
+	for _, day := range plan.Days {
+		overcommitted := ""
+
+		if day.Overcommitted {
+			overcommitted = " overcommitted"
+		}
+
+		fmt.Fprintf(stdout, "%s %s  %s/%s%s\n", p.T(day.Date.Weekday().String()), p.FormatDate(day.Date), formatDuration(minutes(day.Planned)), formatDuration(minutes(day.Capacity)), overcommitted)
+
+		for _, slot := range day.Slots {
+			printSlot(user, slot)
+		}
+	}
+
+	if len(plan.Deferred) > 0 {
+		fmt.Fprintln(stdout, "Deferred")
+
+		for _, slot := range plan.Deferred {
+			printSlot(user, slot)
+		}
+	}
+
+	if len(plan.Unestimated) > 0 {
+		fmt.Fprintln(stdout, "Unestimated")
+
+		for _, task := range plan.Unestimated {
+			fmt.Fprintf(stdout, "  [%d] %s\n", task.Id, task.Name)
+		}
+	}
+}
+
+func printSlot(user userLib.User, slot taskLib.Slot) {
+	due := ""
+
+	if !slot.EndDate.IsZero() {
+		due = " (" + fmt.Sprintf(user.Preferences.T("due"), user.Preferences.FormatDate(slot.EndDate)) + ")"
+	}
+
+	fmt.Fprintf(stdout, "  [%d] %s  %s%s\n", slot.TaskId, slot.Name, formatDuration(minutes(slot.Minutes)), due)
+}
+
+func minutes(count int) time.Duration {
+	return time.Duration(count) * time.Minute
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"time"
+	"todolist/reminder"
+)
+
+func init() {
+	Register(Command{
+		Name:        "remind",
+		Usage:       "remind",
+		Description: "email every user about tasks due today or overdue",
+		Run: func(userId int64, args []string) error {
+			return reminder.SendAll(time.Now())
+		},
+	})
+	Register(Command{
+		Name:        "digest",
+		Usage:       "digest",
+		Description: "email every user the tasks due this week",
+		Run: func(userId int64, args []string) error {
+			return reminder.SendAllDigests(time.Now())
+		},
+	})
+}
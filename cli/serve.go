@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"todolist/api"
+	"todolist/config"
+)
+
+func init() {
+	Register(Command{
+		Name:        "serve",
+		Usage:       "serve [-addr address]",
+		Description: "start the HTTP API",
+		Run: func(userId int64, args []string) error {
+			flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+			addr := flags.String("addr", config.Current.Server.Addr, "address to listen on (env TODOLIST_ADDR)")
+
+			err := flags.Parse(args)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Listening on", *addr)
+
+			return api.Serve(*addr)
+		},
+	})
+}
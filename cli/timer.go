@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"todolist/preferences"
+	"todolist/timer"
+)
+
+const (
+	DAY_FORMAT = "2006-01-02"
+)
+
+func init() {
+	Register(Command{
+		Name:        "timer",
+		Usage:       "timer start <task> [note] | stop | status | add <task> <duration> [date] | delete <entry> | report [from] [to]",
+		Description: "track the time spent on tasks, dates are written 2006-01-02",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				return fmt.Errorf("Usage: todolist timer start|stop|status|add|delete|report")
+			}
+
+			p, err := userPreferences(userId)
+
+			if err != nil {
+				return err
+			}
+
+			switch args[0] {
+			case "start":
+				return startTimer(userId, args[1:])
+			case "stop":
+				entry, err := timer.Stop(userId)
+
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(stdout, "[%d] %s on task %d\n", entry.Id, formatDuration(entry.Duration()), entry.TaskId)
+
+				return nil
+			case "status":
+				entry, err := timer.Running(userId)
+
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(stdout, "[%d] %s on task %d since %s\n", entry.Id, formatDuration(entry.Duration()), entry.TaskId, entry.StartedAt.In(p.Location()).Format("15:04"))
+
+				return nil
+			case "add":
+				return addEntry(userId, p, args[1:])
+			case "delete":
+				if len(args) != 2 {
+					return fmt.Errorf("Usage: todolist timer delete <entry>")
+				}
+
+				entryId, err := parseId(args[1], "entry")
+
+				if err != nil {
+					return err
+				}
+
+				entry, err := timer.GetEntry(entryId)
+
+				if err != nil {
+					return err
+				}
+
+				return entry.DeleteAs(userId)
+			case "report":
+				return printReport(userId, p, args[1:])
+			}
+
+			return fmt.Errorf("Unknown timer command %s", args[0])
+		},
+	})
+}
+
+func startTimer(userId int64, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Usage: todolist timer start <task> [note]")
+	}
+
+	taskId, err := parseId(args[0], "task")
+
+	if err != nil {
+		return err
+	}
+
+	entry, err := timer.Start(taskId, userId, strings.Join(args[1:], " "))
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "[%d]\n", entry.Id)
+
+	return nil
+}
+
+// addEntry records a duration ending now, or starting at the beginning of
+// the given day.
+func addEntry(userId int64, p preferences.Preferences, args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf("Usage: todolist timer add <task> <duration> [date]")
+	}
+
+	taskId, err := parseId(args[0], "task")
+
+	if err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(args[1])
+
+	if err != nil {
+		return fmt.Errorf("Invalid duration %q, e.g. 1h30m", args[1])
+	}
+
+	end := time.Now()
+
+	if len(args) == 3 {
+		day, err := time.ParseInLocation(DAY_FORMAT, args[2], p.Location())
+
+		if err != nil {
+			return fmt.Errorf("Invalid date %q", args[2])
+		}
+
+		end = day.Add(duration)
+	}
+
+	entry := timer.NewEntry(taskId, userId, end, duration)
+
+	err = entry.Save()
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "[%d]\n", entry.Id)
+
+	return nil
+}
+
+// printReport prints the totals from the from day to the to day included,
+// the current week by default.
+func printReport(userId int64, p preferences.Preferences, args []string) error {
+	from := p.StartOfWeek(time.Now())
+	to := from.AddDate(0, 0, 7)
+
+	if len(args) > 2 {
+		return fmt.Errorf("Usage: todolist timer report [from] [to]")
+	}
+
+	if len(args) > 0 {
+		day, err := time.ParseInLocation(DAY_FORMAT, args[0], p.Location())
+
+		if err != nil {
+			return fmt.Errorf("Invalid date %q", args[0])
+		}
+
+		from, to = day, day.AddDate(0, 0, 7)
+	}
+
+	if len(args) > 1 {
+		day, err := time.ParseInLocation(DAY_FORMAT, args[1], p.Location())
+
+		if err != nil {
+			return fmt.Errorf("Invalid date %q", args[1])
+		}
+
+		to = day.AddDate(0, 0, 1)
+	}
+
+	report, err := timer.GetReport(userId, from, to)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "%s - %s\n", p.FormatDate(from), p.FormatDate(to.AddDate(0, 0, -1)))
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+
+	for _, total := range report.Tasks {
+		fmt.Fprintf(tw, "[%d] %s\t%s\n", total.TaskId, total.Name, formatDuration(total.Duration))
+	}
+
+	for _, total := range report.Labels {
+		label := "#" + total.Label
+
+		if total.Label == "" {
+			label = "(no label)"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\n", label, formatDuration(total.Duration))
+	}
+
+	fmt.Fprintf(tw, "Total\t%s\n", formatDuration(report.Total))
+
+	return tw.Flush()
+}
+
+func formatDuration(duration time.Duration) string {
+	minutes := int(duration.Round(time.Minute) / time.Minute)
+
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
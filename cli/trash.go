@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	taskLib "todolist/task"
+)
+
+func init() {
+	Register(Command{
+		Name:        "trash",
+		Usage:       "trash",
+		Description: "list the deleted tasks",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			tasks, err := taskLib.GetDeletedTasksByUserId(userId)
+
+			if err != nil {
+				return err
+			}
+
+			p, err := userPreferences(userId)
+
+			if err != nil {
+				return err
+			}
+
+			return renderTasks(p, tasks)
+		},
+	})
+	Register(Command{
+		Name:        "restore",
+		Usage:       "restore <id>",
+		Description: "move a task out of the trash",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) != 1 {
+				return fmt.Errorf("Usage: todolist restore <id>")
+			}
+
+			id, err := strconv.ParseInt(args[0], 10, 64)
+
+			if err != nil {
+				return fmt.Errorf("Invalid task id %s", args[0])
+			}
+
+			task := taskLib.GetTask(id)
+
+			if task.Id == 0 || task.UserId != userId || !task.IsDeleted() {
+				return fmt.Errorf("Task %d is not in the trash", id)
+			}
+
+			err = task.RestoreAs(userId)
+
+			if err != nil {
+				return err
+			}
+
+			p, err := userPreferences(userId)
+
+			if err != nil {
+				return err
+			}
+
+			return renderTasks(p, []taskLib.Task{task})
+		},
+	})
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"time"
+	taskLib "todolist/task"
+	"todolist/tui"
+	userLib "todolist/user"
+)
+
+func init() {
+	Register(Command{
+		Name:        "tui",
+		Usage:       "tui",
+		Description: "browse and edit tasks in a full screen interface",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			user, err := userLib.GetUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			tasks, err := taskLib.GetVisibleTasks(userId)
+
+			if err != nil {
+				return err
+			}
+
+			err = taskLib.SortTasks(tasks, taskLib.URGENCY_SORT, time.Now())
+
+			if err != nil {
+				return err
+			}
+
+			return tui.Run(tasks, user.Preferences, func(task *taskLib.Task) error {
+				return task.SaveAs(userId)
+			})
+		},
+	})
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"flag"
+	"todolist/history"
+	"todolist/undo"
+)
+
+func init() {
+	Register(Command{
+		Name:        "undo",
+		Usage:       "undo [-n count]",
+		Description: "revert the last task operations",
+		Run: func(userId int64, args []string) error {
+			return runReplay(userId, args, undo.Undo)
+		},
+	})
+	Register(Command{
+		Name:        "redo",
+		Usage:       "redo [-n count]",
+		Description: "apply again the last undone operations",
+		Run: func(userId int64, args []string) error {
+			return runReplay(userId, args, undo.Redo)
+		},
+	})
+}
+
+func runReplay(userId int64, args []string, replay func(int64, int) ([]history.Entry, error)) error {
+	flags := flag.NewFlagSet("undo", flag.ContinueOnError)
+	count := flags.Int("n", 1, "number of operations")
+
+	err := flags.Parse(args)
+
+	if err != nil {
+		return err
+	}
+
+	err = requireUser(userId)
+
+	if err != nil {
+		return err
+	}
+
+	entries, err := replay(userId, *count)
+
+	if err != nil {
+		return err
+	}
+
+	history.PrintHistory(entries)
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"todolist/config"
+	"todolist/preferences"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+	viewLib "todolist/view"
+)
+
+func init() {
+	Register(Command{
+		Name:        "view",
+		Usage:       "view <" + strings.Join(taskLib.VIEWS, "|") + "|saved view>",
+		Description: "list the tasks of a smart view or of one of your saved views, days follow your timezone",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) != 1 {
+				return fmt.Errorf("Usage: todolist view <%s|saved view>", strings.Join(taskLib.VIEWS, "|"))
+			}
+
+			user, err := userLib.GetUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if taskLib.IsView(args[0]) {
+				tasks, err := user.View(args[0], time.Now())
+
+				if err != nil {
+					return err
+				}
+
+				return renderTasks(user.Preferences, tasks)
+			}
+
+			view, err := viewLib.GetViewByName(userId, args[0])
+
+			if err != nil {
+				return err
+			}
+
+			groups, err := view.Run(time.Now())
+
+			if err != nil {
+				return err
+			}
+
+			return renderGroups(user.Preferences, view, groups)
+		},
+	})
+	Register(Command{
+		Name:        "views",
+		Usage:       "views",
+		Description: "list your saved views",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			views, err := viewLib.GetViewsByUserId(userId)
+
+			if err != nil {
+				return err
+			}
+
+			for _, view := range views {
+				fmt.Fprintf(stdout, "%s  %s\n", view.Name, strings.Join(viewSettings(view), " "))
+			}
+
+			return nil
+		},
+	})
+	Register(Command{
+		Name:        "view-save",
+		Usage:       "view-save <name> [filter=<expression>] [sort=<sort>] [group=<" + strings.Join(viewLib.GROUPS, "|") + ">] [columns=<column,...>]",
+		Description: `save a view or change some of its settings, e.g. view-save work "filter=#work is:open" group=priority`,
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) < 1 {
+				return fmt.Errorf("Usage: todolist view-save <name> [filter=<expression>] [sort=<sort>] [group=<group>] [columns=<column,...>]")
+			}
+
+			view, err := viewLib.GetViewByName(userId, args[0])
+
+			if errors.Is(err, viewLib.ErrViewNotFound) {
+				view, err = viewLib.NewView(userId, args[0]), nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			for _, arg := range args[1:] {
+				key, value, found := strings.Cut(arg, "=")
+
+				if !found {
+					return fmt.Errorf("Invalid setting %q, expected key=value", arg)
+				}
+
+				switch key {
+				case "filter":
+					view.Filter = value
+				case "sort":
+					view.Sort = value
+				case "group":
+					view.GroupBy = value
+				case "columns":
+					view.Columns = nil
+
+					if value != "" {
+						view.Columns = strings.Split(value, ",")
+					}
+				default:
+					return fmt.Errorf("Unknown setting %q, expected filter, sort, group or columns", key)
+				}
+			}
+
+			return view.SaveAs(userId)
+		},
+	})
+	Register(Command{
+		Name:        "view-delete",
+		Usage:       "view-delete <name>",
+		Description: "delete one of your saved views",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) != 1 {
+				return fmt.Errorf("Usage: todolist view-delete <name>")
+			}
+
+			view, err := viewLib.GetViewByName(userId, args[0])
+
+			if err != nil {
+				return err
+			}
+
+			return view.DeleteAs(userId)
+		},
+	})
+}
+
+// viewSettings returns the settings of the view as key=value pairs, the
+// default ones left out.
+func viewSettings(view viewLib.View) []string {
+	var settings []string
+
+	if view.Filter != "" {
+		settings = append(settings, fmt.Sprintf("filter=%q", view.Filter))
+	}
+
+	if view.Sort != "" {
+		settings = append(settings, "sort="+view.Sort)
+	}
+
+	if view.GroupBy != "" {
+		settings = append(settings, "group="+view.GroupBy)
+	}
+
+	if len(view.Columns) > 0 {
+		settings = append(settings, "columns="+strings.Join(view.Columns, ","))
+	}
+
+	return settings
+}
+
+// renderGroups writes each group under its key with the configured output,
+// the columns of the view turn the text output into a table. The JSON output
+// is the list of groups.
+func renderGroups(p preferences.Preferences, view viewLib.View, groups []viewLib.Group) error {
+	format := config.Current.Output
+
+	if format == taskLib.JSON_FORMAT {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(groups)
+	}
+
+	if len(view.Columns) > 0 && (format == taskLib.TEXT_FORMAT || format == "") {
+		format = taskLib.TABLE_FORMAT + "=" + strings.Join(view.Columns, ",")
+	}
+
+	renderer, err := taskLib.NewRenderer(format, p)
+
+	if err != nil {
+		return err
+	}
+
+	for i, group := range groups {
+		if view.GroupBy != "" {
+			if i > 0 {
+				fmt.Fprintln(stdout)
+			}
+
+			key := group.Key
+
+			if key == "" {
+				key = "-"
+			} else if view.GroupBy == viewLib.PRIORITY_GROUP {
+				key = p.T(key)
+			}
+
+			fmt.Fprintf(stdout, "%s (%d)\n", key, len(group.Tasks))
+		}
+
+		err := renderer.Render(stdout, group.Tasks)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	listLib "todolist/list"
+	taskLib "todolist/task"
+)
+
+func init() {
+	Register(Command{
+		Name:        "workflow",
+		Usage:       "workflow <list>",
+		Description: "show the statuses and allowed moves of a list",
+		Run: withList(1, "workflow <list>", func(userId int64, list listLib.List, args []string) error {
+			err := requireMember(userId, list)
+
+			if err != nil {
+				return err
+			}
+
+			workflow, err := taskLib.GetWorkflow(list.Id)
+
+			if err != nil {
+				return err
+			}
+
+			for _, status := range workflow.Statuses {
+				fmt.Fprintln(stdout, formatStatus(status))
+			}
+
+			for _, transition := range workflow.Transitions {
+				fmt.Fprintf(stdout, "%s -> %s\n", transition.From, transition.To)
+			}
+
+			return nil
+		}),
+	})
+	Register(Command{
+		Name:        "workflow-set",
+		Usage:       "workflow-set <list> <status>...",
+		Description: "replace the statuses of a list, written name, name:wip_limit, name! when terminal",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) < 2 {
+				return fmt.Errorf("Usage: todolist workflow-set <list> <status>...")
+			}
+
+			listId, err := parseId(args[0], "list")
+
+			if err != nil {
+				return err
+			}
+
+			workflow := taskLib.Workflow{ListId: listId}
+
+			for _, arg := range args[1:] {
+				status, err := parseStatus(arg)
+
+				if err != nil {
+					return err
+				}
+
+				workflow.Statuses = append(workflow.Statuses, status)
+			}
+
+			return workflow.SaveAs(userId)
+		},
+	})
+	Register(Command{
+		Name:        "workflow-allow",
+		Usage:       "workflow-allow <list> <from> <to>",
+		Description: "allow a move between two statuses, once a move is listed the others are refused",
+		Run: withList(3, "workflow-allow <list> <from> <to>", func(userId int64, list listLib.List, args []string) error {
+			workflow, err := taskLib.GetWorkflow(list.Id)
+
+			if err != nil {
+				return err
+			}
+
+			workflow.Transitions = append(workflow.Transitions, taskLib.Transition{From: args[1], To: args[2]})
+
+			return workflow.SaveAs(userId)
+		}),
+	})
+	Register(Command{
+		Name:        "status",
+		Usage:       "status <task> <status>",
+		Description: "move a task to another status of its workflow",
+		Run: withTask(2, "status <task> <status>", func(userId int64, task taskLib.Task, args []string) error {
+			return task.Move(userId, strings.Join(args[1:], " "))
+		}),
+	})
+	Register(Command{
+		Name:        "board",
+		Usage:       "board <list>",
+		Description: "show the tasks of a list by status",
+		Run: withList(1, "board <list>", func(userId int64, list listLib.List, args []string) error {
+			err := requireMember(userId, list)
+
+			if err != nil {
+				return err
+			}
+
+			board, err := taskLib.GetBoard(list.Id)
+
+			if err != nil {
+				return err
+			}
+
+			p, err := userPreferences(userId)
+
+			if err != nil {
+				return err
+			}
+
+			for _, column := range board {
+				count := strconv.Itoa(len(column.Tasks))
+
+				if column.Status.WIPLimit > 0 {
+					count += "/" + strconv.Itoa(column.Status.WIPLimit)
+				}
+
+				fmt.Fprintf(stdout, "== %s (%s) ==\n", column.Status.Name, count)
+
+				err = renderTasks(p, column.Tasks)
+
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}),
+	})
+	Register(Command{
+		Name:        "status-history",
+		Usage:       "status-history <task>",
+		Description: "show the moves of a task between statuses",
+		Run: withTask(1, "status-history <task>", func(userId int64, task taskLib.Task, args []string) error {
+			changes, err := task.StatusHistory()
+
+			if err != nil {
+				return err
+			}
+
+			p, err := userPreferences(userId)
+
+			if err != nil {
+				return err
+			}
+
+			for _, change := range changes {
+				from := change.From
+
+				if from == "" {
+					from = "created"
+				}
+
+				fmt.Fprintf(stdout, "%s %s -> %s (user %d)\n", p.FormatDate(change.CreatedAt), from, change.To, change.ActorId)
+			}
+
+			return nil
+		}),
+	})
+}
+
+// requireMember returns ErrListNotFound when the list is not shared with
+// the user.
+func requireMember(userId int64, list listLib.List) error {
+	role, err := list.Role(userId)
+
+	if err != nil {
+		return err
+	}
+
+	if role == "" {
+		return fmt.Errorf("%w: %d", listLib.ErrListNotFound, list.Id)
+	}
+
+	return nil
+}
+
+// parseStatus reads a status written name, name:wip_limit, name! or
+// name:wip_limit!.
+func parseStatus(value string) (taskLib.Status, error) {
+	status := taskLib.Status{Name: strings.TrimSuffix(value, "!")}
+	status.Terminal = status.Name != value
+
+	if i := strings.LastIndex(status.Name, ":"); i >= 0 {
+		limit, err := strconv.Atoi(status.Name[i+1:])
+
+		if err != nil {
+			return taskLib.Status{}, fmt.Errorf("Invalid WIP limit in %q", value)
+		}
+
+		status.Name, status.WIPLimit = status.Name[:i], limit
+	}
+
+	return status, nil
+}
+
+func formatStatus(status taskLib.Status) string {
+	text := status.Name
+
+	if status.WIPLimit > 0 {
+		text += fmt.Sprintf(" (WIP %d)", status.WIPLimit)
+	}
+
+	if status.Terminal {
+		text += " (terminal)"
+	}
+
+	return text
+}
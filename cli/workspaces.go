@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"fmt"
+	workspaceLib "todolist/workspace"
+)
+
+func init() {
+	Register(Command{
+		Name:        "workspaces",
+		Usage:       "workspaces",
+		Description: "list the workspaces you are a member of and the ones you were added to",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			workspaces, err := workspaceLib.GetWorkspacesByUserId(userId)
+
+			if err != nil {
+				return err
+			}
+
+			for _, workspace := range workspaces {
+				role, err := workspace.Role(userId)
+
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(stdout, "[%d] %s (%s)\n", workspace.Id, workspace.Name, role)
+			}
+
+			invited, err := workspaceLib.GetInvitedWorkspaces(userId)
+
+			if err != nil {
+				return err
+			}
+
+			for _, workspace := range invited {
+				fmt.Fprintf(stdout, "[%d] %s (pending, workspace-accept %d to join)\n", workspace.Id, workspace.Name, workspace.Id)
+			}
+
+			return nil
+		},
+	})
+	Register(Command{
+		Name:        "workspace-create",
+		Usage:       "workspace-create <name>",
+		Description: "create a workspace you administrate",
+		Run: func(userId int64, args []string) error {
+			err := requireUser(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if len(args) != 1 {
+				return fmt.Errorf("Usage: todolist workspace-create <name>")
+			}
+
+			workspace := workspaceLib.NewWorkspace(args[0])
+
+			err = workspace.SaveAs(userId)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(stdout, "[%d] %s\n", workspace.Id, workspace.Name)
+
+			return nil
+		},
+	})
+	Register(Command{
+		Name:        "workspace-delete",
+		Usage:       "workspace-delete <workspace>",
+		Description: "delete a workspace, its lists are kept",
+		Run: withWorkspace(1, "workspace-delete <workspace>", func(userId int64, workspace workspaceLib.Workspace, args []string) error {
+			return workspace.DeleteAs(userId)
+		}),
+	})
+	Register(Command{
+		Name:        "workspace-members",
+		Usage:       "workspace-members <workspace>",
+		Description: "show the members of a workspace",
+		Run: withWorkspace(1, "workspace-members <workspace>", func(userId int64, workspace workspaceLib.Workspace, args []string) error {
+			role, err := workspace.Role(userId)
+
+			if err != nil {
+				return err
+			}
+
+			if role == "" {
+				return fmt.Errorf("%w: %d", workspaceLib.ErrWorkspaceNotFound, workspace.Id)
+			}
+
+			members, err := workspace.Members()
+
+			if err != nil {
+				return err
+			}
+
+			for _, member := range members {
+				fmt.Fprintf(stdout, "%d %s\n", member.UserId, member.Role)
+			}
+
+			return nil
+		}),
+	})
+	Register(Command{
+		Name:        "workspace-add",
+		Usage:       "workspace-add <workspace> <user> <role>",
+		Description: "add a user to a workspace as guest, member or admin, the user joins once accepted",
+		Run: withWorkspace(3, "workspace-add <workspace> <user> <role>", func(userId int64, workspace workspaceLib.Workspace, args []string) error {
+			memberId, err := parseId(args[1], "user")
+
+			if err != nil {
+				return err
+			}
+
+			return workspace.AddMember(userId, memberId, args[2])
+		}),
+	})
+	Register(Command{
+		Name:        "workspace-accept",
+		Usage:       "workspace-accept <workspace>",
+		Description: "join a workspace you were added to, workspace-remove declines it",
+		Run: withWorkspace(1, "workspace-accept <workspace>", func(userId int64, workspace workspaceLib.Workspace, args []string) error {
+			return workspace.Accept(userId)
+		}),
+	})
+	Register(Command{
+		Name:        "workspace-remove",
+		Usage:       "workspace-remove <workspace> <user>",
+		Description: "remove a user from a workspace",
+		Run: withWorkspace(2, "workspace-remove <workspace> <user>", func(userId int64, workspace workspaceLib.Workspace, args []string) error {
+			memberId, err := parseId(args[1], "user")
+
+			if err != nil {
+				return err
+			}
+
+			return workspace.RemoveMember(userId, memberId)
+		}),
+	})
+}
+
+// withWorkspace wraps a command taking a workspace id as first of count
+// arguments.
+func withWorkspace(count int, usage string, run func(userId int64, workspace workspaceLib.Workspace, args []string) error) func(int64, []string) error {
+	return func(userId int64, args []string) error {
+		err := requireUser(userId)
+
+		if err != nil {
+			return err
+		}
+
+		if len(args) != count {
+			return fmt.Errorf("Usage: todolist %s", usage)
+		}
+
+		workspaceId, err := parseId(args[0], "workspace")
+
+		if err != nil {
+			return err
+		}
+
+		workspace, err := workspaceLib.GetWorkspace(workspaceId)
+
+		if err != nil {
+			return err
+		}
+
+		return run(userId, workspace, args)
+	}
+}
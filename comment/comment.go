@@ -0,0 +1,349 @@
+package comment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"todolist/events"
+	"todolist/permission"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+	"todolist/utils"
+)
+
+// Comment is a message on a task, its body is Markdown and is stored as
+// written.
+type Comment struct {
+	Id        int64     `json:"id"`
+	TaskId    int64     `json:"task_id"`
+	AuthorId  int64     `json:"author_id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CommentInterface interface {
+	Validate() utils.ValidationErrors
+	IsEdited() bool
+	Mentions() []string
+	Save() error
+	SaveAs(actorId int64) error
+	SaveContext(ctx context.Context, actorId int64) error
+	Delete() error
+	DeleteAs(actorId int64) error
+	DeleteContext(ctx context.Context, actorId int64) error
+}
+
+const (
+	COMMENT_COLUMNS = "id, task_id, author_id, body, created_at, updated_at"
+)
+
+const (
+	MAX_BODY_LENGTH = 10000
+)
+
+var (
+	ErrCommentNotFound = errors.New("Comment not found")
+)
+
+// mention matches @ followed by the email of a user, e.g.
+// "@jane@example.com", unless the @ is part of a word.
+var mention = regexp.MustCompile(`(?:^|[^\w.@])@([\w.%+-]+@[\w-]+(?:\.[\w-]+)+)`)
+
+func NewComment(taskId int64, authorId int64, body string) Comment {
+	now := time.Now()
+
+	return Comment{
+		TaskId:    taskId,
+		AuthorId:  authorId,
+		Body:      body,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func GetComment(id int64) (Comment, error) {
+	return GetCommentContext(context.Background(), id)
+}
+
+func GetCommentContext(ctx context.Context, id int64) (Comment, error) {
+	var comment Comment
+
+	row := utils.SqliteInstance.DB.QueryRowContext(ctx, "SELECT "+COMMENT_COLUMNS+" FROM comments WHERE id = ?", id)
+	err := row.Scan(&comment.Id, &comment.TaskId, &comment.AuthorId, &comment.Body, &comment.CreatedAt, &comment.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return Comment{}, fmt.Errorf("%w: %d", ErrCommentNotFound, id)
+	}
+
+	if err != nil {
+		return Comment{}, fmt.Errorf("Scanning comment %d: %w", id, err)
+	}
+
+	return comment, nil
+}
+
+// GetCommentsByTaskId returns the thread of the task, oldest first.
+func GetCommentsByTaskId(taskId int64) ([]Comment, error) {
+	return GetCommentsByTaskIdContext(context.Background(), taskId)
+}
+
+func GetCommentsByTaskIdContext(ctx context.Context, taskId int64) ([]Comment, error) {
+	var comments []Comment
+
+	rows, err := utils.SqliteInstance.DB.QueryContext(ctx, "SELECT "+COMMENT_COLUMNS+" FROM comments WHERE task_id = ? ORDER BY created_at, id", taskId)
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying comments: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var comment Comment
+
+		err := rows.Scan(&comment.Id, &comment.TaskId, &comment.AuthorId, &comment.Body, &comment.CreatedAt, &comment.UpdatedAt)
+
+		if err != nil {
+			return nil, fmt.Errorf("Scanning comment: %w", err)
+		}
+
+		comments = append(comments, comment)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// Mentions returns the emails mentioned in body, each one once.
+func Mentions(body string) []string {
+	var emails []string
+
+	seen := map[string]bool{}
+
+	for _, match := range mention.FindAllStringSubmatch(body, -1) {
+		email := strings.TrimRight(match[1], ".")
+
+		if !seen[email] {
+			seen[email] = true
+			emails = append(emails, email)
+		}
+	}
+
+	return emails
+}
+
+func (c *Comment) Mentions() []string {
+	return Mentions(c.Body)
+}
+
+func (c *Comment) IsEdited() bool {
+	return c.UpdatedAt.After(c.CreatedAt)
+}
+
+func (c *Comment) Validate() utils.ValidationErrors {
+	var errs utils.ValidationErrors
+
+	if strings.TrimSpace(c.Body) == "" {
+		errs.Add("body", "is required")
+	} else if len(c.Body) > MAX_BODY_LENGTH {
+		errs.Add("body", fmt.Sprintf("must be at most %d characters", MAX_BODY_LENGTH))
+	}
+
+	if c.TaskId == 0 {
+		errs.Add("task_id", "is required")
+	}
+
+	if c.AuthorId == 0 {
+		errs.Add("author_id", "is required")
+	}
+
+	return errs
+}
+
+func (c *Comment) Save() error {
+	return c.SaveAs(c.AuthorId)
+}
+
+func (c *Comment) SaveAs(actorId int64) error {
+	return c.SaveContext(context.Background(), actorId)
+}
+
+// SaveContext posts the comment as actorId when actorId may read the task, or
+// edits it when actorId wrote it. Users mentioned for the first time are
+// notified.
+func (c *Comment) SaveContext(ctx context.Context, actorId int64) error {
+	var mentioned []string
+
+	// a comment is posted by the actor, not on behalf of another user
+	if c.Id == 0 && actorId != 0 {
+		if c.AuthorId != 0 && c.AuthorId != actorId {
+			return fmt.Errorf("%w: user %d can not post as user %d", permission.ErrForbidden, actorId, c.AuthorId)
+		}
+
+		c.AuthorId = actorId
+	}
+
+	if errs := c.Validate(); len(errs) > 0 {
+		return errs
+	}
+
+	var old Comment
+	var err error
+
+	if c.Id != 0 {
+		old, err = GetCommentContext(ctx, c.Id)
+
+		if err != nil {
+			return err
+		}
+
+		if actorId != 0 && actorId != old.AuthorId {
+			return fmt.Errorf("%w: user %d can not edit comment %d", permission.ErrForbidden, actorId, c.Id)
+		}
+
+		c.TaskId = old.TaskId
+		c.AuthorId = old.AuthorId
+	}
+
+	task, err := taskLib.GetTaskAsContext(ctx, c.TaskId, actorId)
+
+	if err != nil {
+		return err
+	}
+
+	if c.Id == 0 {
+		res, err := utils.SqliteInstance.DB.ExecContext(ctx, "INSERT INTO comments (task_id, author_id, body, created_at, updated_at) VALUES (?, ?, ?, ?, ?)", c.TaskId, c.AuthorId, c.Body, c.CreatedAt, c.UpdatedAt)
+
+		if err != nil {
+			return err
+		}
+
+		c.Id, err = res.LastInsertId()
+
+		if err != nil {
+			return err
+		}
+
+		mentioned = c.Mentions()
+	} else {
+		c.CreatedAt = old.CreatedAt
+		c.UpdatedAt = time.Now()
+		_, err = utils.SqliteInstance.DB.ExecContext(ctx, "UPDATE comments SET body = ?, updated_at = ? WHERE id = ?", c.Body, c.UpdatedAt, c.Id)
+
+		if err != nil {
+			return err
+		}
+
+		known := map[string]bool{}
+
+		for _, email := range old.Mentions() {
+			known[email] = true
+		}
+
+		for _, email := range c.Mentions() {
+			if !known[email] {
+				mentioned = append(mentioned, email)
+			}
+		}
+	}
+
+	return c.notify(ctx, task, mentioned)
+}
+
+// notify publishes a CommentMention for each mentioned user who may read the
+// task, unknown emails are ignored.
+func (c *Comment) notify(ctx context.Context, task taskLib.Task, emails []string) error {
+	var errs []error
+
+	for _, email := range emails {
+		user, err := userLib.GetUserByEmailContext(ctx, email)
+
+		if errors.Is(err, userLib.ErrUserNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if user.Id == c.AuthorId {
+			continue
+		}
+
+		role, err := task.RoleContext(ctx, user.Id)
+
+		if err != nil {
+			return err
+		}
+
+		if !permission.Authorize(permission.TASK_RESOURCE, role, permission.READ_ACTION) {
+			continue
+		}
+
+		err = events.Publish(events.CommentMention{
+			CommentId: c.Id,
+			TaskId:    task.Id,
+			TaskName:  task.Name,
+			AuthorId:  c.AuthorId,
+			UserId:    user.Id,
+			Email:     user.Email,
+			Body:      c.Body,
+		})
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Comment) Delete() error {
+	return c.DeleteAs(c.AuthorId)
+}
+
+func (c *Comment) DeleteAs(actorId int64) error {
+	return c.DeleteContext(context.Background(), actorId)
+}
+
+// DeleteContext removes the comment, its author and the owners of the task
+// may delete it.
+func (c *Comment) DeleteContext(ctx context.Context, actorId int64) error {
+	stored, err := GetCommentContext(ctx, c.Id)
+
+	if err != nil {
+		return err
+	}
+
+	if actorId != 0 && actorId != stored.AuthorId {
+		task, err := taskLib.GetTaskContext(ctx, stored.TaskId)
+
+		if err != nil {
+			return err
+		}
+
+		role, err := task.RoleContext(ctx, actorId)
+
+		if err != nil {
+			return err
+		}
+
+		if !permission.Authorize(permission.TASK_RESOURCE, role, permission.SHARE_ACTION) {
+			return fmt.Errorf("%w: user %d can not delete comment %d", permission.ErrForbidden, actorId, c.Id)
+		}
+	}
+
+	_, err = utils.SqliteInstance.DB.ExecContext(ctx, "DELETE FROM comments WHERE id = ?", c.Id)
+
+	return err
+}
@@ -0,0 +1,212 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"todolist/events"
+	"todolist/permission"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+	"todolist/utils"
+
+	fakerLib "github.com/jaswdr/faker"
+)
+
+var faker = fakerLib.New()
+
+func newUser(t *testing.T, email string) userLib.User {
+	user := userLib.NewUser(faker.Person().FirstName(), faker.Person().LastName(), email, nil)
+	user.Birthdate = time.Now().AddDate(-30, 0, 0)
+
+	if err := user.Save(); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	return user
+}
+
+// newTask creates a task of owner in a list shared with the editors.
+func newTask(owner userLib.User, editors ...userLib.User) taskLib.Task {
+	utils.SqliteInstance.DB.Exec("INSERT INTO list_members (list_id, user_id, role) VALUES (1, ?, 'owner')", owner.Id)
+
+	for _, editor := range editors {
+		utils.SqliteInstance.DB.Exec("INSERT INTO list_members (list_id, user_id, role) VALUES (1, ?, 'editor')", editor.Id)
+	}
+
+	task := taskLib.NewTask("Release")
+	task.UserId = owner.Id
+	task.ListId = 1
+	task.Save()
+
+	return task
+}
+
+func TestMentions(t *testing.T) {
+	cases := []struct {
+		body     string
+		expected []string
+	}{
+		{"No mention", nil},
+		{"@jane@example.com can you review?", []string{"jane@example.com"}},
+		{"Ping @jane@example.com and @john.doe@example.org.", []string{"jane@example.com", "john.doe@example.org"}},
+		{"**@jane@example.com** twice @jane@example.com", []string{"jane@example.com"}},
+		{"Mail jane@example.com", nil},
+		{"@jane alone", nil},
+	}
+
+	for _, c := range cases {
+		mentions := Mentions(c.body)
+
+		if len(mentions) != len(c.expected) {
+			t.Errorf("%q mentions should be %v but got %v", c.body, c.expected, mentions)
+			continue
+		}
+
+		for i := range mentions {
+			if mentions[i] != c.expected[i] {
+				t.Errorf("%q mentions should be %v but got %v", c.body, c.expected, mentions)
+			}
+		}
+	}
+}
+
+func TestSaveComment(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	owner := newUser(t, "owner@example.com")
+	editor := newUser(t, "editor@example.com")
+	outsider := newUser(t, "outsider@example.com")
+	task := newTask(owner, editor)
+
+	comment := NewComment(task.Id, editor.Id, "Looks *good* to me")
+
+	if err := comment.Save(); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	other := NewComment(task.Id, outsider.Id, "Hello")
+
+	if err := other.Save(); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Outsider should not comment but got", err)
+	}
+
+	invalid := NewComment(task.Id, owner.Id, " ")
+
+	if err := invalid.Save(); err == nil {
+		t.Error("Should return an error without a body")
+	}
+
+	comment.Body = "Looks *great* to me"
+
+	if err := comment.SaveAs(owner.Id); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Only the author should edit but got", err)
+	}
+	if err := comment.Save(); err != nil {
+		t.Error("Author should edit but got", err)
+	}
+
+	saved, err := GetComment(comment.Id)
+
+	if err != nil || saved.Body != "Looks *great* to me" || !saved.IsEdited() {
+		t.Error("Comment should be edited but got", saved, err)
+	}
+
+	forged := NewComment(task.Id, owner.Id, "Approved")
+
+	if err := forged.SaveAs(editor.Id); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Editor should not post as the owner but got", err)
+	}
+
+	reply := NewComment(task.Id, 0, "Thanks")
+
+	if err := reply.SaveAs(owner.Id); err != nil || reply.AuthorId != owner.Id {
+		t.Error("Comment should be posted by the actor but got", reply.AuthorId, err)
+	}
+
+	comments, _ := GetCommentsByTaskId(task.Id)
+
+	if len(comments) != 2 || comments[0].Id != comment.Id || comments[1].Id != reply.Id {
+		t.Error("Thread should have the comment then the reply but got", comments)
+	}
+
+	stored, _ := taskLib.GetTaskContext(context.Background(), task.Id)
+
+	if stored.Comments != 2 {
+		t.Error("Task should count 2 comments but got", stored.Comments)
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	owner := newUser(t, "owner@example.com")
+	editor := newUser(t, "editor@example.com")
+	other := newUser(t, "other@example.com")
+	task := newTask(owner, editor, other)
+
+	comment := NewComment(task.Id, editor.Id, "First")
+	comment.Save()
+
+	if err := comment.DeleteAs(other.Id); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Another editor should not delete but got", err)
+	}
+
+	// the stored author counts, not the one of the struct
+	forged := comment
+	forged.AuthorId = other.Id
+
+	if err := forged.DeleteAs(other.Id); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Another editor should not delete as the author but got", err)
+	}
+	if err := comment.DeleteAs(owner.Id); err != nil {
+		t.Error("Owner of the task should delete but got", err)
+	}
+	if _, err := GetComment(comment.Id); !errors.Is(err, ErrCommentNotFound) {
+		t.Error("Error should be", ErrCommentNotFound, "but got", err)
+	}
+
+	comment = NewComment(task.Id, editor.Id, "Second")
+	comment.Save()
+	task.Delete()
+	task.Purge()
+
+	if comments, _ := GetCommentsByTaskId(task.Id); len(comments) != 0 {
+		t.Error("Purging the task should remove its comments but got", comments)
+	}
+}
+
+func TestCommentMentions(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+	defer events.BusInstance.Reset()
+
+	var mentioned []string
+
+	events.Subscribe(events.COMMENT_MENTION, func(event events.Event) error {
+		mentioned = append(mentioned, event.(events.CommentMention).Email)
+		return nil
+	})
+
+	owner := newUser(t, "owner@example.com")
+	editor := newUser(t, "editor@example.com")
+	newUser(t, "outsider@example.com")
+	task := newTask(owner, editor)
+
+	comment := NewComment(task.Id, owner.Id, "@editor@example.com @outsider@example.com @owner@example.com @ghost@example.com please check")
+	comment.Save()
+
+	if len(mentioned) != 1 || mentioned[0] != "editor@example.com" {
+		t.Fatal("Only the editor should be notified but got", mentioned)
+	}
+
+	comment.Body += ", thanks @editor@example.com"
+	comment.Save()
+
+	if len(mentioned) != 1 {
+		t.Error("Editing should not notify twice but got", mentioned)
+	}
+}
@@ -0,0 +1,292 @@
+package config
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+	"todolist/utils"
+)
+
+const (
+	APP_NAME    = "todolist"
+	CONFIG_FILE = "config.json"
+	ENV_CONFIG  = "TODOLIST_CONFIG"
+)
+
+type Database struct {
+	Driver string `json:"driver"`
+	Path   string `json:"path"`
+}
+
+type SMTP struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+}
+
+type Quota struct {
+	MaxTasks     int `json:"max_tasks"`
+	WarningTasks int `json:"warning_tasks"`
+}
+
+// Attachments are stored under Path, next to the database when empty, and
+// may not be larger than MaxSize bytes.
+type Attachments struct {
+	Path    string `json:"path"`
+	MaxSize int64  `json:"max_size"`
+}
+
+// Trash keeps deleted tasks for RetentionDays days before purging them.
+type Trash struct {
+	RetentionDays int `json:"retention_days"`
+}
+
+type Server struct {
+	Addr string `json:"addr"`
+}
+
+type Config struct {
+	Database Database `json:"database"`
+	SMTP     SMTP     `json:"smtp"`
+	Quota    Quota    `json:"quota"`
+	Server   Server   `json:"server"`
+	Output   string   `json:"output"`
+
+	Attachments Attachments `json:"attachments"`
+	Trash       Trash       `json:"trash"`
+}
+
+type ConfigInterface interface {
+	Validate() utils.ValidationErrors
+}
+
+var Current = Default()
+
+func Default() Config {
+	return Config{
+		Database: Database{Driver: utils.DRIVER, Path: defaultDatabasePath()},
+		SMTP:     SMTP{Port: 587},
+		Quota:    Quota{MaxTasks: 10, WarningTasks: 8},
+		Server:   Server{Addr: ":8080"},
+		Output:   "text",
+
+		Attachments: Attachments{MaxSize: 10 << 20},
+		Trash:       Trash{RetentionDays: 30},
+	}
+}
+
+// AttachmentsPath returns the directory of the attachments.
+func (c *Config) AttachmentsPath() string {
+	if c.Attachments.Path != "" {
+		return c.Attachments.Path
+	}
+
+	return filepath.Join(filepath.Dir(c.Database.Path), "attachments")
+}
+
+// TrashRetention returns how long deleted tasks stay in the trash.
+func (c *Config) TrashRetention() time.Duration {
+	return time.Duration(c.Trash.RetentionDays) * 24 * time.Hour
+}
+
+// Load returns the configuration built from the defaults, then the file at
+// path, then the environment, each overriding the previous one. An empty
+// path looks for the file in TODOLIST_CONFIG then in the XDG config
+// directory, where it may be missing.
+func Load(path string) (Config, error) {
+	config := Default()
+
+	if path == "" {
+		path = os.Getenv(ENV_CONFIG)
+	}
+
+	required := path != ""
+
+	if path == "" {
+		path = Path()
+	}
+
+	err := config.readFile(path)
+
+	if errors.Is(err, os.ErrNotExist) && !required {
+		err = nil
+	}
+
+	if err != nil {
+		return config, err
+	}
+
+	err = config.readEnv()
+
+	if err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Path returns the default location of the configuration file,
+// $XDG_CONFIG_HOME/todolist/config.json or ~/.config/todolist/config.json.
+func Path() string {
+	return filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config"), APP_NAME, CONFIG_FILE)
+}
+
+// defaultDatabasePath keeps using a tasks.db left in the working directory
+// by older versions, otherwise the database lives in the XDG data directory.
+func defaultDatabasePath() string {
+	if utils.FileExist(utils.DB_FILE) {
+		return utils.DB_FILE
+	}
+
+	return filepath.Join(xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share")), APP_NAME, utils.DB_FILE)
+}
+
+func xdgDir(env string, fallback string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+
+	home, err := os.UserHomeDir()
+
+	if err != nil {
+		return fallback
+	}
+
+	return filepath.Join(home, fallback)
+}
+
+func (c *Config) readFile(path string) error {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(c)
+
+	if err != nil {
+		return fmt.Errorf("Invalid config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *Config) readEnv() error {
+	strings := map[string]*string{
+		"TODOLIST_DB_DRIVER":        &c.Database.Driver,
+		"TODOLIST_DB_PATH":          &c.Database.Path,
+		"TODOLIST_SMTP_HOST":        &c.SMTP.Host,
+		"TODOLIST_SMTP_USERNAME":    &c.SMTP.Username,
+		"TODOLIST_SMTP_PASSWORD":    &c.SMTP.Password,
+		"TODOLIST_SMTP_FROM":        &c.SMTP.From,
+		"TODOLIST_ADDR":             &c.Server.Addr,
+		"TODOLIST_OUTPUT":           &c.Output,
+		"TODOLIST_ATTACHMENTS_PATH": &c.Attachments.Path,
+	}
+
+	for env, field := range strings {
+		if value, ok := os.LookupEnv(env); ok {
+			*field = value
+		}
+	}
+
+	ints := map[string]*int{
+		"TODOLIST_SMTP_PORT":            &c.SMTP.Port,
+		"TODOLIST_QUOTA_MAX_TASKS":      &c.Quota.MaxTasks,
+		"TODOLIST_QUOTA_WARNING_TASKS":  &c.Quota.WarningTasks,
+		"TODOLIST_TRASH_RETENTION_DAYS": &c.Trash.RetentionDays,
+	}
+
+	for env, field := range ints {
+		value, ok := os.LookupEnv(env)
+
+		if !ok {
+			continue
+		}
+
+		number, err := strconv.Atoi(value)
+
+		if err != nil {
+			return fmt.Errorf("Invalid %s %q, expected a number", env, value)
+		}
+
+		*field = number
+	}
+
+	if value, ok := os.LookupEnv("TODOLIST_ATTACHMENTS_MAX_SIZE"); ok {
+		number, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil {
+			return fmt.Errorf("Invalid TODOLIST_ATTACHMENTS_MAX_SIZE %q, expected a number", value)
+		}
+
+		c.Attachments.MaxSize = number
+	}
+
+	return nil
+}
+
+// Validate checks the settings, a max_tasks of 0 means no quota.
+func (c *Config) Validate() utils.ValidationErrors {
+	var errs utils.ValidationErrors
+
+	if !isDriver(c.Database.Driver) {
+		errs.Add("database.driver", fmt.Sprintf("%q is not a known driver", c.Database.Driver))
+	}
+
+	if c.Database.Path == "" {
+		errs.Add("database.path", "is required")
+	}
+
+	if c.SMTP.Port < 1 || c.SMTP.Port > 65535 {
+		errs.Add("smtp.port", "must be between 1 and 65535")
+	}
+
+	if c.SMTP.Host != "" && c.SMTP.From == "" {
+		errs.Add("smtp.from", "is required when smtp.host is set")
+	}
+
+	if c.Quota.MaxTasks < 0 {
+		errs.Add("quota.max_tasks", "must not be negative")
+	}
+
+	if c.Quota.WarningTasks < 0 || (c.Quota.MaxTasks > 0 && c.Quota.WarningTasks > c.Quota.MaxTasks) {
+		errs.Add("quota.warning_tasks", "must be between 0 and quota.max_tasks")
+	}
+
+	if c.Server.Addr == "" {
+		errs.Add("server.addr", "is required")
+	}
+
+	if c.Attachments.MaxSize <= 0 {
+		errs.Add("attachments.max_size", "must be positive")
+	}
+
+	if c.Trash.RetentionDays <= 0 {
+		errs.Add("trash.retention_days", "must be positive")
+	}
+
+	return errs
+}
+
+func isDriver(name string) bool {
+	for _, driver := range sql.Drivers() {
+		if driver == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, dir string, content string) string {
+	path := filepath.Join(dir, APP_NAME, CONFIG_FILE)
+	os.MkdirAll(filepath.Dir(path), 0o755)
+
+	err := os.WriteFile(path, []byte(content), 0o644)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	return path
+}
+
+func TestLoadDefault(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", "/data")
+	t.Setenv(ENV_CONFIG, "")
+
+	config, err := Load("")
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if config.Database.Path != "/data/todolist/tasks.db" {
+		t.Error("Database path should be", "/data/todolist/tasks.db", "but got", config.Database.Path)
+	}
+	if config.Quota.MaxTasks != 10 || config.Server.Addr != ":8080" || config.Output != "text" {
+		t.Error("Config should have the defaults but got", config)
+	}
+	if errs := config.Validate(); len(errs) > 0 {
+		t.Error("Default config should be valid but got", errs)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv(ENV_CONFIG, "")
+	writeConfig(t, dir, `{"database": {"path": "/file.db"}, "server": {"addr": ":9000"}, "quota": {"max_tasks": 20}}`)
+	t.Setenv("TODOLIST_ADDR", ":9001")
+	t.Setenv("TODOLIST_QUOTA_WARNING_TASKS", "15")
+	t.Setenv("TODOLIST_TRASH_RETENTION_DAYS", "7")
+
+	config, err := Load("")
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if config.Database.Path != "/file.db" {
+		t.Error("Database path should be", "/file.db", "but got", config.Database.Path)
+	}
+	if config.Server.Addr != ":9001" {
+		t.Error("Addr should be", ":9001", "but got", config.Server.Addr)
+	}
+	if config.Quota.MaxTasks != 20 || config.Quota.WarningTasks != 15 {
+		t.Error("Quota should be", Quota{20, 15}, "but got", config.Quota)
+	}
+	if config.SMTP.Port != 587 {
+		t.Error("SMTP port should keep its default", 587, "but got", config.SMTP.Port)
+	}
+	if config.TrashRetention() != 7*24*time.Hour {
+		t.Error("Trash retention should be", 7*24*time.Hour, "but got", config.TrashRetention())
+	}
+}
+
+func TestLoadPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path := writeConfig(t, dir, `{"output": "json"}`)
+	t.Setenv(ENV_CONFIG, path)
+
+	config, err := Load("")
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if config.Output != "json" {
+		t.Error("Output should be", "json", "but got", config.Output)
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Should return an error for a missing file")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(ENV_CONFIG, "")
+
+	for _, content := range []string{`{"output": `, `{"unknown": 1}`, `{"quota": {"max_tasks": "ten"}}`} {
+		if _, err := Load(writeConfig(t, dir, content)); err == nil {
+			t.Error("Should return an error for", content)
+		}
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("TODOLIST_SMTP_PORT", "smtp")
+
+	if _, err := Load(""); err == nil {
+		t.Error("Should return an error for an invalid number")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(config *Config)
+		fields []string
+	}{
+		{"valid", func(config *Config) {}, nil},
+		{"unlimited quota", func(config *Config) { config.Quota = Quota{0, 100} }, nil},
+		{"driver", func(config *Config) { config.Database.Driver = "oracle" }, []string{"database.driver"}},
+		{"path", func(config *Config) { config.Database.Path = "" }, []string{"database.path"}},
+		{"port", func(config *Config) { config.SMTP.Port = 70000 }, []string{"smtp.port"}},
+		{"from", func(config *Config) { config.SMTP.Host = "smtp.example.com" }, []string{"smtp.from"}},
+		{"quota", func(config *Config) { config.Quota = Quota{-1, 0} }, []string{"quota.max_tasks"}},
+		{"warning", func(config *Config) { config.Quota = Quota{5, 6} }, []string{"quota.warning_tasks"}},
+		{"addr", func(config *Config) { config.Server.Addr = "" }, []string{"server.addr"}},
+		{"attachments", func(config *Config) { config.Attachments.MaxSize = 0 }, []string{"attachments.max_size"}},
+		{"trash", func(config *Config) { config.Trash.RetentionDays = 0 }, []string{"trash.retention_days"}},
+	}
+
+	for _, c := range cases {
+		config := Default()
+		c.modify(&config)
+		fields := config.Validate().Fields()
+
+		if len(fields) != len(c.fields) {
+			t.Error(c.name, "should fail on", c.fields, "but got", fields)
+		}
+
+		for _, field := range c.fields {
+			if _, ok := fields[field]; !ok {
+				t.Error(c.name, "should fail on", field, "but got", fields)
+			}
+		}
+	}
+}
+
+func TestAttachmentsPath(t *testing.T) {
+	config := Default()
+	config.Database.Path = "/data/tasks.db"
+
+	if path := config.AttachmentsPath(); path != "/data/attachments" {
+		t.Error("Attachments should be next to the database but are in", path)
+	}
+
+	config.Attachments.Path = "/blobs"
+
+	if path := config.AttachmentsPath(); path != "/blobs" {
+		t.Error("Attachments should be in", "/blobs", "but are in", path)
+	}
+}
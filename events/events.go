@@ -0,0 +1,193 @@
+package events
+
+import (
+	"errors"
+	"log"
+	"sync"
+)
+
+const (
+	TASK_CREATED    = "task.created"
+	TASK_COMPLETED  = "task.completed"
+	TASK_DELETED    = "task.deleted"
+	TASK_PURGED     = "task.purged"
+	USER_REGISTERED = "user.registered"
+	QUOTA_WARNING   = "user.quota_warning"
+	LIST_INVITATION = "list.invitation"
+	TASK_ASSIGNED   = "task.assigned"
+	COMMENT_MENTION = "comment.mention"
+)
+
+type Event interface {
+	Type() string
+}
+
+type TaskCreated struct {
+	TaskId int64  `json:"task_id"`
+	UserId int64  `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+type TaskCompleted struct {
+	TaskId int64 `json:"task_id"`
+	UserId int64 `json:"user_id"`
+}
+
+type TaskDeleted struct {
+	TaskId int64 `json:"task_id"`
+	UserId int64 `json:"user_id"`
+}
+
+type TaskPurged struct {
+	TaskId int64 `json:"task_id"`
+	UserId int64 `json:"user_id"`
+}
+
+type TaskAssigned struct {
+	TaskId     int64  `json:"task_id"`
+	Name       string `json:"name"`
+	AssigneeId int64  `json:"assignee_id"`
+	AssignerId int64  `json:"assigner_id"`
+}
+
+type CommentMention struct {
+	CommentId int64  `json:"comment_id"`
+	TaskId    int64  `json:"task_id"`
+	TaskName  string `json:"task_name"`
+	AuthorId  int64  `json:"author_id"`
+	UserId    int64  `json:"user_id"`
+	Email     string `json:"email"`
+	Body      string `json:"body"`
+}
+
+type UserRegistered struct {
+	UserId int64  `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+type QuotaWarning struct {
+	UserId    int64  `json:"user_id"`
+	Email     string `json:"email"`
+	TasksLeft int    `json:"tasks_left"`
+}
+
+type ListInvitation struct {
+	InvitationId int64  `json:"invitation_id"`
+	ListId       int64  `json:"list_id"`
+	ListName     string `json:"list_name"`
+	InviterId    int64  `json:"inviter_id"`
+	Email        string `json:"email"`
+	Role         string `json:"role"`
+	Token        string `json:"token"`
+}
+
+func (e TaskCreated) Type() string    { return TASK_CREATED }
+func (e TaskCompleted) Type() string  { return TASK_COMPLETED }
+func (e TaskDeleted) Type() string    { return TASK_DELETED }
+func (e TaskPurged) Type() string     { return TASK_PURGED }
+func (e TaskAssigned) Type() string   { return TASK_ASSIGNED }
+func (e UserRegistered) Type() string { return USER_REGISTERED }
+func (e QuotaWarning) Type() string   { return QUOTA_WARNING }
+func (e ListInvitation) Type() string { return LIST_INVITATION }
+func (e CommentMention) Type() string { return COMMENT_MENTION }
+
+type Handler func(event Event) error
+
+type Bus struct {
+	mu           sync.RWMutex
+	wg           sync.WaitGroup
+	sync         map[string][]Handler
+	async        map[string][]Handler
+	OnAsyncError func(event Event, err error)
+}
+
+type BusInterface interface {
+	Subscribe(name string, handler Handler)
+	SubscribeAsync(name string, handler Handler)
+	Publish(event Event) error
+	Wait()
+}
+
+var BusInstance = NewBus()
+
+func NewBus() *Bus {
+	return &Bus{
+		sync:  map[string][]Handler{},
+		async: map[string][]Handler{},
+		OnAsyncError: func(event Event, err error) {
+			log.Printf("events: async handler for %s failed: %v", event.Type(), err)
+		},
+	}
+}
+
+// Subscribe registers a handler run inline by Publish, its error is
+// returned to the publisher.
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sync[name] = append(b.sync[name], handler)
+}
+
+// SubscribeAsync registers a handler run in its own goroutine, its error is
+// reported through OnAsyncError.
+func (b *Bus) SubscribeAsync(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.async[name] = append(b.async[name], handler)
+}
+
+func (b *Bus) Publish(event Event) error {
+	b.mu.RLock()
+	syncHandlers := append([]Handler{}, b.sync[event.Type()]...)
+	asyncHandlers := append([]Handler{}, b.async[event.Type()]...)
+	b.mu.RUnlock()
+
+	for _, handler := range asyncHandlers {
+		b.wg.Add(1)
+
+		go func(handler Handler) {
+			defer b.wg.Done()
+
+			if err := handler(event); err != nil && b.OnAsyncError != nil {
+				b.OnAsyncError(event, err)
+			}
+		}(handler)
+	}
+
+	var errs []error
+
+	for _, handler := range syncHandlers {
+		if err := handler(event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Wait blocks until every async handler started so far has returned.
+func (b *Bus) Wait() {
+	b.wg.Wait()
+}
+
+func (b *Bus) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sync = map[string][]Handler{}
+	b.async = map[string][]Handler{}
+}
+
+func Subscribe(name string, handler Handler) {
+	BusInstance.Subscribe(name, handler)
+}
+
+func SubscribeAsync(name string, handler Handler) {
+	BusInstance.SubscribeAsync(name, handler)
+}
+
+func Publish(event Event) error {
+	return BusInstance.Publish(event)
+}
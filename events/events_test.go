@@ -0,0 +1,112 @@
+package events
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPublishSync(t *testing.T) {
+	bus := NewBus()
+	var received []Event
+
+	bus.Subscribe(TASK_CREATED, func(event Event) error {
+		received = append(received, event)
+		return nil
+	})
+
+	err := bus.Publish(TaskCreated{TaskId: 1, UserId: 2, Name: "task"})
+
+	if err != nil {
+		t.Error("Error should be nil but got", err)
+	}
+	if len(received) != 1 {
+		t.Fatal("Handler should be called once but was called", len(received), "times")
+	}
+
+	created, ok := received[0].(TaskCreated)
+
+	if !ok {
+		t.Fatal("Event should be a TaskCreated but is", received[0])
+	}
+	if created.TaskId != 1 || created.UserId != 2 || created.Name != "task" {
+		t.Error("Event payload is wrong:", created)
+	}
+
+	bus.Publish(TaskDeleted{TaskId: 1})
+
+	if len(received) != 1 {
+		t.Error("Handler should not be called for other events")
+	}
+}
+
+func TestPublishError(t *testing.T) {
+	bus := NewBus()
+	called := 0
+
+	bus.Subscribe(QUOTA_WARNING, func(event Event) error {
+		called++
+		return fmt.Errorf("first")
+	})
+	bus.Subscribe(QUOTA_WARNING, func(event Event) error {
+		called++
+		return nil
+	})
+
+	err := bus.Publish(QuotaWarning{UserId: 1, TasksLeft: 2})
+
+	if err == nil {
+		t.Error("Error should not be nil")
+	}
+	if called != 2 {
+		t.Error("Every handler should be called but only", called, "were")
+	}
+}
+
+func TestPublishAsync(t *testing.T) {
+	bus := NewBus()
+	var called int32
+	var failed int32
+
+	bus.OnAsyncError = func(event Event, err error) {
+		atomic.AddInt32(&failed, 1)
+	}
+
+	bus.SubscribeAsync(USER_REGISTERED, func(event Event) error {
+		atomic.AddInt32(&called, 1)
+		return nil
+	})
+	bus.SubscribeAsync(USER_REGISTERED, func(event Event) error {
+		atomic.AddInt32(&called, 1)
+		return fmt.Errorf("async")
+	})
+
+	err := bus.Publish(UserRegistered{UserId: 1, Email: "a@b.c"})
+	bus.Wait()
+
+	if err != nil {
+		t.Error("Async errors should not be returned but got", err)
+	}
+	if called != 2 {
+		t.Error("Async handlers should be called twice but were called", called, "times")
+	}
+	if failed != 1 {
+		t.Error("OnAsyncError should be called once but was called", failed, "times")
+	}
+}
+
+func TestReset(t *testing.T) {
+	bus := NewBus()
+	called := false
+
+	bus.Subscribe(TASK_COMPLETED, func(event Event) error {
+		called = true
+		return nil
+	})
+	bus.Reset()
+	bus.Publish(TaskCompleted{TaskId: 1})
+
+	if called {
+		t.Error("Handler should not be called after Reset")
+	}
+}
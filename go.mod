@@ -5,6 +5,7 @@ go 1.20
 require (
 	github.com/glebarez/go-sqlite v1.21.1
 	github.com/jaswdr/faker v1.17.0
+	golang.org/x/sys v0.4.0
 )
 
 require (
@@ -12,7 +13,6 @@ require (
 	github.com/google/uuid v1.3.0 // indirect
 	github.com/mattn/go-isatty v0.0.17 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
-	golang.org/x/sys v0.4.0 // indirect
 	modernc.org/libc v1.22.3 // indirect
 	modernc.org/mathutil v1.5.0 // indirect
 	modernc.org/memory v1.5.0 // indirect
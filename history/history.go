@@ -0,0 +1,241 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+	"todolist/utils"
+)
+
+const (
+	TASK_ENTITY = "task"
+	USER_ENTITY = "user"
+
+	CREATE_ACTION = "create"
+	UPDATE_ACTION = "update"
+	DELETE_ACTION = "delete"
+	PURGE_ACTION  = "purge"
+)
+
+type Change struct {
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+type Entry struct {
+	Id        int64     `json:"id"`
+	Entity    string    `json:"entity"`
+	EntityId  int64     `json:"entity_id"`
+	Action    string    `json:"action"`
+	ActorId   int64     `json:"actor_id"`
+	Changes   []Change  `json:"changes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type EntryInterface interface {
+	Print()
+}
+
+// Diff returns the fields whose value differs between two snapshots, sorted
+// by field name.
+func Diff(old map[string]string, new map[string]string) []Change {
+	var changes []Change
+
+	for field, value := range new {
+		if old[field] != value {
+			changes = append(changes, Change{Field: field, OldValue: old[field], NewValue: value})
+		}
+	}
+
+	for field, value := range old {
+		if _, ok := new[field]; !ok && value != "" {
+			changes = append(changes, Change{Field: field, OldValue: value})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+
+	return changes
+}
+
+func Record(entity string, entityId int64, actorId int64, action string, changes []Change) (int64, error) {
+	return RecordWith(context.Background(), utils.SqliteInstance.DB, entity, entityId, actorId, action, changes)
+}
+
+func RecordWith(ctx context.Context, exec utils.Executor, entity string, entityId int64, actorId int64, action string, changes []Change) (int64, error) {
+	stmt, err := exec.PrepareContext(ctx, "INSERT INTO history (entity, entity_id, action, actor_id, created_at) VALUES (?, ?, ?, ?, ?)")
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, entity, entityId, action, actorId, time.Now())
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, change := range changes {
+		_, err := exec.ExecContext(
+			ctx,
+			"INSERT INTO history_changes (history_id, field, old_value, new_value) VALUES (?, ?, ?, ?)",
+			id,
+			change.Field,
+			change.OldValue,
+			change.NewValue,
+		)
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return id, nil
+}
+
+func GetHistory(entity string, entityId int64) ([]Entry, error) {
+	return GetHistoryContext(context.Background(), entity, entityId)
+}
+
+func GetHistoryContext(ctx context.Context, entity string, entityId int64) ([]Entry, error) {
+	var entries []Entry
+
+	rows, err := utils.SqliteInstance.DB.QueryContext(ctx, "SELECT id, entity, entity_id, action, actor_id, created_at FROM history WHERE entity = ? AND entity_id = ? ORDER BY id", entity, entityId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var entry Entry
+
+		err := rows.Scan(
+			&entry.Id,
+			&entry.Entity,
+			&entry.EntityId,
+			&entry.Action,
+			&entry.ActorId,
+			&entry.CreatedAt,
+		)
+
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	rows.Close()
+
+	for i := range entries {
+		entries[i].Changes, err = getChanges(ctx, utils.SqliteInstance.DB, entries[i].Id)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+func getChanges(ctx context.Context, exec utils.Executor, historyId int64) ([]Change, error) {
+	var changes []Change
+
+	rows, err := exec.QueryContext(ctx, "SELECT field, old_value, new_value FROM history_changes WHERE history_id = ? ORDER BY id", historyId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var change Change
+
+		err := rows.Scan(&change.Field, &change.OldValue, &change.NewValue)
+
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+func (e *Entry) Print() {
+	fmt.Println("Id:          ", e.Id)
+	fmt.Println("Action:      ", e.Action)
+	fmt.Println("Actor:       ", e.ActorId)
+	fmt.Println("Date:        ", e.CreatedAt.Format(time.RFC3339))
+
+	for _, change := range e.Changes {
+		fmt.Printf("  %-12s %q -> %q\n", change.Field+":", change.OldValue, change.NewValue)
+	}
+}
+
+func PrintHistory(entries []Entry) {
+	for i, entry := range entries {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		entry.Print()
+	}
+}
+
+func LastId() (int64, error) {
+	return LastIdWith(context.Background(), utils.SqliteInstance.DB)
+}
+
+// LastIdWith returns the id of the last entry recorded through exec, 0 when
+// there is none.
+func LastIdWith(ctx context.Context, exec utils.Executor) (int64, error) {
+	var id int64
+
+	row := exec.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM history")
+	err := row.Scan(&id)
+
+	return id, err
+}
+
+func GetEntry(id int64) (Entry, error) {
+	return GetEntryWith(context.Background(), utils.SqliteInstance.DB, id)
+}
+
+func GetEntryWith(ctx context.Context, exec utils.Executor, id int64) (Entry, error) {
+	var entry Entry
+
+	row := exec.QueryRowContext(ctx, "SELECT id, entity, entity_id, action, actor_id, created_at FROM history WHERE id = ?", id)
+
+	err := row.Scan(
+		&entry.Id,
+		&entry.Entity,
+		&entry.EntityId,
+		&entry.Action,
+		&entry.ActorId,
+		&entry.CreatedAt,
+	)
+
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry.Changes, err = getChanges(ctx, exec, entry.Id)
+
+	return entry, err
+}
@@ -0,0 +1,69 @@
+package history
+
+import (
+	"testing"
+	"todolist/utils"
+)
+
+func TestDiff(t *testing.T) {
+	old := map[string]string{"name": "a", "label": "work", "location": "home"}
+	new := map[string]string{"name": "b", "label": "work", "priority": "2"}
+
+	changes := Diff(old, new)
+	expected := []Change{
+		{Field: "location", OldValue: "home", NewValue: ""},
+		{Field: "name", OldValue: "a", NewValue: "b"},
+		{Field: "priority", OldValue: "", NewValue: "2"},
+	}
+
+	if len(changes) != len(expected) {
+		t.Fatal("Changes should be", expected, "but got", changes)
+	}
+
+	for i, change := range changes {
+		if change != expected[i] {
+			t.Error("Change should be", expected[i], "but got", change)
+		}
+	}
+
+	if len(Diff(old, old)) != 0 {
+		t.Error("Diff of identical snapshots should be empty")
+	}
+}
+
+func TestRecord(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	changes := []Change{{Field: "name", OldValue: "", NewValue: "task"}}
+
+	id, err := Record(TASK_ENTITY, 1, 2, CREATE_ACTION, changes)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if id != 1 {
+		t.Error("History id should be 1 but is", id)
+	}
+
+	Record(TASK_ENTITY, 1, 3, UPDATE_ACTION, []Change{{Field: "name", OldValue: "task", NewValue: "renamed"}})
+	Record(USER_ENTITY, 1, 1, CREATE_ACTION, nil)
+
+	entries, err := GetHistory(TASK_ENTITY, 1)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if len(entries) != 2 {
+		t.Fatal("History should have 2 entries but has", len(entries))
+	}
+	if entries[0].Action != CREATE_ACTION || entries[0].ActorId != 2 {
+		t.Error("First entry should be a create by 2 but is", entries[0].Action, "by", entries[0].ActorId)
+	}
+	if entries[1].Action != UPDATE_ACTION || entries[1].ActorId != 3 {
+		t.Error("Second entry should be an update by 3 but is", entries[1].Action, "by", entries[1].ActorId)
+	}
+	if len(entries[1].Changes) != 1 || entries[1].Changes[0].NewValue != "renamed" {
+		t.Error("Second entry changes are wrong:", entries[1].Changes)
+	}
+}
@@ -0,0 +1,225 @@
+package list
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"todolist/events"
+	"todolist/permission"
+	userLib "todolist/user"
+	"todolist/utils"
+)
+
+type Invitation struct {
+	Id         int64      `json:"id"`
+	ListId     int64      `json:"list_id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	Token      string     `json:"-"`
+	InviterId  int64      `json:"inviter_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+}
+
+const (
+	INVITATION_COLUMNS = "id, list_id, email, role, token, inviter_id, created_at, accepted_at"
+)
+
+var (
+	ErrInvitationNotFound = errors.New("Invitation not found")
+)
+
+func (l *List) Invite(actorId int64, email string, role string) (Invitation, error) {
+	return l.InviteContext(context.Background(), actorId, email, role)
+}
+
+// InviteContext records an invitation to join the list with role and
+// publishes a ListInvitation event, the email carries the token to accept
+// it.
+func (l *List) InviteContext(ctx context.Context, actorId int64, email string, role string) (Invitation, error) {
+	var errs utils.ValidationErrors
+
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		errs.Add("email", "is not a valid email address")
+	}
+
+	if !permission.IsRole(role) {
+		errs.Add("role", "must be one of "+strings.Join(permission.ROLES, ", "))
+	}
+
+	if len(errs) > 0 {
+		return Invitation{}, errs
+	}
+
+	err := l.authorize(ctx, utils.SqliteInstance.DB, actorId, permission.SHARE_ACTION)
+
+	if err != nil {
+		return Invitation{}, err
+	}
+
+	token, err := newToken()
+
+	if err != nil {
+		return Invitation{}, err
+	}
+
+	invitation := Invitation{
+		ListId:    l.Id,
+		Email:     email,
+		Role:      role,
+		Token:     token,
+		InviterId: actorId,
+		CreatedAt: time.Now(),
+	}
+
+	res, err := utils.SqliteInstance.DB.ExecContext(ctx, "INSERT INTO invitations (list_id, email, role, token, inviter_id, created_at) VALUES (?, ?, ?, ?, ?, ?)", invitation.ListId, invitation.Email, invitation.Role, invitation.Token, invitation.InviterId, invitation.CreatedAt)
+
+	if err != nil {
+		return Invitation{}, err
+	}
+
+	invitation.Id, err = res.LastInsertId()
+
+	if err != nil {
+		return Invitation{}, err
+	}
+
+	err = events.Publish(events.ListInvitation{
+		InvitationId: invitation.Id,
+		ListId:       l.Id,
+		ListName:     l.Name,
+		InviterId:    actorId,
+		Email:        email,
+		Role:         role,
+		Token:        token,
+	})
+
+	return invitation, err
+}
+
+func newToken() (string, error) {
+	bytes := make([]byte, 16)
+
+	_, err := rand.Read(bytes)
+
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bytes), nil
+}
+
+func GetInvitation(token string) (Invitation, error) {
+	return GetInvitationContext(context.Background(), token)
+}
+
+func GetInvitationContext(ctx context.Context, token string) (Invitation, error) {
+	var invitation Invitation
+	var acceptedAt sql.NullTime
+
+	row := utils.SqliteInstance.DB.QueryRowContext(ctx, "SELECT "+INVITATION_COLUMNS+" FROM invitations WHERE token = ?", token)
+	err := row.Scan(&invitation.Id, &invitation.ListId, &invitation.Email, &invitation.Role, &invitation.Token, &invitation.InviterId, &invitation.CreatedAt, &acceptedAt)
+
+	if err == sql.ErrNoRows {
+		return Invitation{}, ErrInvitationNotFound
+	}
+
+	if err != nil {
+		return Invitation{}, fmt.Errorf("Scanning invitation: %w", err)
+	}
+
+	if acceptedAt.Valid {
+		invitation.AcceptedAt = &acceptedAt.Time
+	}
+
+	return invitation, nil
+}
+
+func AcceptInvitation(token string, userId int64) (List, error) {
+	return AcceptInvitationContext(context.Background(), token, userId)
+}
+
+// AcceptInvitationContext adds the user to the list of the invitation, the
+// email of the user must be the invited one and an invitation can only be
+// used once. An owner keeps the owner role.
+func AcceptInvitationContext(ctx context.Context, token string, userId int64) (list List, err error) {
+	invitation, err := GetInvitationContext(ctx, token)
+
+	if err != nil {
+		return List{}, err
+	}
+
+	if invitation.AcceptedAt != nil {
+		return List{}, fmt.Errorf("%w: already accepted", ErrInvitationNotFound)
+	}
+
+	user, err := userLib.GetUserContext(ctx, userId)
+
+	if err != nil {
+		return List{}, err
+	}
+
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		return List{}, fmt.Errorf("%w: the invitation was sent to another email", permission.ErrForbidden)
+	}
+
+	list, err = GetListContext(ctx, invitation.ListId)
+
+	if err != nil {
+		return List{}, err
+	}
+
+	tx, err := utils.SqliteInstance.BeginTx(ctx)
+
+	if err != nil {
+		return List{}, err
+	}
+
+	defer func() {
+		if err != nil && !tx.Committed {
+			tx.Rollback()
+		}
+	}()
+
+	// the invitation is claimed first so that of concurrent accepts only
+	// one changes the role
+	res, err := tx.ExecContext(ctx, "UPDATE invitations SET accepted_at = ? WHERE id = ? AND accepted_at IS NULL", time.Now(), invitation.Id)
+
+	if err != nil {
+		return List{}, err
+	}
+
+	count, err := res.RowsAffected()
+
+	if err != nil {
+		return List{}, err
+	}
+
+	if count != 1 {
+		err = fmt.Errorf("%w: already accepted", ErrInvitationNotFound)
+		return List{}, err
+	}
+
+	current, err := memberRole(ctx, tx, list.Id, userId)
+
+	if err != nil {
+		return List{}, err
+	}
+
+	// an invitation never lowers the role the user already has
+	if role := permission.Strongest(current, invitation.Role); role != current {
+		err = setRole(ctx, tx, list.Id, userId, role)
+
+		if err != nil {
+			return List{}, err
+		}
+	}
+
+	return list, tx.Commit()
+}
@@ -0,0 +1,465 @@
+package list
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"todolist/permission"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+	"todolist/utils"
+)
+
+type List struct {
+	Id        int64     `json:"id"`
+	Name      string    `json:"name"`
+	OwnerId   int64     `json:"owner_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	WorkspaceId int64 `json:"workspace_id"`
+}
+
+type Member struct {
+	ListId    int64     `json:"list_id"`
+	UserId    int64     `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ListInterface interface {
+	Validate() utils.ValidationErrors
+	Save() error
+	SaveAs(actorId int64) error
+	SaveContext(ctx context.Context, actorId int64) error
+	Delete() error
+	DeleteAs(actorId int64) error
+	DeleteContext(ctx context.Context, actorId int64) error
+	Role(userId int64) (string, error)
+	RoleContext(ctx context.Context, userId int64) (string, error)
+	Members() ([]Member, error)
+	MembersContext(ctx context.Context) ([]Member, error)
+	Share(actorId int64, userId int64, role string) error
+	ShareContext(ctx context.Context, actorId int64, userId int64, role string) error
+	Unshare(actorId int64, userId int64) error
+	UnshareContext(ctx context.Context, actorId int64, userId int64) error
+	Invite(actorId int64, email string, role string) (Invitation, error)
+	InviteContext(ctx context.Context, actorId int64, email string, role string) (Invitation, error)
+	Tasks() ([]taskLib.Task, error)
+}
+
+const (
+	LIST_COLUMNS = "id, name, owner_id, created_at, updated_at, workspace_id"
+)
+
+var (
+	ErrListNotFound = errors.New("List not found")
+)
+
+func NewList(name string, ownerId int64) List {
+	return List{
+		Name:      name,
+		OwnerId:   ownerId,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func GetList(id int64) (List, error) {
+	return GetListContext(context.Background(), id)
+}
+
+func GetListContext(ctx context.Context, id int64) (List, error) {
+	var list List
+
+	row := utils.SqliteInstance.DB.QueryRowContext(ctx, "SELECT "+LIST_COLUMNS+" FROM lists WHERE id = ?", id)
+	err := scanList(row, &list)
+
+	if err == sql.ErrNoRows {
+		return List{}, fmt.Errorf("%w: %d", ErrListNotFound, id)
+	}
+
+	if err != nil {
+		return List{}, fmt.Errorf("Scanning list %d: %w", id, err)
+	}
+
+	return list, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanList(row scanner, list *List) error {
+	var workspaceId sql.NullInt64
+
+	err := row.Scan(&list.Id, &list.Name, &list.OwnerId, &list.CreatedAt, &list.UpdatedAt, &workspaceId)
+	list.WorkspaceId = workspaceId.Int64
+
+	return err
+}
+
+// GetListsByUserId returns the lists the user owns or that are shared with
+// the user, along with the lists of the workspaces where the user is more
+// than a guest.
+func GetListsByUserId(userId int64) ([]List, error) {
+	return GetListsByUserIdContext(context.Background(), userId)
+}
+
+func GetListsByUserIdContext(ctx context.Context, userId int64) ([]List, error) {
+	return queryLists(ctx, "SELECT "+LIST_COLUMNS+" FROM lists WHERE id IN (SELECT list_id FROM list_members WHERE user_id = ?) OR id IN ("+taskLib.WORKSPACE_LISTS+") ORDER BY id", userId, userId)
+}
+
+func GetListsByWorkspaceId(workspaceId int64) ([]List, error) {
+	return GetListsByWorkspaceIdContext(context.Background(), workspaceId)
+}
+
+func GetListsByWorkspaceIdContext(ctx context.Context, workspaceId int64) ([]List, error) {
+	return queryLists(ctx, "SELECT "+LIST_COLUMNS+" FROM lists WHERE workspace_id = ? ORDER BY id", workspaceId)
+}
+
+func queryLists(ctx context.Context, query string, args ...any) ([]List, error) {
+	var lists []List
+
+	rows, err := utils.SqliteInstance.DB.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying lists: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var list List
+
+		err := scanList(rows, &list)
+
+		if err != nil {
+			return nil, fmt.Errorf("Scanning list: %w", err)
+		}
+
+		lists = append(lists, list)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying lists: %w", err)
+	}
+
+	return lists, nil
+}
+
+func (l *List) Validate() utils.ValidationErrors {
+	var errs utils.ValidationErrors
+
+	if strings.TrimSpace(l.Name) == "" {
+		errs.Add("name", "is required")
+	}
+
+	if l.OwnerId == 0 {
+		errs.Add("owner_id", "is required")
+	}
+
+	return errs
+}
+
+func (l *List) Save() error {
+	return l.SaveAs(l.OwnerId)
+}
+
+func (l *List) SaveAs(actorId int64) error {
+	return l.SaveContext(context.Background(), actorId)
+}
+
+// SaveContext creates the list with its owner as first member, or renames
+// it when actorId may share it. A list is created in a workspace by its
+// members.
+func (l *List) SaveContext(ctx context.Context, actorId int64) (err error) {
+	if errs := l.Validate(); len(errs) > 0 {
+		return errs
+	}
+
+	if l.Id != 0 {
+		err = l.authorize(ctx, utils.SqliteInstance.DB, actorId, permission.SHARE_ACTION)
+
+		if err != nil {
+			return err
+		}
+
+		l.UpdatedAt = time.Now()
+		_, err = utils.SqliteInstance.DB.ExecContext(ctx, "UPDATE lists SET name = ?, updated_at = ? WHERE id = ?", l.Name, l.UpdatedAt, l.Id)
+
+		return err
+	}
+
+	if l.WorkspaceId != 0 {
+		err = authorizeWorkspace(ctx, utils.SqliteInstance.DB, actorId, l.WorkspaceId, permission.CREATE_ACTION)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := utils.SqliteInstance.BeginTx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil && !tx.Committed {
+			tx.Rollback()
+			l.Id = 0
+		}
+	}()
+
+	var workspaceId any
+
+	if l.WorkspaceId != 0 {
+		workspaceId = l.WorkspaceId
+	}
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO lists (name, owner_id, created_at, updated_at, workspace_id) VALUES (?, ?, ?, ?, ?)", l.Name, l.OwnerId, l.CreatedAt, l.UpdatedAt, workspaceId)
+
+	if err != nil {
+		return err
+	}
+
+	l.Id, err = res.LastInsertId()
+
+	if err != nil {
+		return err
+	}
+
+	err = setRole(ctx, tx, l.Id, l.OwnerId, permission.OWNER_ROLE)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (l *List) Delete() error {
+	return l.DeleteAs(l.OwnerId)
+}
+
+func (l *List) DeleteAs(actorId int64) error {
+	return l.DeleteContext(context.Background(), actorId)
+}
+
+// DeleteContext removes the list, its members, invitations and workflow. Its
+// tasks are kept and go back to their own users.
+func (l *List) DeleteContext(ctx context.Context, actorId int64) (err error) {
+	err = l.authorize(ctx, utils.SqliteInstance.DB, actorId, permission.SHARE_ACTION)
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := utils.SqliteInstance.BeginTx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil && !tx.Committed {
+			tx.Rollback()
+		}
+	}()
+
+	for _, query := range []string{
+		"UPDATE tasks SET list_id = NULL WHERE list_id = ?",
+		"DELETE FROM invitations WHERE list_id = ?",
+		"DELETE FROM list_members WHERE list_id = ?",
+		"DELETE FROM list_statuses WHERE list_id = ?",
+		"DELETE FROM status_transitions WHERE list_id = ?",
+		"DELETE FROM lists WHERE id = ?",
+	} {
+		_, err = tx.ExecContext(ctx, query, l.Id)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (l *List) Role(userId int64) (string, error) {
+	return l.RoleContext(context.Background(), userId)
+}
+
+// RoleContext returns the role of the user in the list, "" when the list is
+// not shared with the user. Admins of the workspace of the list own it and
+// its members edit it.
+func (l *List) RoleContext(ctx context.Context, userId int64) (string, error) {
+	return taskLib.ListRole(ctx, utils.SqliteInstance.DB, l.Id, userId)
+}
+
+// memberRole returns the role the list is shared with the user, without the
+// role inherited from the workspace.
+func memberRole(ctx context.Context, exec utils.Executor, listId int64, userId int64) (string, error) {
+	var role string
+
+	row := exec.QueryRowContext(ctx, "SELECT role FROM list_members WHERE list_id = ? AND user_id = ?", listId, userId)
+	err := row.Scan(&role)
+
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("Reading role on list %d: %w", listId, err)
+	}
+
+	return role, nil
+}
+
+// authorizeWorkspace checks the role of actorId in the workspace, the
+// workspace package can not be imported as it lists its lists.
+func authorizeWorkspace(ctx context.Context, exec utils.Executor, actorId int64, workspaceId int64, action string) error {
+	var role string
+
+	if actorId == 0 {
+		return nil
+	}
+
+	row := exec.QueryRowContext(ctx, "SELECT role FROM workspace_members WHERE workspace_id = ? AND user_id = ? AND accepted_at IS NOT NULL", workspaceId, actorId)
+	err := row.Scan(&role)
+
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("Reading role on workspace %d: %w", workspaceId, err)
+	}
+
+	if !permission.Authorize(permission.WORKSPACE_RESOURCE, role, action) {
+		return fmt.Errorf("%w: user %d can not %s in workspace %d", permission.ErrForbidden, actorId, action, workspaceId)
+	}
+
+	return nil
+}
+
+func setRole(ctx context.Context, exec utils.Executor, listId int64, userId int64, role string) error {
+	_, err := exec.ExecContext(ctx, "INSERT INTO list_members (list_id, user_id, role, created_at) VALUES (?, ?, ?, ?) ON CONFLICT (list_id, user_id) DO UPDATE SET role = excluded.role", listId, userId, role, time.Now())
+
+	return err
+}
+
+func (l *List) authorize(ctx context.Context, exec utils.Executor, actorId int64, action string) error {
+	if actorId == 0 {
+		return nil
+	}
+
+	role, err := taskLib.ListRole(ctx, exec, l.Id, actorId)
+
+	if err != nil {
+		return err
+	}
+
+	if !permission.Authorize(permission.LIST_RESOURCE, role, action) {
+		return fmt.Errorf("%w: user %d can not %s list %d", permission.ErrForbidden, actorId, action, l.Id)
+	}
+
+	return nil
+}
+
+func (l *List) Members() ([]Member, error) {
+	return l.MembersContext(context.Background())
+}
+
+func (l *List) MembersContext(ctx context.Context) ([]Member, error) {
+	var members []Member
+
+	rows, err := utils.SqliteInstance.DB.QueryContext(ctx, "SELECT list_id, user_id, role, created_at FROM list_members WHERE list_id = ? ORDER BY user_id", l.Id)
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying members: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var member Member
+		var createdAt sql.NullTime
+
+		err := rows.Scan(&member.ListId, &member.UserId, &member.Role, &createdAt)
+
+		if err != nil {
+			return nil, fmt.Errorf("Scanning member: %w", err)
+		}
+
+		member.CreatedAt = createdAt.Time
+		members = append(members, member)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying members: %w", err)
+	}
+
+	return members, nil
+}
+
+func (l *List) Share(actorId int64, userId int64, role string) error {
+	return l.ShareContext(context.Background(), actorId, userId, role)
+}
+
+// ShareContext gives role on the list to the user, replacing the previous
+// one. The owner of the list always stays owner.
+func (l *List) ShareContext(ctx context.Context, actorId int64, userId int64, role string) error {
+	if !permission.IsRole(role) {
+		return utils.ValidationErrors{{Field: "role", Message: "must be one of " + strings.Join(permission.ROLES, ", ")}}
+	}
+
+	err := l.authorize(ctx, utils.SqliteInstance.DB, actorId, permission.SHARE_ACTION)
+
+	if err != nil {
+		return err
+	}
+
+	if userId == l.OwnerId {
+		return utils.ValidationErrors{{Field: "user", Message: "owns the list"}}
+	}
+
+	_, err = userLib.GetUserContext(ctx, userId)
+
+	if err != nil {
+		return err
+	}
+
+	return setRole(ctx, utils.SqliteInstance.DB, l.Id, userId, role)
+}
+
+func (l *List) Unshare(actorId int64, userId int64) error {
+	return l.UnshareContext(context.Background(), actorId, userId)
+}
+
+// UnshareContext removes the user from the list, users may always leave a
+// list they do not own.
+func (l *List) UnshareContext(ctx context.Context, actorId int64, userId int64) error {
+	if actorId != userId {
+		err := l.authorize(ctx, utils.SqliteInstance.DB, actorId, permission.SHARE_ACTION)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if userId == l.OwnerId {
+		return utils.ValidationErrors{{Field: "user", Message: "owns the list"}}
+	}
+
+	_, err := utils.SqliteInstance.DB.ExecContext(ctx, "DELETE FROM list_members WHERE list_id = ? AND user_id = ?", l.Id, userId)
+
+	return err
+}
+
+func (l *List) Tasks() ([]taskLib.Task, error) {
+	return taskLib.GetTasksByListId(l.Id)
+}
@@ -0,0 +1,222 @@
+package list
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"todolist/events"
+	"todolist/permission"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+	"todolist/utils"
+
+	fakerLib "github.com/jaswdr/faker"
+)
+
+var faker = fakerLib.New()
+
+func newUser(t *testing.T, email string) userLib.User {
+	user := userLib.NewUser(faker.Person().FirstName(), faker.Person().LastName(), email, nil)
+	user.Birthdate = time.Now().AddDate(-30, 0, 0)
+
+	if err := user.Save(); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	return user
+}
+
+func TestSaveList(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	owner := newUser(t, "owner@example.com")
+	list := NewList("Groceries", owner.Id)
+
+	if err := list.Save(); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	saved, err := GetList(list.Id)
+
+	if err != nil || saved.Name != "Groceries" || saved.OwnerId != owner.Id {
+		t.Error("List should be saved but got", saved, err)
+	}
+
+	if role, _ := list.Role(owner.Id); role != permission.OWNER_ROLE {
+		t.Error("Role should be", permission.OWNER_ROLE, "but got", role)
+	}
+
+	invalid := NewList(" ", owner.Id)
+
+	if err := invalid.Save(); err == nil {
+		t.Error("Should return an error without a name")
+	}
+
+	if _, err := GetList(42); !errors.Is(err, ErrListNotFound) {
+		t.Error("Error should be", ErrListNotFound, "but got", err)
+	}
+}
+
+func TestShare(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	owner := newUser(t, "owner@example.com")
+	editor := newUser(t, "editor@example.com")
+	viewer := newUser(t, "viewer@example.com")
+
+	list := NewList("Groceries", owner.Id)
+	list.Save()
+
+	if err := list.Share(owner.Id, editor.Id, permission.EDITOR_ROLE); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if err := list.Share(editor.Id, viewer.Id, permission.VIEWER_ROLE); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Editor should not share but got", err)
+	}
+	if err := list.Share(owner.Id, viewer.Id, "admin"); err == nil {
+		t.Error("Should return an error for an unknown role")
+	}
+	if err := list.Share(owner.Id, 42, permission.VIEWER_ROLE); !errors.Is(err, userLib.ErrUserNotFound) {
+		t.Error("Error should be", userLib.ErrUserNotFound, "but got", err)
+	}
+	if err := list.Share(owner.Id, owner.Id, permission.VIEWER_ROLE); err == nil {
+		t.Error("Owner should stay owner")
+	}
+
+	list.Share(owner.Id, viewer.Id, permission.VIEWER_ROLE)
+
+	members, _ := list.Members()
+
+	if len(members) != 3 || members[1].UserId != editor.Id || members[1].Role != permission.EDITOR_ROLE {
+		t.Error("List should have 3 members but got", members)
+	}
+
+	lists, _ := GetListsByUserId(viewer.Id)
+
+	if len(lists) != 1 || lists[0].Id != list.Id {
+		t.Error("List should be shared with the viewer but got", lists)
+	}
+
+	task := taskLib.NewTask("Milk")
+	task.UserId = editor.Id
+	task.ListId = list.Id
+
+	if err := task.SaveAs(editor.Id); err != nil {
+		t.Fatal("Editor should add tasks but got", err)
+	}
+
+	tasks, _ := taskLib.GetVisibleTasks(viewer.Id)
+
+	if len(tasks) != 1 || tasks[0].Id != task.Id {
+		t.Error("Viewer should see the task but got", tasks)
+	}
+
+	if err := list.Unshare(editor.Id, viewer.Id); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Editor should not unshare but got", err)
+	}
+	if err := list.Unshare(viewer.Id, viewer.Id); err != nil {
+		t.Error("Viewer should leave but got", err)
+	}
+	if err := list.Unshare(owner.Id, owner.Id); err == nil {
+		t.Error("Owner should not leave")
+	}
+
+	if tasks, _ := taskLib.GetVisibleTasks(viewer.Id); len(tasks) != 0 {
+		t.Error("Viewer should not see the task anymore but got", tasks)
+	}
+
+	workflow := taskLib.Workflow{ListId: list.Id, Statuses: []taskLib.Status{{Name: "backlog"}, {Name: "shipped", Terminal: true}}}
+	workflow.SaveAs(owner.Id)
+
+	if err := list.DeleteAs(editor.Id); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Editor should not delete but got", err)
+	}
+	if err := list.Delete(); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	task = taskLib.GetTask(task.Id)
+
+	if task.ListId != 0 || task.UserId != editor.Id {
+		t.Error("Task should go back to its user but got", task)
+	}
+	if lists, _ := GetListsByUserId(editor.Id); len(lists) != 0 {
+		t.Error("Editor should have no list but got", lists)
+	}
+
+	// the next list reuses the id of the deleted one
+	next := NewList("Groceries", owner.Id)
+	next.Save()
+
+	if stored, _ := taskLib.GetWorkflow(next.Id); next.Id != list.Id || stored.Statuses[0].Name == "backlog" {
+		t.Error("New list should not inherit the deleted workflow but got", stored.Statuses)
+	}
+}
+
+func TestInvitation(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	var sent []events.ListInvitation
+	events.Subscribe(events.LIST_INVITATION, func(event events.Event) error {
+		sent = append(sent, event.(events.ListInvitation))
+		return nil
+	})
+	defer events.BusInstance.Reset()
+
+	owner := newUser(t, "owner@example.com")
+	guest := newUser(t, "guest@example.com")
+	other := newUser(t, "other@example.com")
+
+	list := NewList("Groceries", owner.Id)
+	list.Save()
+
+	if _, err := list.Invite(guest.Id, guest.Email, permission.EDITOR_ROLE); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Guest should not invite but got", err)
+	}
+	if _, err := list.Invite(owner.Id, "not an email", permission.EDITOR_ROLE); err == nil {
+		t.Error("Should return an error for an invalid email")
+	}
+
+	invitation, err := list.Invite(owner.Id, guest.Email, permission.EDITOR_ROLE)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if len(sent) != 1 || sent[0].Email != guest.Email || sent[0].Token != invitation.Token || sent[0].ListName != "Groceries" {
+		t.Fatal("An invitation should be sent but got", sent)
+	}
+
+	if _, err := AcceptInvitation(invitation.Token, other.Id); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Other users should not accept but got", err)
+	}
+	if _, err := AcceptInvitation("unknown", guest.Id); !errors.Is(err, ErrInvitationNotFound) {
+		t.Error("Error should be", ErrInvitationNotFound, "but got", err)
+	}
+
+	accepted, err := AcceptInvitation(invitation.Token, guest.Id)
+
+	if err != nil || accepted.Id != list.Id {
+		t.Fatal("Invitation should be accepted but got", err)
+	}
+	if role, _ := list.Role(guest.Id); role != permission.EDITOR_ROLE {
+		t.Error("Role should be", permission.EDITOR_ROLE, "but got", role)
+	}
+	if _, err := AcceptInvitation(invitation.Token, guest.Id); !errors.Is(err, ErrInvitationNotFound) {
+		t.Error("Invitation should only be accepted once but got", err)
+	}
+
+	weaker, err := list.Invite(owner.Id, guest.Email, permission.VIEWER_ROLE)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if _, err := AcceptInvitation(weaker.Token, guest.Id); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if role, _ := list.Role(guest.Id); role != permission.EDITOR_ROLE {
+		t.Error("Role should stay", permission.EDITOR_ROLE, "but got", role)
+	}
+}
@@ -1,7 +1,57 @@
 package main
 
-import "fmt"
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"todolist/attachment"
+	"todolist/cli"
+	"todolist/config"
+	"todolist/events"
+	"todolist/services"
+	taskLib "todolist/task"
+	"todolist/utils"
+)
+
+func setup(cfg config.Config) error {
+	err := os.MkdirAll(filepath.Dir(cfg.Database.Path), 0o755)
+
+	if err != nil {
+		return err
+	}
+
+	utils.SqliteInstance, err = utils.Open(cfg.Database.Driver, cfg.Database.Path)
+
+	if err != nil {
+		return err
+	}
+
+	attachment.StoreInstance = attachment.NewLocalStore(cfg.AttachmentsPath())
+	services.RegisterSubscribers(events.BusInstance)
+	// a failed purge is retried on the next run and does not stop the command
+	_, err = taskLib.PurgeExpired(cfg.TrashRetention())
+
+	if err != nil {
+		cli.PrintError(fmt.Errorf("Purging the trash: %w", err))
+	}
+
+	return nil
+}
 
 func main() {
-	fmt.Println("vim-go")
+	cli.Setup = setup
+
+	err := cli.Run(os.Args[1:])
+	events.BusInstance.Wait()
+
+	if utils.SqliteInstance.DB != nil {
+		utils.SqliteInstance.Close()
+	}
+
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		cli.PrintError(err)
+		os.Exit(1)
+	}
 }
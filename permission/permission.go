@@ -0,0 +1,40 @@
+package permission
+
+import (
+	"errors"
+)
+
+const (
+	VIEWER_ROLE = "viewer"
+	EDITOR_ROLE = "editor"
+	OWNER_ROLE  = "owner"
+)
+
+const (
+	READ_ACTION  = "read"
+	WRITE_ACTION = "write"
+	SHARE_ACTION = "share"
+)
+
+var ROLES = []string{VIEWER_ROLE, EDITOR_ROLE, OWNER_ROLE}
+
+var (
+	ErrForbidden = errors.New("Permission denied")
+)
+
+var grants = map[string][]string{
+	VIEWER_ROLE: {READ_ACTION},
+	EDITOR_ROLE: {READ_ACTION, WRITE_ACTION},
+	OWNER_ROLE:  {READ_ACTION, WRITE_ACTION, SHARE_ACTION},
+}
+
+func IsRole(role string) bool {
+	_, ok := grants[role]
+	return ok
+}
+
+// Allows tells whether role grants action on a task, an empty role grants
+// nothing.
+func Allows(role string, action string) bool {
+	return Authorize(TASK_RESOURCE, role, action)
+}
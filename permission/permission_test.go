@@ -0,0 +1,99 @@
+package permission
+
+import "testing"
+
+func TestAllows(t *testing.T) {
+	cases := []struct {
+		role     string
+		action   string
+		expected bool
+	}{
+		{VIEWER_ROLE, READ_ACTION, true},
+		{VIEWER_ROLE, WRITE_ACTION, false},
+		{EDITOR_ROLE, WRITE_ACTION, true},
+		{EDITOR_ROLE, SHARE_ACTION, false},
+		{OWNER_ROLE, SHARE_ACTION, true},
+		{"", READ_ACTION, false},
+		{"admin", READ_ACTION, false},
+	}
+
+	for _, c := range cases {
+		if Allows(c.role, c.action) != c.expected {
+			t.Error(c.role, "allows", c.action, "should be", c.expected)
+		}
+	}
+
+	if !IsRole(EDITOR_ROLE) || IsRole("admin") {
+		t.Error("Only defined roles should be roles")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	cases := []struct {
+		resource string
+		role     string
+		action   string
+		expected bool
+	}{
+		{LIST_RESOURCE, EDITOR_ROLE, WRITE_ACTION, true},
+		{WORKSPACE_RESOURCE, GUEST_ROLE, READ_ACTION, true},
+		{WORKSPACE_RESOURCE, GUEST_ROLE, CREATE_ACTION, false},
+		{WORKSPACE_RESOURCE, MEMBER_ROLE, CREATE_ACTION, true},
+		{WORKSPACE_RESOURCE, MEMBER_ROLE, SHARE_ACTION, false},
+		{WORKSPACE_RESOURCE, ADMIN_ROLE, DELETE_ACTION, true},
+		{WORKSPACE_RESOURCE, OWNER_ROLE, READ_ACTION, false},
+		{USER_RESOURCE, SELF_ROLE, WRITE_ACTION, true},
+		{USER_RESOURCE, ADMIN_ROLE, READ_ACTION, true},
+		{USER_RESOURCE, ADMIN_ROLE, WRITE_ACTION, false},
+		{USER_RESOURCE, MEMBER_ROLE, WRITE_ACTION, false},
+		{USER_RESOURCE, "", READ_ACTION, false},
+		{"unknown", ADMIN_ROLE, READ_ACTION, false},
+	}
+
+	for _, c := range cases {
+		if Authorize(c.resource, c.role, c.action) != c.expected {
+			t.Error(c.role, "allows", c.action, "on", c.resource, "should be", c.expected)
+		}
+	}
+}
+
+func TestPolicyGrant(t *testing.T) {
+	policy := Policy{}
+	policy.Grant(TASK_RESOURCE, GUEST_ROLE, READ_ACTION)
+
+	if !policy.Allows(TASK_RESOURCE, GUEST_ROLE, READ_ACTION) || policy.Allows(TASK_RESOURCE, GUEST_ROLE, WRITE_ACTION) {
+		t.Error("Policy should only allow the granted actions")
+	}
+	if Authorize(TASK_RESOURCE, GUEST_ROLE, READ_ACTION) {
+		t.Error("Granting on a policy should not change the default policy")
+	}
+	// the roles sharing their actions are changed one at a time
+	shared := make([]string, 1, 4)
+	shared[0] = READ_ACTION
+	policy = Policy{LIST_RESOURCE: {VIEWER_ROLE: shared, EDITOR_ROLE: shared}}
+	policy.Grant(LIST_RESOURCE, VIEWER_ROLE, WRITE_ACTION)
+	policy.Grant(LIST_RESOURCE, EDITOR_ROLE, SHARE_ACTION)
+
+	if !policy.Allows(LIST_RESOURCE, VIEWER_ROLE, WRITE_ACTION) || policy.Allows(LIST_RESOURCE, VIEWER_ROLE, SHARE_ACTION) {
+		t.Error("Granting on a role should not change another one but got", policy[LIST_RESOURCE])
+	}
+
+	defer func() { DefaultPolicy[TASK_RESOURCE] = copyGrants(grants) }()
+	DefaultPolicy.Grant(TASK_RESOURCE, EDITOR_ROLE, DELETE_ACTION)
+
+	if !Authorize(TASK_RESOURCE, EDITOR_ROLE, DELETE_ACTION) || Authorize(LIST_RESOURCE, EDITOR_ROLE, DELETE_ACTION) {
+		t.Error("Granting on tasks should not change the lists")
+	}
+}
+
+func TestInherit(t *testing.T) {
+	if Inherit(ADMIN_ROLE) != OWNER_ROLE || Inherit(MEMBER_ROLE) != EDITOR_ROLE || Inherit(GUEST_ROLE) != "" {
+		t.Error("Workspace roles should give owner, editor and nothing on lists")
+	}
+	if Strongest(VIEWER_ROLE, EDITOR_ROLE) != EDITOR_ROLE || Strongest(OWNER_ROLE, "") != OWNER_ROLE || Strongest("", VIEWER_ROLE) != VIEWER_ROLE {
+		t.Error("Strongest should return the role granting the most")
+	}
+	if Strongest(GUEST_ROLE, ADMIN_ROLE) != ADMIN_ROLE {
+		t.Error("Strongest should compare workspace roles")
+	}
+}
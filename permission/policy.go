@@ -0,0 +1,124 @@
+package permission
+
+const (
+	TASK_RESOURCE      = "task"
+	LIST_RESOURCE      = "list"
+	WORKSPACE_RESOURCE = "workspace"
+	USER_RESOURCE      = "user"
+)
+
+const (
+	ADMIN_ROLE  = "admin"
+	MEMBER_ROLE = "member"
+	GUEST_ROLE  = "guest"
+	// SELF_ROLE is the role of a user on its own account
+	SELF_ROLE = "self"
+)
+
+const (
+	CREATE_ACTION = "create"
+	DELETE_ACTION = "delete"
+)
+
+var WORKSPACE_ROLES = []string{GUEST_ROLE, MEMBER_ROLE, ADMIN_ROLE}
+
+// Policy lists the actions each role grants on each resource.
+type Policy map[string]map[string][]string
+
+// DefaultPolicy is the policy every operation is authorized against. Roles on
+// a user are the role of the actor in a workspace the user belongs to, only
+// users themselves change their account.
+var DefaultPolicy = Policy{
+	TASK_RESOURCE: copyGrants(grants),
+	LIST_RESOURCE: copyGrants(grants),
+	WORKSPACE_RESOURCE: {
+		GUEST_ROLE:  {READ_ACTION},
+		MEMBER_ROLE: {READ_ACTION, CREATE_ACTION},
+		ADMIN_ROLE:  {READ_ACTION, CREATE_ACTION, WRITE_ACTION, SHARE_ACTION, DELETE_ACTION},
+	},
+	USER_RESOURCE: {
+		SELF_ROLE:   {READ_ACTION, WRITE_ACTION, DELETE_ACTION},
+		ADMIN_ROLE:  {READ_ACTION},
+		MEMBER_ROLE: {READ_ACTION},
+		GUEST_ROLE:  {READ_ACTION},
+	},
+}
+
+// inherited maps the role in a workspace to the role on its lists, guests
+// only see the lists shared with them.
+var inherited = map[string]string{
+	ADMIN_ROLE:  OWNER_ROLE,
+	MEMBER_ROLE: EDITOR_ROLE,
+}
+
+// Grant adds actions to role on resource. The actions are copied so that no
+// other role nor resource sharing them is changed.
+func (p Policy) Grant(resource string, role string, actions ...string) {
+	if p[resource] == nil {
+		p[resource] = map[string][]string{}
+	}
+
+	granted := append([]string(nil), p[resource][role]...)
+	p[resource][role] = append(granted, actions...)
+}
+
+// copyGrants returns a copy of the actions of each role in grants, the
+// resources using the same grants are changed one at a time.
+func copyGrants(grants map[string][]string) map[string][]string {
+	copied := make(map[string][]string, len(grants))
+
+	for role, actions := range grants {
+		copied[role] = append([]string(nil), actions...)
+	}
+
+	return copied
+}
+
+func (p Policy) Allows(resource string, role string, action string) bool {
+	for _, granted := range p[resource][role] {
+		if granted == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authorize tells whether role grants action on resource in the default
+// policy.
+func Authorize(resource string, role string, action string) bool {
+	return DefaultPolicy.Allows(resource, role, action)
+}
+
+func IsWorkspaceRole(role string) bool {
+	_, ok := DefaultPolicy[WORKSPACE_RESOURCE][role]
+	return ok
+}
+
+// Inherit returns the role on the lists of a workspace given by the role in
+// the workspace.
+func Inherit(workspaceRole string) string {
+	return inherited[workspaceRole]
+}
+
+// Strongest returns the role granting the most of two list roles or of two
+// workspace roles.
+func Strongest(a string, b string) string {
+	if rank(a) >= rank(b) {
+		return a
+	}
+
+	return b
+}
+
+func rank(role string) int {
+	for _, roles := range [][]string{ROLES, WORKSPACE_ROLES} {
+		for i, r := range roles {
+			if r == role {
+				return i + 1
+			}
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,116 @@
+package preferences
+
+import (
+	"sort"
+)
+
+var catalogs = map[string]map[string]string{
+	"en": {
+		"id":           "Id",
+		"name":         "Name",
+		"completed":    "Completed",
+		"status":       "Status",
+		"description":  "Description",
+		"priority":     "Priority",
+		"urgency":      "Urgency",
+		"location":     "Location",
+		"label":        "Label",
+		"begin_date":   "Begins",
+		"end_date":     "Due",
+		"estimate":     "Estimate",
+		"assignee_id":  "Assignee",
+		"completed_at": "Completed on",
+		"created_at":   "Created",
+		"updated_at":   "Updated",
+		"true":         "true",
+		"false":        "false",
+		"due":          "due %s",
+		"due_today":    "due today",
+		"overdue":      "overdue since %s",
+		"comment":      "1 comment",
+		"comments":     "%d comments",
+		"progress":     "%d%% done",
+		"checklist":    "Checklist",
+		"reminder":     "%d tasks need your attention",
+		"digest":       "Your week starting %s",
+		"no_tasks":     "Nothing planned",
+		"low":          "low",
+		"medium":       "medium",
+		"high":         "high",
+		"critical":     "critical",
+		"Sunday":       "Sunday",
+		"Monday":       "Monday",
+		"Tuesday":      "Tuesday",
+		"Wednesday":    "Wednesday",
+		"Thursday":     "Thursday",
+		"Friday":       "Friday",
+		"Saturday":     "Saturday",
+	},
+	"fr": {
+		"id":           "Id",
+		"name":         "Nom",
+		"completed":    "Terminée",
+		"status":       "Statut",
+		"description":  "Description",
+		"priority":     "Priorité",
+		"urgency":      "Urgence",
+		"location":     "Lieu",
+		"label":        "Étiquette",
+		"begin_date":   "Début",
+		"end_date":     "Échéance",
+		"estimate":     "Estimation",
+		"assignee_id":  "Assigné à",
+		"completed_at": "Terminée le",
+		"created_at":   "Créée",
+		"updated_at":   "Modifiée",
+		"true":         "oui",
+		"false":        "non",
+		"due":          "pour le %s",
+		"due_today":    "pour aujourd'hui",
+		"overdue":      "en retard depuis le %s",
+		"comment":      "1 commentaire",
+		"comments":     "%d commentaires",
+		"progress":     "%d%% fait",
+		"checklist":    "Checklist",
+		"reminder":     "%d tâches demandent votre attention",
+		"digest":       "Votre semaine à partir du %s",
+		"no_tasks":     "Rien de prévu",
+		"low":          "basse",
+		"medium":       "moyenne",
+		"high":         "haute",
+		"critical":     "critique",
+		"Sunday":       "Dimanche",
+		"Monday":       "Lundi",
+		"Tuesday":      "Mardi",
+		"Wednesday":    "Mercredi",
+		"Thursday":     "Jeudi",
+		"Friday":       "Vendredi",
+		"Saturday":     "Samedi",
+	},
+}
+
+func Locales() []string {
+	var locales []string
+
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+
+	sort.Strings(locales)
+
+	return locales
+}
+
+// T returns the message for key in the locale of the preferences, falling
+// back to English then to the key itself.
+func (p *Preferences) T(key string) string {
+	if message, ok := catalogs[p.Locale][key]; ok {
+		return message
+	}
+
+	if message, ok := catalogs[DEFAULT_LOCALE][key]; ok {
+		return message
+	}
+
+	return key
+}
@@ -0,0 +1,117 @@
+package preferences
+
+import (
+	"fmt"
+	"time"
+	"todolist/utils"
+)
+
+const (
+	DEFAULT_LOCALE      = "en"
+	DEFAULT_DATE_FORMAT = "2006-01-02"
+
+	DEFAULT_POINT_MINUTES = 60
+	MINUTES_PER_DAY       = 24 * 60
+)
+
+type Preferences struct {
+	Timezone        string       `json:"timezone"`
+	Locale          string       `json:"locale"`
+	DateFormat      string       `json:"date_format"`
+	WeekStart       time.Weekday `json:"week_start"`
+	DefaultList     string       `json:"default_list"`
+	DefaultPriority int          `json:"default_priority"`
+	// DailyCapacity is the effort planned per day in minutes, 0 when the
+	// user does not plan, and a point of estimate is worth PointMinutes, 0
+	// for the default
+	DailyCapacity int `json:"daily_capacity"`
+	PointMinutes  int `json:"point_minutes"`
+}
+
+type PreferencesInterface interface {
+	Validate(minPriority int, maxPriority int) utils.ValidationErrors
+	Location() *time.Location
+	FormatDate(date time.Time) string
+	StartOfWeek(date time.Time) time.Time
+	MinutesPerPoint() int
+	T(key string) string
+}
+
+func Default() Preferences {
+	return Preferences{
+		Locale:     DEFAULT_LOCALE,
+		DateFormat: DEFAULT_DATE_FORMAT,
+		WeekStart:  time.Monday,
+	}
+}
+
+// Validate checks every preference, the priority bounds are given by the
+// caller since they belong to the task package. An empty locale or date
+// format falls back to its default.
+func (p *Preferences) Validate(minPriority int, maxPriority int) utils.ValidationErrors {
+	var errs utils.ValidationErrors
+
+	if _, err := utils.LoadLocation(p.Timezone); err != nil {
+		errs.Add("timezone", "is unknown")
+	}
+
+	if _, ok := catalogs[p.Locale]; !ok && p.Locale != "" {
+		errs.Add("locale", "is not supported")
+	}
+
+	if p.WeekStart < time.Sunday || p.WeekStart > time.Saturday {
+		errs.Add("week_start", "must be a day of the week")
+	}
+
+	if p.DefaultPriority < minPriority || p.DefaultPriority > maxPriority {
+		errs.Add("default_priority", fmt.Sprintf("must be between %d and %d", minPriority, maxPriority))
+	}
+
+	if p.DailyCapacity < 0 || p.DailyCapacity > MINUTES_PER_DAY {
+		errs.Add("daily_capacity", fmt.Sprintf("must be between 0 and %d minutes", MINUTES_PER_DAY))
+	}
+
+	if p.PointMinutes < 0 {
+		errs.Add("point_minutes", "must not be negative")
+	}
+
+	return errs
+}
+
+// Location returns the timezone of the preferences, time.Local when it is not
+// set or unknown.
+func (p *Preferences) Location() *time.Location {
+	loc, err := utils.LoadLocation(p.Timezone)
+
+	if err != nil {
+		return time.Local
+	}
+
+	return loc
+}
+
+// MinutesPerPoint returns PointMinutes or its default.
+func (p *Preferences) MinutesPerPoint() int {
+	if p.PointMinutes == 0 {
+		return DEFAULT_POINT_MINUTES
+	}
+
+	return p.PointMinutes
+}
+
+func (p *Preferences) FormatDate(date time.Time) string {
+	layout := p.DateFormat
+
+	if layout == "" {
+		layout = DEFAULT_DATE_FORMAT
+	}
+
+	return date.In(p.Location()).Format(layout)
+}
+
+func (p *Preferences) StartOfWeek(date time.Time) time.Time {
+	day := utils.StartOfDay(date, p.Location())
+	offset := (int(day.Weekday()) - int(p.WeekStart) + 7) % 7
+
+	return day.AddDate(0, 0, -offset)
+}
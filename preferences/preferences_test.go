@@ -0,0 +1,91 @@
+package preferences
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	p := Default()
+
+	if errs := p.Validate(0, 4); len(errs) != 0 {
+		t.Error("Default preferences should be valid but got", errs)
+	}
+
+	p.Timezone = "Mars/Olympus"
+	p.Locale = "tlh"
+	p.WeekStart = 9
+	p.DefaultPriority = 5
+
+	errs := p.Validate(0, 4)
+	fields := []string{"timezone", "locale", "week_start", "default_priority"}
+
+	if len(errs) != len(fields) {
+		t.Fatal("Preferences should fail on", fields, "but got", errs)
+	}
+
+	for i, field := range fields {
+		if errs[i].Field != field {
+			t.Error("Preferences should fail on", field, "but got", errs[i].Field)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	p := Default()
+	p.Timezone = "Asia/Tokyo"
+	p.DateFormat = "02/01/2006 15:04"
+
+	date := time.Date(2026, time.March, 10, 20, 30, 0, 0, time.UTC)
+
+	if formatted := p.FormatDate(date); formatted != "11/03/2026 05:30" {
+		t.Error("Date should be 11/03/2026 05:30 but got", formatted)
+	}
+
+	p.DateFormat = ""
+
+	if formatted := p.FormatDate(date); formatted != "2026-03-11" {
+		t.Error("Date should fall back to 2026-03-11 but got", formatted)
+	}
+}
+
+func TestStartOfWeek(t *testing.T) {
+	p := Default()
+	p.Timezone = "UTC"
+
+	// Wednesday
+	date := time.Date(2026, time.March, 11, 15, 0, 0, 0, time.UTC)
+
+	if start := p.StartOfWeek(date); !start.Equal(time.Date(2026, time.March, 9, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Week should start on Monday March 9 but got", start)
+	}
+
+	p.WeekStart = time.Sunday
+
+	if start := p.StartOfWeek(date); !start.Equal(time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Week should start on Sunday March 8 but got", start)
+	}
+}
+
+func TestT(t *testing.T) {
+	p := Default()
+
+	if message := p.T("name"); message != "Name" {
+		t.Error("Message should be Name but got", message)
+	}
+
+	p.Locale = "fr"
+
+	if message := p.T("name"); message != "Nom" {
+		t.Error("Message should be Nom but got", message)
+	}
+
+	p.Locale = "tlh"
+
+	if message := p.T("name"); message != "Name" {
+		t.Error("Unknown locale should fall back to English but got", message)
+	}
+	if message := p.T("missing"); message != "missing" {
+		t.Error("Unknown key should be returned as is but got", message)
+	}
+}
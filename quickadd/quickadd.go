@@ -0,0 +1,370 @@
+package quickadd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	taskLib "todolist/task"
+)
+
+type Parser struct {
+	Now      time.Time
+	Location *time.Location
+}
+
+type ParserInterface interface {
+	Parse(input string) (taskLib.Task, error)
+}
+
+var (
+	priorityPattern = regexp.MustCompile(`^!(\w+)$`)
+	clockPattern    = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+	frenchPattern   = regexp.MustCompile(`^(\d{1,2})h(\d{2})?$`)
+	isoPattern      = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	inPattern       = regexp.MustCompile(`^(day|week|month)s?$`)
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+var months = map[string]time.Month{}
+
+func init() {
+	for month := time.January; month <= time.December; month++ {
+		name := strings.ToLower(month.String())
+		months[name] = month
+		months[name[:3]] = month
+	}
+}
+
+func NewParser(now time.Time, loc *time.Location) Parser {
+	return Parser{Now: now, Location: loc}
+}
+
+func Parse(input string) (taskLib.Task, error) {
+	return NewParser(time.Now(), time.Local).Parse(input)
+}
+
+// Parse builds a task from a single line. Dates fill BeginDate and EndDate,
+// !N or a level such as !high sets the priority, #tags are joined into the
+// label, @word becomes the location and every other word is kept in the
+// name.
+//
+//	Call dentist tomorrow 3pm !2 #health @phone
+//	Write report from monday to friday #work
+//	Renew passport in 3 weeks
+func (p Parser) Parse(input string) (taskLib.Task, error) {
+	var name []string
+	var labels []string
+	var locations []string
+	var begin, end time.Time
+	var priority int
+
+	tokens := strings.Fields(input)
+
+	for i := 0; i < len(tokens); {
+		token := tokens[i]
+		lower := strings.ToLower(token)
+
+		if match := priorityPattern.FindStringSubmatch(token); match != nil {
+			if level, err := taskLib.ParsePriority(match[1]); err == nil {
+				priority = level
+				i++
+				continue
+			}
+		}
+
+		if len(token) > 1 && token[0] == '#' {
+			labels = append(labels, token[1:])
+			i++
+			continue
+		}
+
+		if len(token) > 1 && token[0] == '@' {
+			locations = append(locations, token[1:])
+			i++
+			continue
+		}
+
+		switch lower {
+		case "from":
+			if from, n := p.when(tokens, i+1); n > 0 {
+				begin = from
+				i += 1 + n
+
+				// the end is read relative to the begin so "from friday to
+				// monday" spans a weekend
+				if i < len(tokens) && strings.ToLower(tokens[i]) == "to" {
+					if to, n := NewParser(begin, p.Location).when(tokens, i+1); n > 0 {
+						end = to
+						i += 1 + n
+					}
+				}
+
+				continue
+			}
+		case "start", "starting":
+			if date, n := p.when(tokens, i+1); n > 0 {
+				begin = date
+				i += 1 + n
+				continue
+			}
+		case "by", "due", "on", "at":
+			if date, n := p.when(tokens, i+1); n > 0 {
+				end = date
+				i += 1 + n
+				continue
+			}
+		}
+
+		if date, n := p.when(tokens, i); n > 0 {
+			end = date
+			i += n
+			continue
+		}
+
+		name = append(name, token)
+		i++
+	}
+
+	if len(name) == 0 {
+		return taskLib.Task{}, fmt.Errorf("Missing task name in %q", input)
+	}
+
+	task := taskLib.NewTask(strings.Join(name, " "))
+	task.BeginDate = begin
+	task.EndDate = end
+	task.Priority = priority
+	task.Label = strings.Join(labels, ",")
+	task.Location = strings.Join(locations, ",")
+
+	return task, nil
+}
+
+// when parses a day and a time of day in any order, "at" may sit between
+// them. It returns the number of tokens used, 0 when nothing matched.
+func (p Parser) when(tokens []string, i int) (time.Time, int) {
+	day, n := p.day(tokens, i)
+	hour, minute, m := 0, 0, 0
+
+	if n > 0 {
+		j := i + n
+
+		if j < len(tokens) && strings.ToLower(tokens[j]) == "at" {
+			if h, min, k := clock(tokens, j+1); k > 0 {
+				hour, minute, m = h, min, k+1
+			}
+		} else {
+			hour, minute, m = clock(tokens, j)
+		}
+
+		return at(day, hour, minute), n + m
+	}
+
+	hour, minute, m = clock(tokens, i)
+
+	if m == 0 {
+		return time.Time{}, 0
+	}
+
+	day, n = p.day(tokens, i+m)
+
+	if n == 0 {
+		day = p.today()
+	}
+
+	return at(day, hour, minute), m + n
+}
+
+func at(day time.Time, hour int, minute int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+}
+
+func (p Parser) today() time.Time {
+	now := p.Now.In(p.Location)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, p.Location)
+}
+
+// day parses a calendar day starting at tokens[i], the result is at midnight
+// in the parser location.
+func (p Parser) day(tokens []string, i int) (time.Time, int) {
+	if i >= len(tokens) {
+		return time.Time{}, 0
+	}
+
+	today := p.today()
+	word := strings.ToLower(tokens[i])
+	next := ""
+
+	if i+1 < len(tokens) {
+		next = strings.ToLower(tokens[i+1])
+	}
+
+	switch word {
+	case "today":
+		return today, 1
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), 1
+	case "next":
+		switch next {
+		case "week":
+			return today.AddDate(0, 0, 7), 2
+		case "month":
+			return today.AddDate(0, 1, 0), 2
+		case "year":
+			return today.AddDate(1, 0, 0), 2
+		}
+
+		if weekday, ok := weekdays[next]; ok {
+			return nextWeekday(today.AddDate(0, 0, 1), weekday), 2
+		}
+	case "this":
+		if weekday, ok := weekdays[next]; ok {
+			return nextWeekday(today, weekday), 2
+		}
+	case "in":
+		count, err := strconv.Atoi(next)
+
+		if err == nil && count > 0 && i+2 < len(tokens) {
+			if match := inPattern.FindStringSubmatch(strings.ToLower(tokens[i+2])); match != nil {
+				switch match[1] {
+				case "day":
+					return today.AddDate(0, 0, count), 3
+				case "week":
+					return today.AddDate(0, 0, 7*count), 3
+				case "month":
+					return today.AddDate(0, count, 0), 3
+				}
+			}
+		}
+	}
+
+	if weekday, ok := weekdays[word]; ok {
+		return nextWeekday(today, weekday), 1
+	}
+
+	if isoPattern.MatchString(word) {
+		if date, err := time.ParseInLocation("2006-01-02", word, p.Location); err == nil {
+			return date, 1
+		}
+	}
+
+	if month, ok := months[word]; ok {
+		if dayOfMonth, err := strconv.Atoi(next); err == nil {
+			if date, ok := p.monthDay(month, dayOfMonth); ok {
+				return date, 2
+			}
+		}
+	}
+
+	if dayOfMonth, err := strconv.Atoi(word); err == nil {
+		if month, ok := months[next]; ok {
+			if date, ok := p.monthDay(month, dayOfMonth); ok {
+				return date, 2
+			}
+		}
+	}
+
+	return time.Time{}, 0
+}
+
+// monthDay returns the next occurrence of month and day, today included.
+func (p Parser) monthDay(month time.Month, day int) (time.Time, bool) {
+	today := p.today()
+	date := time.Date(today.Year(), month, day, 0, 0, 0, 0, p.Location)
+
+	if date.Month() != month {
+		return time.Time{}, false
+	}
+
+	if date.Before(today) {
+		date = date.AddDate(1, 0, 0)
+	}
+
+	return date, true
+}
+
+func nextWeekday(from time.Time, weekday time.Weekday) time.Time {
+	return from.AddDate(0, 0, (int(weekday)-int(from.Weekday())+7)%7)
+}
+
+// clock parses a time of day such as 3pm, 3 pm, 3:30pm, 15:00, 15h, 15h30,
+// noon or midnight.
+func clock(tokens []string, i int) (int, int, int) {
+	if i >= len(tokens) {
+		return 0, 0, 0
+	}
+
+	word := strings.ToLower(tokens[i])
+
+	switch word {
+	case "noon":
+		return 12, 0, 1
+	case "midnight":
+		return 0, 0, 1
+	}
+
+	if match := frenchPattern.FindStringSubmatch(word); match != nil {
+		hour, _ := strconv.Atoi(match[1])
+		minute, _ := strconv.Atoi(match[2])
+
+		if hour < 24 && minute < 60 {
+			return hour, minute, 1
+		}
+
+		return 0, 0, 0
+	}
+
+	match := clockPattern.FindStringSubmatch(word)
+
+	if match == nil {
+		return 0, 0, 0
+	}
+
+	n := 1
+	suffix := match[3]
+
+	if suffix == "" && i+1 < len(tokens) {
+		if next := strings.ToLower(tokens[i+1]); next == "am" || next == "pm" {
+			suffix = next
+			n = 2
+		}
+	}
+
+	// a bare number is part of the name, "15:00" or "3pm" is a time
+	if suffix == "" && match[2] == "" {
+		return 0, 0, 0
+	}
+
+	hour, _ := strconv.Atoi(match[1])
+	minute, _ := strconv.Atoi(match[2])
+
+	if minute >= 60 {
+		return 0, 0, 0
+	}
+
+	if suffix != "" {
+		if hour < 1 || hour > 12 {
+			return 0, 0, 0
+		}
+
+		hour %= 12
+
+		if suffix == "pm" {
+			hour += 12
+		}
+	} else if hour >= 24 {
+		return 0, 0, 0
+	}
+
+	return hour, minute, n
+}
@@ -0,0 +1,124 @@
+package quickadd
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	paris, _ = time.LoadLocation("Europe/Paris")
+	// Wednesday
+	now = time.Date(2026, time.March, 11, 10, 0, 0, 0, paris)
+)
+
+func date(month time.Month, day int, hour int, minute int) time.Time {
+	return time.Date(2026, month, day, hour, minute, 0, 0, paris)
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		input    string
+		name     string
+		begin    time.Time
+		end      time.Time
+		priority int
+		label    string
+		location string
+	}{
+		{"Buy milk", "Buy milk", time.Time{}, time.Time{}, 0, "", ""},
+		{"Call dentist tomorrow 3pm !2 #health @phone", "Call dentist", time.Time{}, date(time.March, 12, 15, 0), 2, "health", "phone"},
+		{"Call dentist tomorrow at 3:30pm", "Call dentist", time.Time{}, date(time.March, 12, 15, 30), 0, "", ""},
+		{"Call dentist 3 pm tomorrow", "Call dentist", time.Time{}, date(time.March, 12, 15, 0), 0, "", ""},
+		{"Standup at 9:15", "Standup", time.Time{}, date(time.March, 11, 9, 15), 0, "", ""},
+		{"Lunch at noon", "Lunch", time.Time{}, date(time.March, 11, 12, 0), 0, "", ""},
+		{"Réunion demain 14h30", "Réunion demain", time.Time{}, date(time.March, 11, 14, 30), 0, "", ""},
+		{"Deploy 12am", "Deploy", time.Time{}, date(time.March, 11, 0, 0), 0, "", ""},
+		{"Pay rent today", "Pay rent", time.Time{}, date(time.March, 11, 0, 0), 0, "", ""},
+		{"Pay rent TODAY", "Pay rent", time.Time{}, date(time.March, 11, 0, 0), 0, "", ""},
+		{"Gym friday", "Gym", time.Time{}, date(time.March, 13, 0, 0), 0, "", ""},
+		{"Gym wed", "Gym", time.Time{}, date(time.March, 11, 0, 0), 0, "", ""},
+		{"Gym next wednesday", "Gym", time.Time{}, date(time.March, 18, 0, 0), 0, "", ""},
+		{"Gym this saturday", "Gym", time.Time{}, date(time.March, 14, 0, 0), 0, "", ""},
+		{"Retro next week", "Retro", time.Time{}, date(time.March, 18, 0, 0), 0, "", ""},
+		{"Invoice next month", "Invoice", time.Time{}, date(time.April, 11, 0, 0), 0, "", ""},
+		{"Renew passport in 3 weeks", "Renew passport", time.Time{}, date(time.April, 1, 0, 0), 0, "", ""},
+		{"Water plants in 1 day", "Water plants", time.Time{}, date(time.March, 12, 0, 0), 0, "", ""},
+		{"Taxes in 2 months", "Taxes", time.Time{}, date(time.May, 11, 0, 0), 0, "", ""},
+		{"Release 2026-04-01", "Release", time.Time{}, date(time.April, 1, 0, 0), 0, "", ""},
+		{"Release on 2026-04-01 at 18:00", "Release", time.Time{}, date(time.April, 1, 18, 0), 0, "", ""},
+		{"Birthday march 20", "Birthday", time.Time{}, date(time.March, 20, 0, 0), 0, "", ""},
+		{"Birthday 20 Mar", "Birthday", time.Time{}, date(time.March, 20, 0, 0), 0, "", ""},
+		{"Anniversary jan 5", "Anniversary", time.Time{}, time.Date(2027, time.January, 5, 0, 0, 0, 0, paris), 0, "", ""},
+		{"Report due friday 5pm", "Report", time.Time{}, date(time.March, 13, 17, 0), 0, "", ""},
+		{"Report by tomorrow", "Report", time.Time{}, date(time.March, 12, 0, 0), 0, "", ""},
+		{"Conference from monday to wednesday", "Conference", date(time.March, 16, 0, 0), date(time.March, 18, 0, 0), 0, "", ""},
+		{"Trip from friday to monday #travel", "Trip", date(time.March, 13, 0, 0), date(time.March, 16, 0, 0), 0, "travel", ""},
+		{"Sprint starting tomorrow by next week", "Sprint", date(time.March, 12, 0, 0), date(time.March, 18, 0, 0), 0, "", ""},
+		{"Write #work #urgent report !4", "Write report", time.Time{}, time.Time{}, 4, "work,urgent", ""},
+		{"Print @office @home", "Print", time.Time{}, time.Time{}, 0, "", "office,home"},
+		{"Buy 3 apples", "Buy 3 apples", time.Time{}, time.Time{}, 0, "", ""},
+		{"Work on report", "Work on report", time.Time{}, time.Time{}, 0, "", ""},
+		{"Dinner at home", "Dinner at home", time.Time{}, time.Time{}, 0, "", ""},
+		{"Move in the new flat", "Move in the new flat", time.Time{}, time.Time{}, 0, "", ""},
+		{"From scratch", "From scratch", time.Time{}, time.Time{}, 0, "", ""},
+		{"Meet at 25:00", "Meet at 25:00", time.Time{}, time.Time{}, 0, "", ""},
+		{"Meet at 13pm", "Meet at 13pm", time.Time{}, time.Time{}, 0, "", ""},
+		{"Feb 30 party", "Feb 30 party", time.Time{}, time.Time{}, 0, "", ""},
+		{"Hello! # @", "Hello! # @", time.Time{}, time.Time{}, 0, "", ""},
+		{"Priority !x", "Priority !x", time.Time{}, time.Time{}, 0, "", ""},
+		{"Fix outage !Critical", "Fix outage", time.Time{}, time.Time{}, 4, "", ""},
+		{"  spaced   out  ", "spaced out", time.Time{}, time.Time{}, 0, "", ""},
+	}
+
+	parser := NewParser(now, paris)
+
+	for _, c := range cases {
+		task, err := parser.Parse(c.input)
+
+		if err != nil {
+			t.Error(c.input, "should parse but got", err)
+			continue
+		}
+		if task.Name != c.name {
+			t.Errorf("%q name should be %q but got %q", c.input, c.name, task.Name)
+		}
+		if !task.BeginDate.Equal(c.begin) {
+			t.Errorf("%q begin should be %v but got %v", c.input, c.begin, task.BeginDate)
+		}
+		if !task.EndDate.Equal(c.end) {
+			t.Errorf("%q end should be %v but got %v", c.input, c.end, task.EndDate)
+		}
+		if task.Priority != c.priority {
+			t.Errorf("%q priority should be %d but got %d", c.input, c.priority, task.Priority)
+		}
+		if task.Label != c.label {
+			t.Errorf("%q label should be %q but got %q", c.input, c.label, task.Label)
+		}
+		if task.Location != c.location {
+			t.Errorf("%q location should be %q but got %q", c.input, c.location, task.Location)
+		}
+		if task.CreatedAt.IsZero() {
+			t.Errorf("%q should be built with NewTask", c.input)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	parser := NewParser(now, paris)
+
+	for _, input := range []string{"", "   ", "tomorrow 3pm", "#work !2 @home"} {
+		if _, err := parser.Parse(input); err == nil {
+			t.Errorf("%q should fail without a name", input)
+		}
+	}
+}
+
+func TestParseTimezone(t *testing.T) {
+	// 23:30 UTC is already Thursday in Paris
+	utcNow := time.Date(2026, time.March, 11, 23, 30, 0, 0, time.UTC)
+	task, _ := NewParser(utcNow, paris).Parse("Call tomorrow")
+
+	if !task.EndDate.Equal(date(time.March, 13, 0, 0)) {
+		t.Error("Tomorrow should be Friday in Paris but got", task.EndDate)
+	}
+}
@@ -0,0 +1,62 @@
+package reminder
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"todolist/services"
+	userLib "todolist/user"
+)
+
+// Digest returns the subject and body of the weekly email listing the open
+// tasks due each day of the week containing now, the week starts on the day
+// chosen in the user preferences.
+func Digest(user userLib.User, now time.Time) (string, string) {
+	var lines []string
+	p := user.Preferences
+	loc := user.Location()
+	start := p.StartOfWeek(now)
+
+	for i := 0; i < 7; i++ {
+		day := start.AddDate(0, 0, i)
+		lines = append(lines, p.T(day.Weekday().String())+" "+p.FormatDate(day))
+
+		count := 0
+
+		for _, task := range user.Tasks {
+			if !task.Completed && task.IsDueOn(day, loc) {
+				lines = append(lines, "- "+task.Name)
+				count++
+			}
+		}
+
+		if count == 0 {
+			lines = append(lines, "  "+p.T("no_tasks"))
+		}
+	}
+
+	return fmt.Sprintf(p.T("digest"), p.FormatDate(start)), strings.Join(lines, "\n")
+}
+
+func SendDigest(user userLib.User, now time.Time) error {
+	subject, body := Digest(user, now)
+	return services.SendEmail(user.Email, subject, body)
+}
+
+func SendAllDigests(now time.Time) error {
+	users, err := userLib.GetUsers()
+
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		err := SendDigest(user, now)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
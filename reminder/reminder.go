@@ -0,0 +1,71 @@
+package reminder
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"todolist/services"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+)
+
+// DueTasks returns the open tasks of the user that are due today or overdue,
+// days are computed in the user timezone.
+func DueTasks(user userLib.User, now time.Time) []taskLib.Task {
+	var due []taskLib.Task
+	loc := user.Location()
+
+	for _, task := range user.Tasks {
+		if task.Completed {
+			continue
+		}
+
+		if task.IsDueOn(now, loc) || task.IsOverdue(now, loc) {
+			due = append(due, task)
+		}
+	}
+
+	return due
+}
+
+func Send(user userLib.User, now time.Time) error {
+	due := DueTasks(user, now)
+
+	if len(due) == 0 {
+		return nil
+	}
+
+	var lines []string
+	p := user.Preferences
+	loc := user.Location()
+
+	for _, task := range due {
+		state := p.T("due_today")
+
+		if task.IsOverdue(now, loc) {
+			state = fmt.Sprintf(p.T("overdue"), p.FormatDate(task.EndDate))
+		}
+
+		lines = append(lines, fmt.Sprintf("- %s (%s)", task.Name, state))
+	}
+
+	return services.SendEmail(user.Email, fmt.Sprintf(p.T("reminder"), len(due)), strings.Join(lines, "\n"))
+}
+
+func SendAll(now time.Time) error {
+	users, err := userLib.GetUsers()
+
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		err := Send(user, now)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package reminder
+
+import (
+	"strings"
+	"testing"
+	"time"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+)
+
+func TestDueTasks(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 23, 30, 0, 0, time.UTC)
+
+	today := taskLib.NewTask("today")
+	today.EndDate = time.Date(2026, time.March, 10, 9, 0, 0, 0, time.UTC)
+
+	tomorrow := taskLib.NewTask("tomorrow")
+	tomorrow.EndDate = time.Date(2026, time.March, 11, 9, 0, 0, 0, time.UTC)
+
+	late := taskLib.NewTask("late")
+	late.EndDate = time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC)
+
+	done := taskLib.NewTask("done")
+	done.EndDate = today.EndDate
+	done.Completed = true
+
+	user := userLib.NewUser("John", "Doe", "john@example.com", []taskLib.Task{today, tomorrow, late, done, taskLib.NewTask("undated")})
+	user.Preferences.Timezone = "UTC"
+
+	due := DueTasks(user, now)
+
+	if len(due) != 2 || due[0].Name != "today" || due[1].Name != "late" {
+		t.Error("Due tasks should be today and late but got", due)
+	}
+
+	// it is already March 11 in Tokyo
+	user.Preferences.Timezone = "Asia/Tokyo"
+	due = DueTasks(user, now)
+
+	if len(due) != 3 {
+		t.Error("Due tasks in Tokyo should be today, tomorrow and late but got", due)
+	}
+}
+
+func TestSend(t *testing.T) {
+	user := userLib.NewUser("John", "Doe", "john@example.com", nil)
+
+	if err := Send(user, time.Now()); err != nil {
+		t.Error("Error should be nil but got", err)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	// Wednesday
+	now := time.Date(2026, time.March, 11, 10, 0, 0, 0, time.UTC)
+
+	monday := taskLib.NewTask("monday")
+	monday.EndDate = time.Date(2026, time.March, 9, 9, 0, 0, 0, time.UTC)
+
+	nextWeek := taskLib.NewTask("next week")
+	nextWeek.EndDate = time.Date(2026, time.March, 16, 9, 0, 0, 0, time.UTC)
+
+	user := userLib.NewUser("Jean", "Dupont", "jean@example.com", []taskLib.Task{monday, nextWeek})
+	user.Preferences.Timezone = "UTC"
+	user.Preferences.Locale = "fr"
+	user.Preferences.DateFormat = "02/01"
+
+	subject, body := Digest(user, now)
+
+	if subject != "Votre semaine à partir du 09/03" {
+		t.Error("Subject should be localized but got", subject)
+	}
+	if !strings.HasPrefix(body, "Lundi 09/03\n- monday\n") {
+		t.Error("Digest should start with Monday tasks but got", body)
+	}
+	if strings.Contains(body, "next week") {
+		t.Error("Digest should not list tasks of next week")
+	}
+}
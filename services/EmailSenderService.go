@@ -1,6 +1,14 @@
 package services
 
-import "fmt"
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"todolist/config"
+)
 
 type EmailSenderService struct {
 }
@@ -9,10 +17,44 @@ type EmailSenderServiceInterface interface {
 	SendEmail(email string, subject string, body string) error
 }
 
+// SendEmail sends the email through the configured SMTP server, it is only
+// printed when no server is configured.
 func SendEmail(email string, subject string, body string) error {
-	fmt.Println("Email sent:")
-	fmt.Println("To:", email)
-	fmt.Println("Subject:", subject)
-	fmt.Println("Body:", body)
-	return nil
+	settings := config.Current.SMTP
+
+	if settings.Host == "" {
+		fmt.Println("Email sent:")
+		fmt.Println("To:", email)
+		fmt.Println("Subject:", subject)
+		fmt.Println("Body:", body)
+		return nil
+	}
+
+	var auth smtp.Auth
+
+	if settings.Username != "" {
+		auth = smtp.PlainAuth("", settings.Username, settings.Password, settings.Host)
+	}
+
+	addr := net.JoinHostPort(settings.Host, strconv.Itoa(settings.Port))
+
+	return smtp.SendMail(addr, auth, settings.From, []string{email}, []byte(message(settings.From, email, subject, body)))
+}
+
+// headerValue removes the line breaks a value could use to add headers.
+var headerValue = strings.NewReplacer("\r", "", "\n", "")
+
+// message builds the email, the subject carries user text such as task and
+// list names so it is stripped of line breaks and encoded for non-ASCII
+// characters.
+func message(from string, to string, subject string, body string) string {
+	return strings.Join([]string{
+		"From: " + headerValue.Replace(from),
+		"To: " + headerValue.Replace(to),
+		"Subject: " + mime.QEncoding.Encode("utf-8", headerValue.Replace(subject)),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		body,
+	}, "\r\n")
 }
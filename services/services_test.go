@@ -1,7 +1,14 @@
 package services
 
 import (
+	"strings"
 	"testing"
+	"time"
+	"todolist/attachment"
+	"todolist/events"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+	"todolist/utils"
 
 	fakerLib "github.com/jaswdr/faker"
 )
@@ -14,3 +21,95 @@ func TestSendEmail(t *testing.T) {
 		t.Error("Error should be nil but got", err)
 	}
 }
+
+func TestMessage(t *testing.T) {
+	message := message("todo@example.com", "john@example.com", "Tâche \"Milk\r\nBcc: eve@example.com\" assignée", "Body")
+	headers, body, _ := strings.Cut(message, "\r\n\r\n")
+
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Error("Subject should not add headers but got", headers)
+	}
+	if !strings.Contains(headers, "Subject: =?utf-8?q?") {
+		t.Error("Subject should be encoded but got", headers)
+	}
+	if body != "Body" {
+		t.Error("Body should be", "Body", "but got", body)
+	}
+}
+
+func TestRegisterSubscribers(t *testing.T) {
+	bus := events.NewBus()
+	RegisterSubscribers(bus)
+
+	err := bus.Publish(events.QuotaWarning{UserId: 1, Email: faker.Internet().Email(), TasksLeft: 2})
+
+	if err != nil {
+		t.Error("Error should be nil but got", err)
+	}
+
+	err = bus.Publish(events.ListInvitation{ListId: 1, ListName: "Groceries", Email: faker.Internet().Email(), Role: "editor", Token: "token"})
+
+	if err != nil {
+		t.Error("Error should be nil but got", err)
+	}
+}
+
+func TestTaskAssignedSubscriber(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	bus := events.NewBus()
+	RegisterSubscribers(bus)
+
+	user := userLib.NewUser(faker.Person().FirstName(), faker.Person().LastName(), faker.Internet().Email(), nil)
+	user.Birthdate = time.Now().AddDate(-30, 0, 0)
+	user.Save()
+
+	err := bus.Publish(events.TaskAssigned{TaskId: 1, Name: "Milk", AssigneeId: user.Id, AssignerId: 2})
+
+	if err != nil {
+		t.Error("Error should be nil but got", err)
+	}
+
+	err = bus.Publish(events.TaskAssigned{TaskId: 1, Name: "Milk", AssigneeId: 42, AssignerId: 2})
+
+	if err == nil {
+		t.Error("Should return an error for an unknown assignee")
+	}
+}
+
+func TestCommentMentionSubscriber(t *testing.T) {
+	bus := events.NewBus()
+	RegisterSubscribers(bus)
+
+	err := bus.Publish(events.CommentMention{CommentId: 1, TaskId: 1, TaskName: "Milk", AuthorId: 2, UserId: 1, Email: faker.Internet().Email(), Body: "Whole or **skimmed**?"})
+
+	if err != nil {
+		t.Error("Error should be nil but got", err)
+	}
+}
+
+func TestTaskPurgedSubscriber(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	defer func(store attachment.Store) { attachment.StoreInstance = store }(attachment.StoreInstance)
+	attachment.StoreInstance = attachment.NewLocalStore(t.TempDir())
+
+	bus := events.NewBus()
+	RegisterSubscribers(bus)
+
+	task := taskLib.NewTask("Notes")
+	task.Save()
+
+	if _, err := attachment.Attach(task.Id, 0, "notes.txt", strings.NewReader("notes")); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	if err := bus.Publish(events.TaskPurged{TaskId: task.Id}); err != nil {
+		t.Error("Error should be nil but got", err)
+	}
+	if attachments, _ := attachment.GetAttachmentsByTaskId(task.Id); len(attachments) != 0 {
+		t.Error("Attachments of the purged task should be deleted but got", attachments)
+	}
+}
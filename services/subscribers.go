@@ -0,0 +1,40 @@
+package services
+
+import (
+	"fmt"
+	"todolist/attachment"
+	"todolist/events"
+	userLib "todolist/user"
+)
+
+func RegisterSubscribers(bus *events.Bus) {
+	bus.Subscribe(events.QUOTA_WARNING, func(event events.Event) error {
+		warning := event.(events.QuotaWarning)
+		return SendEmail(warning.Email, "wake up", fmt.Sprintf("You have %d tasks left", warning.TasksLeft))
+	})
+	bus.Subscribe(events.LIST_INVITATION, func(event events.Event) error {
+		invitation := event.(events.ListInvitation)
+		body := fmt.Sprintf("You are invited to the list %s as %s.\nRun `todolist accept %s` to join it.", invitation.ListName, invitation.Role, invitation.Token)
+
+		return SendEmail(invitation.Email, "Invitation to "+invitation.ListName, body)
+	})
+	bus.Subscribe(events.TASK_ASSIGNED, func(event events.Event) error {
+		assigned := event.(events.TaskAssigned)
+		assignee, err := userLib.GetUser(assigned.AssigneeId)
+
+		if err != nil {
+			return err
+		}
+
+		return SendEmail(assignee.Email, "New task: "+assigned.Name, fmt.Sprintf("The task %q (#%d) was assigned to you.", assigned.Name, assigned.TaskId))
+	})
+	bus.Subscribe(events.TASK_PURGED, func(event events.Event) error {
+		return attachment.DeleteByTaskId(event.(events.TaskPurged).TaskId)
+	})
+	bus.Subscribe(events.COMMENT_MENTION, func(event events.Event) error {
+		mention := event.(events.CommentMention)
+		body := fmt.Sprintf("You were mentioned on the task %q (#%d):\n\n%s\n\nRun `todolist comments %d` to read the discussion.", mention.TaskName, mention.TaskId, mention.Body, mention.TaskId)
+
+		return SendEmail(mention.Email, "Mentioned on "+mention.TaskName, body)
+	})
+}
@@ -0,0 +1,67 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"todolist/utils"
+)
+
+var (
+	ErrAssigneeNotNotified = errors.New("Task assigned but the assignee could not be notified")
+)
+
+// GetTasksByAssigneeId returns the tasks assigned to the user, whoever owns
+// them.
+func GetTasksByAssigneeId(userId int64) ([]Task, error) {
+	return GetTasksByAssigneeIdContext(context.Background(), userId)
+}
+
+func GetTasksByAssigneeIdContext(ctx context.Context, userId int64) ([]Task, error) {
+	return queryTasks(ctx, "SELECT "+TASK_COLUMNS+" FROM tasks WHERE assignee_id = ? AND deleted_at IS NULL ORDER BY id", userId)
+}
+
+func (t *Task) Assign(actorId int64, assigneeId int64) error {
+	return t.AssignContext(context.Background(), actorId, assigneeId)
+}
+
+// AssignContext gives the task to assigneeId, 0 to unassign it, and saves it.
+// The assignee is notified through a TaskAssigned event once the task is
+// saved, a failed notification is reported as ErrAssigneeNotNotified and
+// leaves the task assigned.
+func (t *Task) AssignContext(ctx context.Context, actorId int64, assigneeId int64) (err error) {
+	previous := t.AssigneeId
+	t.AssigneeId = assigneeId
+
+	tx, err := utils.SqliteInstance.BeginTx(ctx)
+
+	if err != nil {
+		t.AssigneeId = previous
+		return err
+	}
+
+	defer func() {
+		if err != nil && !tx.Committed {
+			tx.Rollback()
+			t.AssigneeId = previous
+		}
+	}()
+
+	err = t.SaveWith(ctx, tx, actorId)
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return err
+	}
+
+	if tx.AfterCommitErr != nil {
+		return fmt.Errorf("%w: %w", ErrAssigneeNotNotified, tx.AfterCommitErr)
+	}
+
+	return nil
+}
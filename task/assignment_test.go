@@ -0,0 +1,147 @@
+package task
+
+import (
+	"errors"
+	"testing"
+	"todolist/events"
+	"todolist/permission"
+	"todolist/utils"
+)
+
+func TestAssign(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	var assigned []events.TaskAssigned
+	events.Subscribe(events.TASK_ASSIGNED, func(event events.Event) error {
+		assigned = append(assigned, event.(events.TaskAssigned))
+		return nil
+	})
+	defer events.BusInstance.Reset()
+
+	task := NewTask(TASK_NAME)
+	task.UserId = 1
+	task.Save()
+
+	if err := task.Assign(3, 2); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Stranger should not assign but got", err)
+	}
+	if task.AssigneeId != 0 {
+		t.Error("AssigneeId should be reverted but got", task.AssigneeId)
+	}
+
+	if err := task.Assign(1, 2); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if len(assigned) != 1 || assigned[0].AssigneeId != 2 || assigned[0].AssignerId != 1 || assigned[0].Name != TASK_NAME {
+		t.Error("Assignee should be notified but got", assigned)
+	}
+
+	tasks, _ := GetTasksByAssigneeId(2)
+
+	if len(tasks) != 1 || tasks[0].Id != task.Id {
+		t.Error("Task should be assigned to user 2 but got", tasks)
+	}
+	if tasks, _ := GetVisibleTasks(2); len(tasks) != 1 {
+		t.Error("Assignee should see the task but got", tasks)
+	}
+
+	task.Completed = true
+
+	if err := task.SaveAs(2); err != nil {
+		t.Error("Assignee should edit the task but got", err)
+	}
+
+	task.UserId = 2
+
+	if err := task.SaveAs(2); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Assignee should not own the task but got", err)
+	}
+
+	task.UserId = 1
+
+	// saving again or assigning oneself does not notify
+	task.SaveAs(1)
+	task.Assign(2, 2)
+	task.Assign(1, 1)
+
+	if len(assigned) != 1 {
+		t.Error("Assignee should be notified once but got", len(assigned))
+	}
+
+	if err := task.Assign(1, 0); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if tasks, _ := GetVisibleTasks(2); len(tasks) != 0 {
+		t.Error("Former assignee should not see the task but got", tasks)
+	}
+
+	created := NewTask(TASK_NAME)
+	created.UserId = 1
+	created.AssigneeId = 3
+	created.Save()
+
+	if len(assigned) != 2 || assigned[1].TaskId != created.Id {
+		t.Error("Assignee of a new task should be notified but got", assigned)
+	}
+}
+
+func TestAssignAccess(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	share(1, 1, permission.OWNER_ROLE)
+	share(1, 2, permission.EDITOR_ROLE)
+	share(1, 3, permission.VIEWER_ROLE)
+
+	task := NewTask(TASK_NAME)
+	task.UserId = 1
+	task.ListId = 1
+	task.Save()
+
+	if err := task.Assign(2, 4); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Editor should not assign a user outside the list but got", err)
+	}
+	if err := task.Assign(2, 3); err != nil {
+		t.Error("Editor should assign a member of the list but got", err)
+	}
+	if err := task.Assign(3, 4); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Assignee should not hand the task to a user outside the list but got", err)
+	}
+	if err := task.Assign(1, 4); err != nil {
+		t.Error("Owner should assign anyone but got", err)
+	}
+
+	created := NewTask(TASK_NAME)
+	created.UserId = 1
+	created.ListId = 1
+	created.AssigneeId = 5
+
+	if err := created.SaveAs(2); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Editor should not create a task assigned outside the list but got", err)
+	}
+}
+
+func TestAssignNotificationError(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	events.Subscribe(events.TASK_ASSIGNED, func(event events.Event) error {
+		return errors.New("smtp down")
+	})
+	defer events.BusInstance.Reset()
+
+	task := NewTask(TASK_NAME)
+	task.UserId = 1
+	task.Save()
+
+	if err := task.Assign(1, 2); !errors.Is(err, ErrAssigneeNotNotified) {
+		t.Error("Error should be", ErrAssigneeNotNotified, "but got", err)
+	}
+	if task.AssigneeId != 2 {
+		t.Error("AssigneeId should be kept but got", task.AssigneeId)
+	}
+	if saved := GetTask(task.Id); saved.AssigneeId != 2 {
+		t.Error("Assignment should be saved but got", saved.AssigneeId)
+	}
+}
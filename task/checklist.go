@@ -0,0 +1,275 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"todolist/permission"
+	"todolist/utils"
+)
+
+type ChecklistItem struct {
+	Id        int64     `json:"id"`
+	TaskId    int64     `json:"task_id"`
+	Position  int       `json:"position"`
+	Text      string    `json:"text"`
+	Checked   bool      `json:"checked"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	CHECKLIST_COLUMNS = "id, task_id, position, text, checked, created_at"
+)
+
+var (
+	ErrItemNotFound = errors.New("Checklist item not found")
+)
+
+// Progress returns the percentage of checked items, 0 without a checklist.
+func (t *Task) Progress() int {
+	if t.ChecklistItems == 0 {
+		return 0
+	}
+
+	return t.ChecklistChecked * 100 / t.ChecklistItems
+}
+
+// Checklist returns the items of the task in their order.
+func (t *Task) Checklist() ([]ChecklistItem, error) {
+	return t.ChecklistContext(context.Background())
+}
+
+func (t *Task) ChecklistContext(ctx context.Context) ([]ChecklistItem, error) {
+	return checklist(ctx, utils.SqliteInstance.DB, t.Id)
+}
+
+func checklist(ctx context.Context, exec utils.Executor, taskId int64) ([]ChecklistItem, error) {
+	var items []ChecklistItem
+
+	rows, err := exec.QueryContext(ctx, "SELECT "+CHECKLIST_COLUMNS+" FROM checklist_items WHERE task_id = ? ORDER BY position, id", taskId)
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying checklist: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var item ChecklistItem
+		var createdAt sql.NullTime
+
+		err := rows.Scan(&item.Id, &item.TaskId, &item.Position, &item.Text, &item.Checked, &createdAt)
+
+		if err != nil {
+			return nil, fmt.Errorf("Scanning checklist item: %w", err)
+		}
+
+		item.CreatedAt = createdAt.Time
+		items = append(items, item)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying checklist: %w", err)
+	}
+
+	return items, nil
+}
+
+func (t *Task) AddItem(actorId int64, text string) (ChecklistItem, error) {
+	return t.AddItemContext(context.Background(), actorId, text)
+}
+
+// AddItemContext appends an unchecked item to the checklist.
+func (t *Task) AddItemContext(ctx context.Context, actorId int64, text string) (ChecklistItem, error) {
+	item := ChecklistItem{TaskId: t.Id, Text: strings.TrimSpace(text), CreatedAt: time.Now()}
+
+	if item.Text == "" {
+		return ChecklistItem{}, utils.ValidationErrors{{Field: "text", Message: "is required"}}
+	}
+
+	err := t.editChecklist(ctx, actorId, func(exec utils.Executor, items []ChecklistItem) error {
+		item.Position = len(items)
+
+		res, err := exec.ExecContext(ctx, "INSERT INTO checklist_items (task_id, position, text, checked, created_at) VALUES (?, ?, ?, ?, ?)", item.TaskId, item.Position, item.Text, false, item.CreatedAt)
+
+		if err != nil {
+			return err
+		}
+
+		item.Id, err = res.LastInsertId()
+
+		return err
+	})
+
+	if err != nil {
+		return ChecklistItem{}, err
+	}
+
+	return item, nil
+}
+
+func (t *Task) ToggleItem(actorId int64, itemId int64) error {
+	return t.ToggleItemContext(context.Background(), actorId, itemId)
+}
+
+// ToggleItemContext checks or unchecks the item. Checking the last item
+// completes a task set to AutoComplete.
+func (t *Task) ToggleItemContext(ctx context.Context, actorId int64, itemId int64) error {
+	return t.editChecklist(ctx, actorId, func(exec utils.Executor, items []ChecklistItem) error {
+		index, err := findItem(items, itemId)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = exec.ExecContext(ctx, "UPDATE checklist_items SET checked = ? WHERE id = ?", !items[index].Checked, itemId)
+
+		return err
+	})
+}
+
+func (t *Task) MoveItem(actorId int64, itemId int64, position int) error {
+	return t.MoveItemContext(context.Background(), actorId, itemId, position)
+}
+
+// MoveItemContext moves the item to position, counted from 0, and shifts
+// the items in between.
+func (t *Task) MoveItemContext(ctx context.Context, actorId int64, itemId int64, position int) error {
+	return t.editChecklist(ctx, actorId, func(exec utils.Executor, items []ChecklistItem) error {
+		index, err := findItem(items, itemId)
+
+		if err != nil {
+			return err
+		}
+
+		if position < 0 || position >= len(items) {
+			return utils.ValidationErrors{{Field: "position", Message: fmt.Sprintf("must be between 0 and %d", len(items)-1)}}
+		}
+
+		item := items[index]
+		items = append(items[:index], items[index+1:]...)
+		items = append(items[:position], append([]ChecklistItem{item}, items[position:]...)...)
+
+		return renumber(ctx, exec, items)
+	})
+}
+
+func (t *Task) RemoveItem(actorId int64, itemId int64) error {
+	return t.RemoveItemContext(context.Background(), actorId, itemId)
+}
+
+func (t *Task) RemoveItemContext(ctx context.Context, actorId int64, itemId int64) error {
+	return t.editChecklist(ctx, actorId, func(exec utils.Executor, items []ChecklistItem) error {
+		index, err := findItem(items, itemId)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = exec.ExecContext(ctx, "DELETE FROM checklist_items WHERE id = ?", itemId)
+
+		if err != nil {
+			return err
+		}
+
+		return renumber(ctx, exec, append(items[:index], items[index+1:]...))
+	})
+}
+
+func findItem(items []ChecklistItem, itemId int64) (int, error) {
+	for i, item := range items {
+		if item.Id == itemId {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %d", ErrItemNotFound, itemId)
+}
+
+func renumber(ctx context.Context, exec utils.Executor, items []ChecklistItem) error {
+	for i, item := range items {
+		if item.Position == i {
+			continue
+		}
+
+		_, err := exec.ExecContext(ctx, "UPDATE checklist_items SET position = ? WHERE id = ?", i, item.Id)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// editChecklist runs edit on the items of the stored task in a transaction
+// once actorId is allowed to write the task, then reloads the task and
+// completes it when AutoComplete is set and every item is checked.
+func (t *Task) editChecklist(ctx context.Context, actorId int64, edit func(exec utils.Executor, items []ChecklistItem) error) (err error) {
+	tx, err := utils.SqliteInstance.BeginTx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil && !tx.Committed {
+			tx.Rollback()
+		}
+	}()
+
+	stored, err := getTask(ctx, tx, t.Id)
+
+	if err != nil {
+		return err
+	}
+
+	err = authorize(ctx, tx, actorId, stored, permission.WRITE_ACTION)
+
+	if err != nil {
+		return err
+	}
+
+	items, err := checklist(ctx, tx, t.Id)
+
+	if err != nil {
+		return err
+	}
+
+	err = edit(tx, items)
+
+	if err != nil {
+		return err
+	}
+
+	stored, err = getTask(ctx, tx, t.Id)
+
+	if err != nil {
+		return err
+	}
+
+	if stored.AutoComplete && !stored.Completed && stored.ChecklistItems > 0 && stored.ChecklistChecked == stored.ChecklistItems {
+		stored.Completed = true
+
+		err = stored.SaveWith(ctx, tx, actorId)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return err
+	}
+
+	*t = stored
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package task
+
+import (
+	"errors"
+	"testing"
+	"todolist/events"
+	"todolist/permission"
+	"todolist/utils"
+)
+
+func TestChecklist(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := NewTask(TASK_NAME)
+	task.UserId = 1
+	task.Save()
+
+	if _, err := task.AddItem(1, " "); err == nil {
+		t.Error("Empty item should return an error")
+	}
+	if _, err := task.AddItem(2, "Stranger"); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Stranger should not add items but got", err)
+	}
+
+	var ids []int64
+
+	for _, text := range []string{"First", "Second", "Third"} {
+		item, err := task.AddItem(1, text)
+
+		if err != nil {
+			t.Fatal("Error should be nil but got", err)
+		}
+
+		ids = append(ids, item.Id)
+	}
+
+	if task.ChecklistItems != 3 || task.ChecklistChecked != 0 {
+		t.Error("Counts should be 3 and 0 but got", task.ChecklistItems, task.ChecklistChecked)
+	}
+
+	if err := task.MoveItem(1, ids[2], 0); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if err := task.MoveItem(1, ids[2], 3); err == nil {
+		t.Error("Moving past the end should return an error")
+	}
+
+	items, _ := task.Checklist()
+	expected := []string{"Third", "First", "Second"}
+
+	for i, item := range items {
+		if item.Text != expected[i] || item.Position != i {
+			t.Error("Item", i, "should be", expected[i], "but got", item.Text, item.Position)
+		}
+	}
+
+	if err := task.ToggleItem(1, ids[0]); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if task.ChecklistChecked != 1 || task.Progress() != 33 {
+		t.Error("Progress should be", 33, "but got", task.Progress())
+	}
+	if err := task.ToggleItem(1, 999); !errors.Is(err, ErrItemNotFound) {
+		t.Error("Unknown item should return", ErrItemNotFound, "but got", err)
+	}
+
+	if err := task.RemoveItem(1, ids[2]); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	items, _ = task.Checklist()
+
+	if len(items) != 2 || items[0].Text != "First" || items[1].Position != 1 {
+		t.Error("Items should be renumbered but got", items)
+	}
+
+	stored := GetTask(task.Id)
+
+	if stored.ChecklistItems != 2 || stored.ChecklistChecked != 1 {
+		t.Error("Counts should be loaded with the task but got", stored.ChecklistItems, stored.ChecklistChecked)
+	}
+}
+
+func TestChecklistAutoComplete(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	var completed []events.TaskCompleted
+	events.Subscribe(events.TASK_COMPLETED, func(event events.Event) error {
+		completed = append(completed, event.(events.TaskCompleted))
+		return nil
+	})
+	defer events.BusInstance.Reset()
+
+	task := NewTask(TASK_NAME)
+	task.UserId = 1
+	task.AutoComplete = true
+	task.Save()
+
+	first, _ := task.AddItem(1, "First")
+	second, _ := task.AddItem(1, "Second")
+
+	task.ToggleItem(1, first.Id)
+
+	if task.Completed {
+		t.Error("Task should not be completed before every item is checked")
+	}
+
+	task.ToggleItem(1, second.Id)
+
+	if !task.Completed || len(completed) != 1 || completed[0].TaskId != task.Id {
+		t.Error("Task should be completed once but got", task.Completed, completed)
+	}
+
+	stored := GetTask(task.Id)
+
+	if !stored.Completed {
+		t.Error("Completion should be saved")
+	}
+
+	// unchecking does not reopen the task
+	task.ToggleItem(1, second.Id)
+
+	if !task.Completed || len(completed) != 1 {
+		t.Error("Task should stay completed but got", task.Completed, completed)
+	}
+}
@@ -0,0 +1,226 @@
+package task
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"todolist/preferences"
+	"todolist/utils"
+)
+
+const (
+	OPEN_STATE      = "open"
+	COMPLETED_STATE = "completed"
+)
+
+// Filter is a parsed filter expression, a task matches when it matches
+// every term of the expression.
+type Filter struct {
+	Expression string
+	terms      []filterTerm
+}
+
+type filterTerm struct {
+	key      string
+	value    string
+	negate   bool
+	priority int
+	atLeast  bool
+	id       int64
+}
+
+// ParseFilter reads an expression made of terms separated by spaces, values
+// with spaces are double quoted:
+//
+//	#work             the task has the label work
+//	!high, !3         the priority is high, !high+ is high or above
+//	@office           the location contains office
+//	is:open           the task is open, is:completed completed
+//	status:"in progress"
+//	list:3            the task is in the list 3, list:none in no list
+//	assignee:2        the task is assigned to the user 2, assignee:none to nobody
+//	view:today        the task is in one of the smart views
+//	report            the name contains report
+//
+// A term prefixed with - must not match, e.g. -#home. A term with another
+// key, e.g. label:work, is an error rather than a search of the name.
+func ParseFilter(expression string) (Filter, error) {
+	var errs utils.ValidationErrors
+
+	filter := Filter{Expression: expression}
+	words, err := splitFilter(expression)
+
+	if err != nil {
+		return Filter{}, utils.ValidationErrors{{Field: "filter", Message: err.Error()}}
+	}
+
+	for _, word := range words {
+		term, err := parseTerm(word)
+
+		if err != nil {
+			errs.Add("filter", err.Error())
+			continue
+		}
+
+		filter.terms = append(filter.terms, term)
+	}
+
+	if len(errs) > 0 {
+		return Filter{}, errs
+	}
+
+	return filter, nil
+}
+
+// splitFilter splits expression on the spaces outside double quotes and
+// removes the quotes.
+func splitFilter(expression string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+
+	quoted, started := false, false
+
+	for _, r := range expression {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			started = true
+		case r == ' ' && !quoted:
+			if started {
+				words = append(words, word.String())
+			}
+
+			word.Reset()
+			started = false
+		default:
+			word.WriteRune(r)
+			started = true
+		}
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in %q", expression)
+	}
+
+	if started {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+func parseTerm(word string) (filterTerm, error) {
+	var term filterTerm
+	var err error
+
+	if len(word) > 1 && word[0] == '-' {
+		term.negate = true
+		word = word[1:]
+	}
+
+	key, value, found := strings.Cut(word, ":")
+
+	switch {
+	case len(word) > 1 && word[0] == '#':
+		term.key, term.value = "label", strings.ToLower(word[1:])
+	case len(word) > 1 && word[0] == '@':
+		term.key, term.value = "location", strings.ToLower(word[1:])
+	case len(word) > 1 && word[0] == '!':
+		term.key, term.value = "priority", strings.TrimSuffix(word[1:], "+")
+		term.atLeast = strings.HasSuffix(word, "+")
+		term.priority, err = ParsePriority(term.value)
+
+		if err != nil {
+			return term, fmt.Errorf("%q is not a priority", word)
+		}
+	case found && key == "is":
+		term.key, term.value = key, strings.ToLower(value)
+
+		if term.value != OPEN_STATE && term.value != COMPLETED_STATE {
+			return term, fmt.Errorf("%q must be is:%s or is:%s", word, OPEN_STATE, COMPLETED_STATE)
+		}
+	case found && key == "status":
+		term.key, term.value = key, strings.ToLower(value)
+	case found && (key == "list" || key == "assignee"):
+		term.key, term.value = key, strings.ToLower(value)
+
+		if term.value != "none" {
+			term.id, err = strconv.ParseInt(value, 10, 64)
+
+			if err != nil || term.id <= 0 {
+				return term, fmt.Errorf("%q must be %s:<id> or %s:none", word, key, key)
+			}
+		}
+	case found && key == "view":
+		term.key, term.value = key, strings.ToLower(value)
+
+		if !IsView(term.value) {
+			return term, fmt.Errorf("%q must be one of the views %s", word, strings.Join(VIEWS, ", "))
+		}
+	case found && key != "":
+		return term, fmt.Errorf("%q has an unknown key, expected one of is, status, list, assignee, view", word)
+	default:
+		term.key, term.value = "name", strings.ToLower(word)
+	}
+
+	return term, nil
+}
+
+// Match tells whether the task matches every term at now, the days of the
+// view terms follow the timezone of p.
+func (f *Filter) Match(t *Task, now time.Time, p preferences.Preferences) bool {
+	for _, term := range f.terms {
+		if term.match(t, now, p) == term.negate {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (term *filterTerm) match(t *Task, now time.Time, p preferences.Preferences) bool {
+	switch term.key {
+	case "label":
+		for _, label := range SplitLabels(t.Label) {
+			if strings.ToLower(label) == term.value {
+				return true
+			}
+		}
+
+		return false
+	case "location":
+		return strings.Contains(strings.ToLower(t.Location), term.value)
+	case "priority":
+		if term.atLeast {
+			return t.Priority >= term.priority
+		}
+
+		return t.Priority == term.priority
+	case "is":
+		return t.Completed == (term.value == COMPLETED_STATE)
+	case "status":
+		return strings.ToLower(t.Status) == term.value
+	case "list":
+		return t.ListId == term.id
+	case "assignee":
+		return t.AssigneeId == term.id
+	case "view":
+		return t.InView(term.value, now, p)
+	}
+
+	return strings.Contains(strings.ToLower(t.Name), term.value)
+}
+
+// FilterTasks returns the tasks matching the filter, in their order.
+func FilterTasks(tasks []Task, f Filter, now time.Time, p preferences.Preferences) []Task {
+	filtered := []Task{}
+
+	for i := range tasks {
+		if f.Match(&tasks[i], now, p) {
+			filtered = append(filtered, tasks[i])
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,69 @@
+package task
+
+import (
+	"testing"
+	"time"
+	"todolist/preferences"
+)
+
+func TestFilter(t *testing.T) {
+	p := preferences.Default()
+	p.Timezone = "UTC"
+	now := time.Date(2026, time.March, 3, 9, 0, 0, 0, time.UTC)
+
+	tasks := []Task{
+		{Id: 1, Name: "Write report", Label: "work,urgent", Priority: HIGH_PRIORITY, ListId: 2, Status: IN_PROGRESS_STATUS, EndDate: now},
+		{Id: 2, Name: "Buy milk", Label: "home", Location: "Grocery store", Priority: LOW_PRIORITY},
+		{Id: 3, Name: "Report taxes", Label: "Home", Priority: CRITICAL_PRIORITY, AssigneeId: 4, Completed: true},
+	}
+
+	cases := []struct {
+		expression string
+		expected   []int64
+	}{
+		{"", []int64{1, 2, 3}},
+		{"#home", []int64{2, 3}},
+		{"-#home", []int64{1}},
+		{"report", []int64{1, 3}},
+		{"!high", []int64{1}},
+		{"!high+", []int64{1, 3}},
+		{"!1", []int64{2}},
+		{"@grocery", []int64{2}},
+		{"is:open #home", []int64{2}},
+		{"is:completed", []int64{3}},
+		{`status:"in progress"`, []int64{1}},
+		{"list:2", []int64{1}},
+		{"list:none", []int64{2, 3}},
+		{"assignee:4", []int64{3}},
+		{"-assignee:none", []int64{3}},
+		{"view:today", []int64{1}},
+	}
+
+	for _, c := range cases {
+		filter, err := ParseFilter(c.expression)
+
+		if err != nil {
+			t.Fatal(c.expression, "error should be nil but got", err)
+		}
+
+		filtered := FilterTasks(tasks, filter, now, p)
+
+		if len(filtered) != len(c.expected) {
+			t.Error("Filter", c.expression, "should match", c.expected, "but got", filtered)
+			continue
+		}
+
+		for i, task := range filtered {
+			if task.Id != c.expected[i] {
+				t.Error("Filter", c.expression, "should match", c.expected, "but got", filtered)
+				break
+			}
+		}
+	}
+
+	for _, expression := range []string{"!urgent", "is:late", "list:x", "view:tomorrow", `status:"open`, "label:work", "-due:today"} {
+		if _, err := ParseFilter(expression); err == nil {
+			t.Error("Filter", expression, "should return an error")
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package task
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"todolist/utils"
+)
+
+// the units of Task.Estimate, an empty unit is read as minutes
+const (
+	MINUTES_UNIT = "minutes"
+	POINTS_UNIT  = "points"
+)
+
+const (
+	MAX_PLAN_DAYS = 90
+)
+
+// Capacity is the effort a user plans per day, points are converted to
+// minutes with PointMinutes.
+type Capacity struct {
+	DailyMinutes int `json:"daily_minutes"`
+	PointMinutes int `json:"point_minutes"`
+}
+
+// Slot is the part of a task planned on a day.
+type Slot struct {
+	TaskId  int64     `json:"task_id"`
+	Name    string    `json:"name"`
+	Minutes int       `json:"minutes"`
+	EndDate time.Time `json:"end_date"`
+}
+
+// PlanDay is overcommitted when more is planned than the capacity, which
+// happens when tasks would miss their end date otherwise.
+type PlanDay struct {
+	Date          time.Time `json:"date"`
+	Capacity      int       `json:"capacity"`
+	Planned       int       `json:"planned"`
+	Slots         []Slot    `json:"slots"`
+	Overcommitted bool      `json:"overcommitted"`
+}
+
+// Plan spreads the open tasks over the upcoming days. Deferred holds the
+// effort that does not fit before the last day and Unestimated the tasks
+// without an estimate, which are not planned.
+type Plan struct {
+	Days          []PlanDay `json:"days"`
+	Deferred      []Slot    `json:"deferred"`
+	Unestimated   []Task    `json:"unestimated"`
+	Overcommitted bool      `json:"overcommitted"`
+}
+
+// Effort returns the estimate of the task in minutes.
+func (t *Task) Effort(pointMinutes int) int {
+	if t.EstimateUnit == POINTS_UNIT {
+		return t.Estimate * pointMinutes
+	}
+
+	return t.Estimate
+}
+
+// FormatEstimate returns the estimate as 90m or 3pt, "" without estimate.
+func (t *Task) FormatEstimate() string {
+	if t.Estimate == 0 {
+		return ""
+	}
+
+	if t.EstimateUnit == POINTS_UNIT {
+		return fmt.Sprintf("%dpt", t.Estimate)
+	}
+
+	return fmt.Sprintf("%dm", t.Estimate)
+}
+
+// ParseEstimate reads an estimate written in points, 3pt, or as a
+// duration, 90m or 1h30m, where a bare number is minutes. It returns the
+// value and its unit.
+func ParseEstimate(value string) (int, string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	for _, suffix := range []string{"points", "pts", "pt"} {
+		if strings.HasSuffix(value, suffix) {
+			points, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(value, suffix)))
+
+			if err != nil || points < 0 {
+				return 0, "", fmt.Errorf("Invalid estimate %q, e.g. 3pt or 1h30m", value)
+			}
+
+			return points, POINTS_UNIT, nil
+		}
+	}
+
+	if minutes, err := strconv.Atoi(value); err == nil && minutes >= 0 {
+		return minutes, MINUTES_UNIT, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+
+	if err != nil || duration < 0 || duration%time.Minute != 0 {
+		return 0, "", fmt.Errorf("Invalid estimate %q, e.g. 3pt or 1h30m", value)
+	}
+
+	return int(duration / time.Minute), MINUTES_UNIT, nil
+}
+
+// PlanTasks schedules the open tasks over days days from the day of now in
+// loc. Tasks are taken by end date, then by priority, the highest first,
+// then by begin date, and each one fills the free capacity from its begin
+// date on, over several days when needed. The effort left when the end date
+// is reached is put on that day even when it overcommits it.
+func PlanTasks(tasks []Task, now time.Time, days int, capacity Capacity, loc *time.Location) (Plan, error) {
+	var errs utils.ValidationErrors
+
+	if days < 1 || days > MAX_PLAN_DAYS {
+		errs.Add("days", fmt.Sprintf("must be between 1 and %d", MAX_PLAN_DAYS))
+	}
+
+	if capacity.DailyMinutes <= 0 {
+		errs.Add("daily_capacity", "must be set to plan")
+	}
+
+	if len(errs) > 0 {
+		return Plan{}, errs
+	}
+
+	plan := Plan{Deferred: []Slot{}, Unestimated: []Task{}}
+	today := utils.StartOfDay(now, loc)
+
+	for i := 0; i < days; i++ {
+		plan.Days = append(plan.Days, PlanDay{Date: today.AddDate(0, 0, i), Capacity: capacity.DailyMinutes, Slots: []Slot{}})
+	}
+
+	var open []Task
+
+	for _, task := range tasks {
+		if task.Completed || task.IsDeleted() {
+			continue
+		}
+
+		if task.Estimate == 0 {
+			plan.Unestimated = append(plan.Unestimated, task)
+			continue
+		}
+
+		open = append(open, task)
+	}
+
+	sort.SliceStable(open, func(i, j int) bool {
+		a, b := open[i], open[j]
+
+		if !a.EndDate.Equal(b.EndDate) {
+			return !a.EndDate.IsZero() && (b.EndDate.IsZero() || a.EndDate.Before(b.EndDate))
+		}
+
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+
+		if !a.BeginDate.Equal(b.BeginDate) {
+			return a.BeginDate.Before(b.BeginDate)
+		}
+
+		return a.Id < b.Id
+	})
+
+	for _, task := range open {
+		remaining := task.Effort(capacity.PointMinutes)
+		slot := Slot{TaskId: task.Id, Name: task.Name, EndDate: task.EndDate}
+
+		start := 0
+
+		if !task.BeginDate.IsZero() {
+			start = max(0, daysBetween(today, task.BeginDate, loc))
+		}
+
+		// the day the task is due, -1 when it is due after the plan
+		due := -1
+
+		if !task.EndDate.IsZero() {
+			due = max(start, daysBetween(today, task.EndDate, loc))
+
+			if due >= days {
+				due = -1
+			}
+		}
+
+		last := days - 1
+
+		if due >= 0 {
+			last = due
+		}
+
+		for i := start; i <= last && remaining > 0; i++ {
+			day := &plan.Days[i]
+			minutes := min(remaining, day.Capacity-day.Planned)
+
+			if minutes > 0 {
+				slot.Minutes = minutes
+				day.Slots = append(day.Slots, slot)
+				day.Planned += minutes
+				remaining -= minutes
+			}
+		}
+
+		if remaining == 0 {
+			continue
+		}
+
+		slot.Minutes = remaining
+
+		if due >= 0 {
+			day := &plan.Days[due]
+			day.Slots = append(day.Slots, slot)
+			day.Planned += remaining
+		} else {
+			plan.Deferred = append(plan.Deferred, slot)
+		}
+	}
+
+	for i := range plan.Days {
+		plan.Days[i].Overcommitted = plan.Days[i].Planned > plan.Days[i].Capacity
+		plan.Overcommitted = plan.Overcommitted || plan.Days[i].Overcommitted
+	}
+
+	return plan, nil
+}
+
+// daysBetween counts the calendar days from the day of from to the day of
+// to in loc, whatever the daylight saving changes in between.
+func daysBetween(from time.Time, to time.Time, loc *time.Location) int {
+	from, to = from.In(loc), to.In(loc)
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func min(a int, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
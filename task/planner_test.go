@@ -0,0 +1,139 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEffort(t *testing.T) {
+	task := Task{Estimate: 3, EstimateUnit: POINTS_UNIT}
+
+	if task.Effort(45) != 135 || task.FormatEstimate() != "3pt" {
+		t.Error("3 points should be 135 minutes but got", task.Effort(45), task.FormatEstimate())
+	}
+
+	task = Task{Estimate: 90}
+
+	if task.Effort(45) != 90 || task.FormatEstimate() != "90m" {
+		t.Error("Effort should be 90 minutes but got", task.Effort(45), task.FormatEstimate())
+	}
+
+	task = Task{Name: TASK_NAME, Estimate: -1, EstimateUnit: "hours"}
+
+	if errs := task.Validate(); len(errs) != 2 {
+		t.Error("Negative estimate and unknown unit should be invalid but got", errs)
+	}
+}
+
+func TestParseEstimate(t *testing.T) {
+	cases := []struct {
+		value    string
+		estimate int
+		unit     string
+	}{
+		{"90", 90, MINUTES_UNIT},
+		{"1h30m", 90, MINUTES_UNIT},
+		{"45m", 45, MINUTES_UNIT},
+		{"3pt", 3, POINTS_UNIT},
+		{"5 points", 5, POINTS_UNIT},
+		{"0", 0, MINUTES_UNIT},
+	}
+
+	for _, c := range cases {
+		estimate, unit, err := ParseEstimate(c.value)
+
+		if err != nil || estimate != c.estimate || unit != c.unit {
+			t.Error("Estimate of", c.value, "should be", c.estimate, c.unit, "but got", estimate, unit, err)
+		}
+	}
+
+	for _, value := range []string{"", "soon", "-1", "-2pt", "30s", "xpt"} {
+		if _, _, err := ParseEstimate(value); err == nil {
+			t.Error("Estimate", value, "should return an error")
+		}
+	}
+}
+
+func TestPlanTasks(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Paris")
+	now := time.Date(2026, time.March, 2, 9, 0, 0, 0, loc)
+	day := func(offset int) time.Time {
+		return now.AddDate(0, 0, offset)
+	}
+
+	tasks := []Task{
+		{Id: 1, Name: "Low", Estimate: 2, EstimateUnit: POINTS_UNIT},
+		{Id: 2, Name: "Urgent", Estimate: 180, Priority: 4},
+		{Id: 3, Name: "Due tomorrow", Estimate: 300, EndDate: day(1)},
+		{Id: 4, Name: "Later", Estimate: 60, BeginDate: day(2)},
+		{Id: 5, Name: "Overdue", Estimate: 120, EndDate: day(-3)},
+		{Id: 6, Name: "Unknown"},
+		{Id: 7, Name: "Done", Estimate: 60, Completed: true},
+		{Id: 8, Name: "Huge", Estimate: 2000},
+	}
+
+	plan, err := PlanTasks(tasks, now, 3, Capacity{DailyMinutes: 240, PointMinutes: 60}, loc)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	// Overdue then Due tomorrow fill the first two days, the priority puts
+	// Urgent before Low and Later waits for its begin date
+	expected := [][]Slot{
+		{{TaskId: 5, Minutes: 120}, {TaskId: 3, Minutes: 120}},
+		{{TaskId: 3, Minutes: 180}, {TaskId: 2, Minutes: 60}},
+		{{TaskId: 2, Minutes: 120}, {TaskId: 1, Minutes: 120}},
+	}
+
+	for i, slots := range expected {
+		if len(plan.Days[i].Slots) != len(slots) {
+			t.Fatal("Day", i, "should have", slots, "but got", plan.Days[i].Slots)
+		}
+		for j, slot := range slots {
+			if got := plan.Days[i].Slots[j]; got.TaskId != slot.TaskId || got.Minutes != slot.Minutes {
+				t.Error("Slot", j, "of day", i, "should be", slot.TaskId, slot.Minutes, "but got", got.TaskId, got.Minutes)
+			}
+		}
+	}
+
+	if plan.Days[1].Planned != 240 || plan.Days[1].Overcommitted {
+		t.Error("Day 1 should be full but got", plan.Days[1].Planned)
+	}
+	if !plan.Days[0].Date.Equal(time.Date(2026, time.March, 2, 0, 0, 0, 0, loc)) {
+		t.Error("First day should start at midnight but got", plan.Days[0].Date)
+	}
+	if len(plan.Deferred) != 2 || plan.Deferred[0].TaskId != 8 || plan.Deferred[0].Minutes != 2000 || plan.Deferred[1].TaskId != 4 {
+		t.Error("Later and Huge should be deferred but got", plan.Deferred)
+	}
+	if len(plan.Unestimated) != 1 || plan.Unestimated[0].Id != 6 {
+		t.Error("Unknown should be unestimated but got", plan.Unestimated)
+	}
+	if plan.Overcommitted {
+		t.Error("Plan should not be overcommitted")
+	}
+}
+
+func TestPlanOvercommitted(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	tasks := []Task{
+		{Id: 1, Name: "Report", Estimate: 300, EndDate: now},
+		{Id: 2, Name: "Slides", Estimate: 120, EndDate: now.AddDate(0, 0, 1)},
+	}
+
+	plan, _ := PlanTasks(tasks, now, 2, Capacity{DailyMinutes: 240, PointMinutes: 60}, time.UTC)
+
+	if !plan.Overcommitted || !plan.Days[0].Overcommitted || plan.Days[0].Planned != 300 {
+		t.Error("First day should be overcommitted with 300 minutes but got", plan.Days[0])
+	}
+	if plan.Days[1].Planned != 120 || plan.Days[1].Overcommitted {
+		t.Error("Second day should have 120 minutes but got", plan.Days[1])
+	}
+
+	if _, err := PlanTasks(tasks, now, 2, Capacity{}, time.UTC); err == nil {
+		t.Error("Planning without capacity should return an error")
+	}
+	if _, err := PlanTasks(tasks, now, 0, Capacity{DailyMinutes: 60}, time.UTC); err == nil {
+		t.Error("Planning no day should return an error")
+	}
+}
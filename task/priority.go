@@ -0,0 +1,248 @@
+package task
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"todolist/utils"
+)
+
+// the priority levels, stored as their number
+const (
+	NONE_PRIORITY = iota
+	LOW_PRIORITY
+	MEDIUM_PRIORITY
+	HIGH_PRIORITY
+	CRITICAL_PRIORITY
+)
+
+// PRIORITIES names the levels, indexed by their number.
+var PRIORITIES = []string{"none", "low", "medium", "high", "critical"}
+
+const (
+	URGENCY_SORT   = "urgency"
+	PRIORITY_SORT  = "priority"
+	DUE_SORT       = "due"
+	CREATED_SORT   = "created"
+	COMPLETED_SORT = "completed"
+	ID_SORT        = "id"
+)
+
+// SORTS lists the orders of the listings, the first one is the default.
+var SORTS = []string{URGENCY_SORT, PRIORITY_SORT, DUE_SORT, CREATED_SORT, COMPLETED_SORT, ID_SORT}
+
+// the coefficients of the urgency, after Taskwarrior: each term is a factor
+// between 0 and 1 multiplied by its coefficient
+const (
+	URGENCY_DUE     = 12.0
+	URGENCY_AGE     = 2.0
+	URGENCY_TAGS    = 1.0
+	URGENCY_BLOCKED = -5.0
+
+	// a task gets the whole age coefficient after a year
+	URGENCY_MAX_AGE = 365 * 24 * time.Hour
+)
+
+// PRIORITY_URGENCY is the urgency of each priority level.
+var PRIORITY_URGENCY = []float64{0, 1.8, 3.9, 6.0, 9.0}
+
+// PriorityName returns the name of the level, the number when it is out of
+// range.
+func PriorityName(priority int) string {
+	if priority < MIN_PRIORITY || priority > MAX_PRIORITY {
+		return strconv.Itoa(priority)
+	}
+
+	return PRIORITIES[priority]
+}
+
+// ParsePriority reads a level by name, e.g. "high", or by number, e.g. "3".
+func ParsePriority(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	for priority, name := range PRIORITIES {
+		if value == name {
+			return priority, nil
+		}
+	}
+
+	priority, err := strconv.Atoi(value)
+
+	if err != nil || priority < MIN_PRIORITY || priority > MAX_PRIORITY {
+		return 0, fmt.Errorf("Invalid priority %q, expected one of %s", value, strings.Join(PRIORITIES, ", "))
+	}
+
+	return priority, nil
+}
+
+// Urgency scores how much the task needs attention at now: the sooner it is
+// due the higher, overdue tasks get the whole due coefficient after a week
+// and tasks due in more than two weeks a fifth of it. Older tasks and tasks
+// with labels get a little more, blocked tasks less: a task is blocked when
+// its status is literally named BLOCKED_STATUS, whatever its workflow.
+func (t *Task) Urgency(now time.Time) float64 {
+	urgency := 0.0
+
+	if t.Priority >= MIN_PRIORITY && t.Priority <= MAX_PRIORITY {
+		urgency += PRIORITY_URGENCY[t.Priority]
+	}
+
+	if !t.EndDate.IsZero() {
+		overdue := now.Sub(t.EndDate).Hours() / 24
+		due := 1.0
+
+		if overdue < -14 {
+			due = 0.2
+		} else if overdue < 7 {
+			due = (overdue+14)*0.8/21 + 0.2
+		}
+
+		urgency += URGENCY_DUE * due
+	}
+
+	if !t.CreatedAt.IsZero() && now.After(t.CreatedAt) {
+		urgency += URGENCY_AGE * math.Min(float64(now.Sub(t.CreatedAt))/float64(URGENCY_MAX_AGE), 1)
+	}
+
+	switch labels := len(SplitLabels(t.Label)); {
+	case labels == 1:
+		urgency += URGENCY_TAGS * 0.8
+	case labels == 2:
+		urgency += URGENCY_TAGS * 0.9
+	case labels > 2:
+		urgency += URGENCY_TAGS
+	}
+
+	if t.Status == BLOCKED_STATUS {
+		urgency += URGENCY_BLOCKED
+	}
+
+	return math.Round(urgency*100) / 100
+}
+
+// SortTasks orders tasks in place by one of SORTS. By urgency and priority
+// the open tasks come first, by due date the tasks without one come last, by
+// completion the most recently completed come first, ties keep the order of
+// the ids.
+func SortTasks(tasks []Task, by string, now time.Time) error {
+	var compare func(a int, b int) float64
+
+	switch by {
+	case URGENCY_SORT, "":
+		// the urgencies follow the positions, the tasks of several lists
+		// or not saved yet may share an id
+		urgencies := make([]float64, len(tasks))
+
+		for i := range tasks {
+			urgencies[i] = tasks[i].Urgency(now)
+		}
+
+		compare = func(a int, b int) float64 {
+			if tasks[a].Completed != tasks[b].Completed {
+				return compareBool(tasks[a].Completed, tasks[b].Completed)
+			}
+
+			return urgencies[b] - urgencies[a]
+		}
+	case PRIORITY_SORT:
+		compare = func(a int, b int) float64 {
+			if tasks[a].Completed != tasks[b].Completed {
+				return compareBool(tasks[a].Completed, tasks[b].Completed)
+			}
+
+			return float64(tasks[b].Priority - tasks[a].Priority)
+		}
+	case DUE_SORT:
+		compare = func(a int, b int) float64 {
+			if tasks[a].EndDate.IsZero() != tasks[b].EndDate.IsZero() {
+				return compareBool(tasks[a].EndDate.IsZero(), tasks[b].EndDate.IsZero())
+			}
+
+			return float64(tasks[a].EndDate.Sub(tasks[b].EndDate))
+		}
+	case CREATED_SORT:
+		compare = func(a int, b int) float64 {
+			return float64(tasks[a].CreatedAt.Sub(tasks[b].CreatedAt))
+		}
+	case COMPLETED_SORT:
+		compare = func(a int, b int) float64 {
+			if tasks[a].CompletedAt.IsZero() != tasks[b].CompletedAt.IsZero() {
+				return compareBool(tasks[a].CompletedAt.IsZero(), tasks[b].CompletedAt.IsZero())
+			}
+
+			return float64(tasks[b].CompletedAt.Sub(tasks[a].CompletedAt))
+		}
+	case ID_SORT:
+		compare = func(a int, b int) float64 {
+			return 0
+		}
+	default:
+		return utils.ValidationErrors{{Field: "sort", Message: "must be one of " + strings.Join(SORTS, ", ")}}
+	}
+
+	// the positions are sorted rather than the tasks so the comparisons
+	// keep reading the values computed for them
+	order := make([]int, len(tasks))
+
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if result := compare(order[i], order[j]); result != 0 {
+			return result < 0
+		}
+
+		return tasks[order[i]].Id < tasks[order[j]].Id
+	})
+
+	sorted := make([]Task, len(tasks))
+
+	for i, position := range order {
+		sorted[i] = tasks[position]
+	}
+
+	copy(tasks, sorted)
+
+	return nil
+}
+
+func IsSort(name string) bool {
+	for _, sort := range SORTS {
+		if name == sort {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compareBool orders false before true.
+func compareBool(a bool, b bool) float64 {
+	if a == b {
+		return 0
+	}
+
+	if a {
+		return 1
+	}
+
+	return -1
+}
+
+// SplitLabels returns the labels of a comma separated list, trimmed and
+// without the empty ones.
+func SplitLabels(label string) []string {
+	var labels []string
+
+	for _, name := range strings.Split(label, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			labels = append(labels, name)
+		}
+	}
+
+	return labels
+}
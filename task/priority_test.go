@@ -0,0 +1,111 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePriority(t *testing.T) {
+	cases := []struct {
+		value    string
+		priority int
+	}{
+		{"none", NONE_PRIORITY},
+		{"Low", LOW_PRIORITY},
+		{" medium ", MEDIUM_PRIORITY},
+		{"3", HIGH_PRIORITY},
+		{"critical", CRITICAL_PRIORITY},
+	}
+
+	for _, c := range cases {
+		priority, err := ParsePriority(c.value)
+
+		if err != nil || priority != c.priority {
+			t.Error("Priority of", c.value, "should be", c.priority, "but got", priority, err)
+		}
+	}
+
+	for _, value := range []string{"", "urgent", "5", "-1"} {
+		if _, err := ParsePriority(value); err == nil {
+			t.Error("Priority", value, "should return an error")
+		}
+	}
+
+	if PriorityName(HIGH_PRIORITY) != "high" || PriorityName(9) != "9" {
+		t.Error("Names should be high and 9 but got", PriorityName(HIGH_PRIORITY), PriorityName(9))
+	}
+}
+
+func TestUrgency(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		task     Task
+		expected float64
+	}{
+		{"nothing", Task{}, 0},
+		{"critical", Task{Priority: CRITICAL_PRIORITY}, 9},
+		{"overdue for a week", Task{EndDate: now.AddDate(0, 0, -7)}, 12},
+		{"due now", Task{EndDate: now}, 12 * (14*0.8/21 + 0.2)},
+		{"due in a month", Task{EndDate: now.AddDate(0, 1, 0)}, 2.4},
+		{"half a year old", Task{CreatedAt: now.Add(-URGENCY_MAX_AGE / 2)}, 1},
+		{"two labels", Task{Label: "work, urgent"}, 0.9},
+		{"blocked", Task{Priority: HIGH_PRIORITY, Status: BLOCKED_STATUS}, 1},
+	}
+
+	for _, c := range cases {
+		urgency := c.task.Urgency(now)
+
+		if urgency < c.expected-0.01 || urgency > c.expected+0.01 {
+			t.Error("Urgency of", c.name, "should be", c.expected, "but got", urgency)
+		}
+	}
+}
+
+func TestSortTasks(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	tasks := []Task{
+		{Id: 1, Name: "Done", Completed: true, Priority: CRITICAL_PRIORITY},
+		{Id: 2, Name: "Someday", CreatedAt: now.AddDate(0, 0, -1)},
+		{Id: 3, Name: "Overdue", EndDate: now.AddDate(0, 0, -1), CreatedAt: now.AddDate(0, 0, -3)},
+		{Id: 4, Name: "High", Priority: HIGH_PRIORITY, CreatedAt: now.AddDate(0, 0, -2)},
+	}
+
+	cases := []struct {
+		by       string
+		expected []int64
+	}{
+		{"", []int64{3, 4, 2, 1}},
+		{PRIORITY_SORT, []int64{4, 2, 3, 1}},
+		{DUE_SORT, []int64{3, 1, 2, 4}},
+		{CREATED_SORT, []int64{1, 3, 4, 2}},
+		{ID_SORT, []int64{1, 2, 3, 4}},
+	}
+
+	for _, c := range cases {
+		err := SortTasks(tasks, c.by, now)
+
+		if err != nil {
+			t.Fatal("Error should be nil but got", err)
+		}
+
+		for i, task := range tasks {
+			if task.Id != c.expected[i] {
+				t.Error("Order by", c.by, "should be", c.expected, "but got", task.Id, "at", i)
+				break
+			}
+		}
+	}
+
+	if err := SortTasks(tasks, "name", now); err == nil {
+		t.Error("Unknown sort should return an error")
+	}
+
+	drafts := []Task{{Name: "Later"}, {Name: "Urgent", Priority: CRITICAL_PRIORITY}, {Name: "Soon", Priority: LOW_PRIORITY}}
+	SortTasks(drafts, URGENCY_SORT, now)
+
+	if drafts[0].Name != "Urgent" || drafts[1].Name != "Soon" || drafts[2].Name != "Later" {
+		t.Error("Tasks without id should be ordered by urgency but got", drafts)
+	}
+}
@@ -0,0 +1,487 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+	"todolist/preferences"
+)
+
+const (
+	TEXT_FORMAT     = "text"
+	TABLE_FORMAT    = "table"
+	JSON_FORMAT     = "json"
+	YAML_FORMAT     = "yaml"
+	MARKDOWN_FORMAT = "markdown"
+	TEMPLATE_FORMAT = "template"
+)
+
+var FORMATS = []string{TEXT_FORMAT, TABLE_FORMAT, JSON_FORMAT, YAML_FORMAT, MARKDOWN_FORMAT, TEMPLATE_FORMAT}
+
+var COLUMNS = []string{"id", "completed", "status", "name", "description", "priority", "urgency", "location", "label", "begin_date", "end_date", "estimate", "assignee_id", "completed_at", "created_at", "updated_at"}
+
+var DEFAULT_COLUMNS = []string{"id", "completed", "name", "priority", "label", "end_date"}
+
+type Renderer interface {
+	Render(w io.Writer, tasks []Task) error
+	RenderDetails(w io.Writer, task Task) error
+}
+
+type TextRenderer struct {
+	Preferences preferences.Preferences
+}
+
+type TableRenderer struct {
+	Preferences preferences.Preferences
+	Columns     []string
+}
+
+type JSONRenderer struct{}
+
+type YAMLRenderer struct {
+	Preferences preferences.Preferences
+}
+
+type MarkdownRenderer struct {
+	Preferences preferences.Preferences
+}
+
+type TemplateRenderer struct {
+	Template *template.Template
+}
+
+// NewRenderer returns the renderer for format. The table and template
+// formats take an argument after "=": the comma separated columns, e.g.
+// "table=id,name,label", or the Go template, e.g. "template={{.Name}}".
+func NewRenderer(format string, p preferences.Preferences) (Renderer, error) {
+	name, arg, hasArg := strings.Cut(format, "=")
+
+	switch name {
+	case TEXT_FORMAT, "":
+		return &TextRenderer{Preferences: p}, nil
+	case TABLE_FORMAT:
+		columns := DEFAULT_COLUMNS
+
+		if hasArg {
+			columns = strings.Split(arg, ",")
+		}
+
+		return NewTableRenderer(columns, p)
+	case JSON_FORMAT:
+		return &JSONRenderer{}, nil
+	case YAML_FORMAT:
+		return &YAMLRenderer{Preferences: p}, nil
+	case MARKDOWN_FORMAT:
+		return &MarkdownRenderer{Preferences: p}, nil
+	case TEMPLATE_FORMAT:
+		if !hasArg {
+			return nil, fmt.Errorf("Missing template, use template=<text>")
+		}
+
+		return NewTemplateRenderer(arg, p)
+	}
+
+	return nil, fmt.Errorf("Unknown output format %s, expected one of %s", name, strings.Join(FORMATS, ", "))
+}
+
+func NewTableRenderer(columns []string, p preferences.Preferences) (*TableRenderer, error) {
+	var trimmed []string
+
+	for _, column := range columns {
+		column = strings.TrimSpace(column)
+
+		if !IsColumn(column) {
+			return nil, fmt.Errorf("Unknown column %s, expected one of %s", column, strings.Join(COLUMNS, ", "))
+		}
+
+		trimmed = append(trimmed, column)
+	}
+
+	return &TableRenderer{Preferences: p, Columns: trimmed}, nil
+}
+
+// NewTemplateRenderer parses text as a Go template executed once per task,
+// the functions date and t format a date and translate a key with p.
+func NewTemplateRenderer(text string, p preferences.Preferences) (*TemplateRenderer, error) {
+	funcs := template.FuncMap{
+		"date": func(date time.Time) string {
+			if date.IsZero() {
+				return ""
+			}
+
+			return p.FormatDate(date)
+		},
+		"t": p.T,
+	}
+
+	tmpl, err := template.New("task").Funcs(funcs).Parse(text)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid template: %w", err)
+	}
+
+	return &TemplateRenderer{Template: tmpl}, nil
+}
+
+// IsColumn tells whether column is one of COLUMNS.
+func IsColumn(column string) bool {
+	for _, c := range COLUMNS {
+		if c == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Column returns the value of column formatted for display, dates follow
+// the timezone and format of p.
+func (t *Task) Column(column string, p preferences.Preferences) string {
+	switch column {
+	case "id":
+		return strconv.FormatInt(t.Id, 10)
+	case "completed":
+		if t.Completed {
+			return "x"
+		}
+		return ""
+	case "status":
+		return t.Status
+	case "name":
+		return t.Name
+	case "description":
+		return t.Description
+	case "priority":
+		if t.Priority == NONE_PRIORITY {
+			return ""
+		}
+		return p.T(PriorityName(t.Priority))
+	case "urgency":
+		return strconv.FormatFloat(t.Urgency(time.Now()), 'f', 1, 64)
+	case "location":
+		return t.Location
+	case "label":
+		return t.Label
+	case "begin_date":
+		return displayDate(t.BeginDate, p)
+	case "end_date":
+		return displayDate(t.EndDate, p)
+	case "estimate":
+		return t.FormatEstimate()
+	case "assignee_id":
+		if t.AssigneeId == 0 {
+			return ""
+		}
+		return strconv.FormatInt(t.AssigneeId, 10)
+	case "completed_at":
+		return displayDate(t.CompletedAt, p)
+	case "created_at":
+		return displayDate(t.CreatedAt, p)
+	case "updated_at":
+		return displayDate(t.UpdatedAt, p)
+	}
+
+	return ""
+}
+
+func displayDate(date time.Time, p preferences.Preferences) string {
+	if date.IsZero() {
+		return ""
+	}
+
+	return p.FormatDate(date)
+}
+
+func (r *TextRenderer) Render(w io.Writer, tasks []Task) error {
+	for _, t := range tasks {
+		completed := " "
+
+		if t.Completed {
+			completed = "x"
+		}
+
+		due := ""
+
+		if !t.EndDate.IsZero() {
+			due = " (" + fmt.Sprintf(r.Preferences.T("due"), r.Preferences.FormatDate(t.EndDate)) + ")"
+		}
+
+		progress := ""
+
+		if t.ChecklistItems > 0 {
+			progress = " (" + fmt.Sprintf(r.Preferences.T("progress"), t.Progress()) + ")"
+		}
+
+		comments := ""
+
+		if t.Comments == 1 {
+			comments = " (" + r.Preferences.T("comment") + ")"
+		} else if t.Comments > 1 {
+			comments = " (" + fmt.Sprintf(r.Preferences.T("comments"), t.Comments) + ")"
+		}
+
+		_, err := fmt.Fprintf(w, "[%s] [%d] %s%s%s%s\n", completed, t.Id, t.Name, due, progress, comments)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RenderDetails writes every field set on the task, one per line.
+func (r *TextRenderer) RenderDetails(w io.Writer, t Task) error {
+	p := r.Preferences
+	fields := [][2]string{
+		{"id", strconv.FormatInt(t.Id, 10)},
+		{"name", t.Name},
+		{"completed", p.T(strconv.FormatBool(t.Completed))},
+	}
+
+	for _, column := range []string{"status", "description", "priority", "location", "label", "begin_date", "end_date", "estimate"} {
+		value := t.Column(column, p)
+
+		if value != "" {
+			fields = append(fields, [2]string{column, value})
+		}
+	}
+
+	if t.ChecklistItems > 0 {
+		fields = append(fields, [2]string{"checklist", fmt.Sprintf("%d/%d (%d%%)", t.ChecklistChecked, t.ChecklistItems, t.Progress())})
+	}
+
+	for _, field := range fields {
+		_, err := fmt.Fprintf(w, "%-13s %s\n", p.T(field[0])+":", field[1])
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *TableRenderer) Render(w io.Writer, tasks []Task) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	var header []string
+
+	for _, column := range r.Columns {
+		header = append(header, strings.ToUpper(r.Preferences.T(column)))
+	}
+
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, t := range tasks {
+		var row []string
+
+		for _, column := range r.Columns {
+			row = append(row, t.Column(column, r.Preferences))
+		}
+
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// RenderDetails writes the columns of the task as a two columns table.
+func (r *TableRenderer) RenderDetails(w io.Writer, t Task) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	for _, column := range r.Columns {
+		fmt.Fprintf(tw, "%s\t%s\n", strings.ToUpper(r.Preferences.T(column)), t.Column(column, r.Preferences))
+	}
+
+	return tw.Flush()
+}
+
+func (r *JSONRenderer) Render(w io.Writer, tasks []Task) error {
+	if tasks == nil {
+		tasks = []Task{}
+	}
+
+	return writeJSON(w, tasks)
+}
+
+func (r *JSONRenderer) RenderDetails(w io.Writer, t Task) error {
+	return writeJSON(w, t)
+}
+
+func writeJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(value)
+}
+
+func (r *YAMLRenderer) Render(w io.Writer, tasks []Task) error {
+	if len(tasks) == 0 {
+		_, err := fmt.Fprintln(w, "[]")
+		return err
+	}
+
+	for _, t := range tasks {
+		err := r.write(w, t, "- ", "  ")
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *YAMLRenderer) RenderDetails(w io.Writer, t Task) error {
+	return r.write(w, t, "", "")
+}
+
+// write emits the task as a YAML mapping, strings are always double quoted
+// so that no value is mistaken for another type.
+func (r *YAMLRenderer) write(w io.Writer, t Task, first string, indent string) error {
+	fields := [][2]string{
+		{"id", strconv.FormatInt(t.Id, 10)},
+		{"name", strconv.Quote(t.Name)},
+		{"completed", strconv.FormatBool(t.Completed)},
+		{"status", strconv.Quote(t.Status)},
+		{"description", strconv.Quote(t.Description)},
+		{"priority", strconv.Itoa(t.Priority)},
+		{"location", strconv.Quote(t.Location)},
+		{"label", strconv.Quote(t.Label)},
+		{"begin_date", r.date(t.BeginDate)},
+		{"end_date", r.date(t.EndDate)},
+		{"estimate", strconv.Itoa(t.Estimate)},
+		{"estimate_unit", strconv.Quote(t.EstimateUnit)},
+		{"user_id", strconv.FormatInt(t.UserId, 10)},
+		{"list_id", strconv.FormatInt(t.ListId, 10)},
+		{"assignee_id", strconv.FormatInt(t.AssigneeId, 10)},
+		{"auto_complete", strconv.FormatBool(t.AutoComplete)},
+		{"comments", strconv.Itoa(t.Comments)},
+		{"checklist_items", strconv.Itoa(t.ChecklistItems)},
+		{"checklist_checked", strconv.Itoa(t.ChecklistChecked)},
+		{"completed_at", r.date(t.CompletedAt)},
+		{"created_at", r.date(t.CreatedAt)},
+		{"updated_at", r.date(t.UpdatedAt)},
+	}
+
+	if t.DeletedAt != nil {
+		fields = append(fields, [2]string{"deleted_at", r.date(*t.DeletedAt)})
+	}
+
+	for i, field := range fields {
+		prefix := indent
+
+		if i == 0 {
+			prefix = first
+		}
+
+		_, err := fmt.Fprintf(w, "%s%s: %s\n", prefix, field[0], field[1])
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *YAMLRenderer) date(date time.Time) string {
+	if date.IsZero() {
+		return "null"
+	}
+
+	return date.In(r.Preferences.Location()).Format(time.RFC3339)
+}
+
+// Render writes the tasks as a Markdown checklist.
+func (r *MarkdownRenderer) Render(w io.Writer, tasks []Task) error {
+	for _, t := range tasks {
+		completed := " "
+
+		if t.Completed {
+			completed = "x"
+		}
+
+		line := fmt.Sprintf("- [%s] %s", completed, escapeMarkdown(t.Name))
+
+		if !t.EndDate.IsZero() {
+			line += " (" + fmt.Sprintf(r.Preferences.T("due"), r.Preferences.FormatDate(t.EndDate)) + ")"
+		}
+
+		for _, label := range strings.Split(t.Label, ",") {
+			if label = strings.TrimSpace(label); label != "" {
+				line += " `#" + label + "`"
+			}
+		}
+
+		_, err := fmt.Fprintln(w, line)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *MarkdownRenderer) RenderDetails(w io.Writer, t Task) error {
+	p := r.Preferences
+
+	fmt.Fprintf(w, "## %s\n\n", escapeMarkdown(t.Name))
+
+	if t.Description != "" {
+		fmt.Fprintf(w, "%s\n\n", t.Description)
+	}
+
+	fmt.Fprintf(w, "- **%s:** %d\n", p.T("id"), t.Id)
+	fmt.Fprintf(w, "- **%s:** %s\n", p.T("completed"), p.T(strconv.FormatBool(t.Completed)))
+
+	for _, column := range []string{"priority", "location", "label", "begin_date", "end_date", "estimate"} {
+		value := t.Column(column, p)
+
+		if value != "" {
+			_, err := fmt.Fprintf(w, "- **%s:** %s\n", p.T(column), escapeMarkdown(value))
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func escapeMarkdown(value string) string {
+	replacer := strings.NewReplacer("\\", "\\\\", "*", "\\*", "_", "\\_", "`", "\\`", "[", "\\[", "]", "\\]")
+	return replacer.Replace(value)
+}
+
+func (r *TemplateRenderer) Render(w io.Writer, tasks []Task) error {
+	for _, t := range tasks {
+		err := r.RenderDetails(w, t)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *TemplateRenderer) RenderDetails(w io.Writer, t Task) error {
+	err := r.Template.Execute(w, t)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w)
+
+	return err
+}
@@ -0,0 +1,173 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"todolist/preferences"
+)
+
+func renderFixtures() []Task {
+	return []Task{
+		{Id: 1, Name: "Buy milk", Label: "home,errands", EndDate: time.Date(2026, time.March, 10, 23, 30, 0, 0, time.UTC)},
+		{Id: 2, Name: "Write *report*", Completed: true, Priority: 3, Description: "Quarterly"},
+	}
+}
+
+func render(t *testing.T, format string, details bool) string {
+	p := preferences.Default()
+	p.Timezone = "Europe/Paris"
+
+	renderer, err := NewRenderer(format, p)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	var buffer bytes.Buffer
+
+	if details {
+		err = renderer.RenderDetails(&buffer, renderFixtures()[1])
+	} else {
+		err = renderer.Render(&buffer, renderFixtures())
+	}
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	return buffer.String()
+}
+
+func TestRender(t *testing.T) {
+	cases := []struct {
+		format   string
+		details  bool
+		expected string
+	}{
+		{TEXT_FORMAT, false, "[ ] [1] Buy milk (due 2026-03-11)\n[x] [2] Write *report*\n"},
+		{TEXT_FORMAT, true, "Id:           2\nName:         Write *report*\nCompleted:    true\nDescription:  Quarterly\nPriority:     high\n"},
+		{TABLE_FORMAT, false, "ID  COMPLETED  NAME            PRIORITY  LABEL         DUE\n1              Buy milk                  home,errands  2026-03-11\n2   x          Write *report*  high                    \n"},
+		{"table=id,name", false, "ID  NAME\n1   Buy milk\n2   Write *report*\n"},
+		{"table=id, name", true, "ID    2\nNAME  Write *report*\n"},
+		{MARKDOWN_FORMAT, false, "- [ ] Buy milk (due 2026-03-11) `#home` `#errands`\n- [x] Write \\*report\\*\n"},
+		{MARKDOWN_FORMAT, true, "## Write \\*report\\*\n\nQuarterly\n\n- **Id:** 2\n- **Completed:** true\n- **Priority:** high\n"},
+		{"template={{.Id}}:{{.Name}} {{date .EndDate}}", false, "1:Buy milk 2026-03-11\n2:Write *report* \n"},
+		{YAML_FORMAT, true, "id: 2\nname: \"Write *report*\"\ncompleted: true\nstatus: \"\"\ndescription: \"Quarterly\"\npriority: 3\nlocation: \"\"\nlabel: \"\"\nbegin_date: null\nend_date: null\nestimate: 0\nestimate_unit: \"\"\nuser_id: 0\nlist_id: 0\nassignee_id: 0\nauto_complete: false\ncomments: 0\nchecklist_items: 0\nchecklist_checked: 0\ncompleted_at: null\ncreated_at: null\nupdated_at: null\n"},
+	}
+
+	for _, c := range cases {
+		if out := render(t, c.format, c.details); out != c.expected {
+			t.Errorf("Output of %s should be\n%q\nbut got\n%q", c.format, c.expected, out)
+		}
+	}
+}
+
+func TestRenderYAMLList(t *testing.T) {
+	out := render(t, YAML_FORMAT, false)
+
+	if !strings.HasPrefix(out, "- id: 1\n  name: \"Buy milk\"\n") || !strings.Contains(out, "  end_date: 2026-03-11T00:30:00+01:00\n") || !strings.Contains(out, "\n- id: 2\n") {
+		t.Error("YAML output is wrong:", out)
+	}
+
+	var buffer bytes.Buffer
+	(&YAMLRenderer{}).Render(&buffer, nil)
+
+	if buffer.String() != "[]\n" {
+		t.Error("Empty YAML list should be", "[]", "but got", buffer.String())
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	var tasks []Task
+
+	if err := json.Unmarshal([]byte(render(t, JSON_FORMAT, false)), &tasks); err != nil {
+		t.Fatal("Output should be valid JSON but got", err)
+	}
+	if len(tasks) != 2 || tasks[1].Name != "Write *report*" || !tasks[1].Completed {
+		t.Error("Tasks should be decoded but got", tasks)
+	}
+
+	var buffer bytes.Buffer
+	(&JSONRenderer{}).Render(&buffer, nil)
+
+	if buffer.String() != "[]\n" {
+		t.Error("Empty JSON list should be", "[]", "but got", buffer.String())
+	}
+}
+
+func TestNewRendererErrors(t *testing.T) {
+	for _, format := range []string{"xml", "table=id,unknown", "template", "template={{.Name"} {
+		if _, err := NewRenderer(format, preferences.Default()); err == nil {
+			t.Error("Format", format, "should return an error")
+		}
+	}
+}
+
+func TestRenderComments(t *testing.T) {
+	tasks := renderFixtures()
+	tasks[0].Comments = 1
+	tasks[1].Comments = 3
+
+	var buffer bytes.Buffer
+	p := preferences.Default()
+	p.Timezone = "Europe/Paris"
+
+	(&TextRenderer{Preferences: p}).Render(&buffer, tasks)
+	expected := "[ ] [1] Buy milk (due 2026-03-11) (1 comment)\n[x] [2] Write *report* (3 comments)\n"
+
+	if buffer.String() != expected {
+		t.Errorf("Output should be\n%q\nbut got\n%q", expected, buffer.String())
+	}
+}
+
+func TestRenderChecklist(t *testing.T) {
+	tasks := renderFixtures()
+	tasks[0].ChecklistItems = 3
+	tasks[0].ChecklistChecked = 2
+	tasks[0].Comments = 1
+
+	var buffer bytes.Buffer
+	p := preferences.Default()
+	p.Timezone = "Europe/Paris"
+	renderer := &TextRenderer{Preferences: p}
+
+	renderer.Render(&buffer, tasks)
+	expected := "[ ] [1] Buy milk (due 2026-03-11) (66% done) (1 comment)\n[x] [2] Write *report*\n"
+
+	if buffer.String() != expected {
+		t.Errorf("Output should be\n%q\nbut got\n%q", expected, buffer.String())
+	}
+
+	buffer.Reset()
+	renderer.RenderDetails(&buffer, tasks[0])
+
+	if !strings.Contains(buffer.String(), "Checklist:    2/3 (66%)\n") {
+		t.Error("Details should contain the checklist but got", buffer.String())
+	}
+}
+
+func TestRenderDetailsZeroValues(t *testing.T) {
+	task := Task{Id: 3, Name: "Room", Location: "0", Label: "0"}
+	p := preferences.Default()
+	var buffer bytes.Buffer
+
+	(&TextRenderer{Preferences: p}).RenderDetails(&buffer, task)
+
+	if !strings.Contains(buffer.String(), "Location:     0\n") || !strings.Contains(buffer.String(), "Label:        0\n") {
+		t.Error("Details should contain the location and label but got", buffer.String())
+	}
+
+	if strings.Contains(buffer.String(), "Priority") || strings.Contains(buffer.String(), "Estimate") {
+		t.Error("Details should not contain the unset priority and estimate but got", buffer.String())
+	}
+
+	buffer.Reset()
+	(&MarkdownRenderer{Preferences: p}).RenderDetails(&buffer, task)
+
+	if !strings.Contains(buffer.String(), "- **Location:** 0\n") || !strings.Contains(buffer.String(), "- **Label:** 0\n") {
+		t.Error("Details should contain the location and label but got", buffer.String())
+	}
+}
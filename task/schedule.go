@@ -0,0 +1,28 @@
+package task
+
+import (
+	"time"
+	"todolist/utils"
+)
+
+// IsDueOn tells whether the end date of the task falls on the same calendar
+// day as day in loc.
+func (t *Task) IsDueOn(day time.Time, loc *time.Location) bool {
+	return !t.EndDate.IsZero() && utils.SameDay(t.EndDate, day, loc)
+}
+
+// IsOverdue tells whether the task is still open after the day of its end
+// date in loc.
+func (t *Task) IsOverdue(now time.Time, loc *time.Location) bool {
+	if t.Completed || t.EndDate.IsZero() {
+		return false
+	}
+
+	return utils.StartOfDay(t.EndDate, loc).Before(utils.StartOfDay(now, loc))
+}
+
+// IsStarted tells whether the begin date of the task is today or earlier in
+// loc, a task without begin date is always started.
+func (t *Task) IsStarted(now time.Time, loc *time.Location) bool {
+	return t.BeginDate.IsZero() || !utils.StartOfDay(t.BeginDate, loc).After(utils.StartOfDay(now, loc))
+}
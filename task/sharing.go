@@ -0,0 +1,248 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"todolist/permission"
+	"todolist/utils"
+)
+
+// GetVisibleTasks returns the tasks of the user along with the tasks
+// assigned to the user, the tasks of the lists shared with the user and of
+// the lists of the workspaces where the user is more than a guest.
+func GetVisibleTasks(userId int64) ([]Task, error) {
+	return GetVisibleTasksContext(context.Background(), userId)
+}
+
+func GetVisibleTasksContext(ctx context.Context, userId int64) ([]Task, error) {
+	return queryTasks(ctx, "SELECT "+TASK_COLUMNS+" FROM tasks WHERE deleted_at IS NULL AND (user_id = ? OR assignee_id = ? OR list_id IN (SELECT list_id FROM list_members WHERE user_id = ?) OR list_id IN ("+WORKSPACE_LISTS+")) ORDER BY id", userId, userId, userId, userId)
+}
+
+// WORKSPACE_LISTS selects the ids of the lists a user reaches through the
+// role in their workspace.
+const WORKSPACE_LISTS = "SELECT l.id FROM lists l JOIN workspace_members wm ON wm.workspace_id = l.workspace_id WHERE wm.user_id = ? AND wm.accepted_at IS NOT NULL AND wm.role IN ('" + permission.ADMIN_ROLE + "', '" + permission.MEMBER_ROLE + "')"
+
+func GetTasksByListId(listId int64) ([]Task, error) {
+	return GetTasksByListIdContext(context.Background(), listId)
+}
+
+func GetTasksByListIdContext(ctx context.Context, listId int64) ([]Task, error) {
+	return queryTasks(ctx, "SELECT "+TASK_COLUMNS+" FROM tasks WHERE list_id = ? AND deleted_at IS NULL ORDER BY id", listId)
+}
+
+// GetTaskAs returns the task when actorId may read it, ErrTaskNotFound or
+// permission.ErrForbidden otherwise.
+func GetTaskAs(id int64, actorId int64) (Task, error) {
+	return GetTaskAsContext(context.Background(), id, actorId)
+}
+
+func GetTaskAsContext(ctx context.Context, id int64, actorId int64) (Task, error) {
+	task, err := GetTaskContext(ctx, id)
+
+	if err != nil {
+		return Task{}, err
+	}
+
+	err = authorize(ctx, utils.SqliteInstance.DB, actorId, task, permission.READ_ACTION)
+
+	if err != nil {
+		return Task{}, err
+	}
+
+	return task, nil
+}
+
+// Role returns the role of userId on the task: owner for the user of the
+// task, the role of the user in the list of the task otherwise and "" when
+// the user has no access. The assignee is at least an editor and the role in
+// the workspace of the list is inherited.
+func (t *Task) Role(userId int64) (string, error) {
+	return t.RoleContext(context.Background(), userId)
+}
+
+func (t *Task) RoleContext(ctx context.Context, userId int64) (string, error) {
+	return roleOf(ctx, utils.SqliteInstance.DB, userId, *t)
+}
+
+func roleOf(ctx context.Context, exec utils.Executor, userId int64, task Task) (string, error) {
+	if userId == task.UserId {
+		return permission.OWNER_ROLE, nil
+	}
+
+	role, err := ListRole(ctx, exec, task.ListId, userId)
+
+	if err != nil {
+		return "", err
+	}
+
+	if userId == task.AssigneeId && role != permission.OWNER_ROLE {
+		return permission.EDITOR_ROLE, nil
+	}
+
+	return role, nil
+}
+
+// ListRole returns the strongest of the role of the user in the list and
+// of the role inherited from the workspace of the list.
+func ListRole(ctx context.Context, exec utils.Executor, listId int64, userId int64) (string, error) {
+	var role, workspaceRole string
+
+	if listId == 0 {
+		return "", nil
+	}
+
+	row := exec.QueryRowContext(
+		ctx,
+		`SELECT
+			COALESCE((SELECT role FROM list_members WHERE list_id = ? AND user_id = ?), ''),
+			COALESCE((SELECT wm.role FROM lists l JOIN workspace_members wm ON wm.workspace_id = l.workspace_id WHERE l.id = ? AND wm.user_id = ? AND wm.accepted_at IS NOT NULL), '')`,
+		listId,
+		userId,
+		listId,
+		userId,
+	)
+	err := row.Scan(&role, &workspaceRole)
+
+	if err != nil {
+		return "", fmt.Errorf("Reading role on list %d: %w", listId, err)
+	}
+
+	return permission.Strongest(role, permission.Inherit(workspaceRole)), nil
+}
+
+// authorize checks that actorId may do action on the stored task. The actor
+// 0 is the application itself and may do anything.
+func authorize(ctx context.Context, exec utils.Executor, actorId int64, task Task, action string) error {
+	if actorId == 0 {
+		return nil
+	}
+
+	role, err := roleOf(ctx, exec, actorId, task)
+
+	if err != nil {
+		return err
+	}
+
+	if !permission.Authorize(permission.TASK_RESOURCE, role, action) {
+		return fmt.Errorf("%w: user %d can not %s this task", permission.ErrForbidden, actorId, action)
+	}
+
+	return nil
+}
+
+// AuthorizeWrite checks that actorId may edit the task taskId, for the
+// packages attaching data to tasks. The actor 0 is the application itself.
+func AuthorizeWrite(ctx context.Context, actorId int64, taskId int64) error {
+	task, err := GetTaskAsContext(ctx, taskId, actorId)
+
+	if err != nil {
+		return err
+	}
+
+	return authorize(ctx, utils.SqliteInstance.DB, actorId, task, permission.WRITE_ACTION)
+}
+
+func authorizeList(ctx context.Context, exec utils.Executor, actorId int64, listId int64, action string) error {
+	if actorId == 0 {
+		return nil
+	}
+
+	role, err := ListRole(ctx, exec, listId, actorId)
+
+	if err != nil {
+		return err
+	}
+
+	if !permission.Authorize(permission.LIST_RESOURCE, role, action) {
+		return fmt.Errorf("%w: user %d can not %s list %d", permission.ErrForbidden, actorId, action, listId)
+	}
+
+	return nil
+}
+
+// authorizeCreate lets users create their own tasks, a task created in a
+// list needs write access to the list.
+func (t *Task) authorizeCreate(ctx context.Context, exec utils.Executor, actorId int64) error {
+	var err error
+
+	if t.ListId != 0 {
+		err = authorizeList(ctx, exec, actorId, t.ListId, permission.WRITE_ACTION)
+	} else {
+		err = authorize(ctx, exec, actorId, Task{UserId: t.UserId}, permission.WRITE_ACTION)
+	}
+
+	if err != nil || t.AssigneeId == 0 || actorId == 0 {
+		return err
+	}
+
+	role, err := roleOf(ctx, exec, actorId, Task{UserId: t.UserId, ListId: t.ListId})
+
+	if err != nil {
+		return err
+	}
+
+	return t.authorizeAssignee(ctx, exec, actorId, role)
+}
+
+// authorizeUpdate checks the access to the stored task old, moving the task
+// to another list needs write access to that list, giving it to another
+// user needs to own it and changing its assignee is checked by
+// authorizeAssignee.
+func (t *Task) authorizeUpdate(ctx context.Context, exec utils.Executor, actorId int64, old Task) error {
+	if actorId == 0 {
+		return nil
+	}
+
+	role, err := roleOf(ctx, exec, actorId, old)
+
+	if err != nil {
+		return err
+	}
+
+	if !permission.Authorize(permission.TASK_RESOURCE, role, permission.WRITE_ACTION) || (t.UserId != old.UserId && role != permission.OWNER_ROLE) {
+		return fmt.Errorf("%w: user %d can not %s this task", permission.ErrForbidden, actorId, permission.WRITE_ACTION)
+	}
+
+	if t.ListId != old.ListId && t.ListId != 0 {
+		err = authorizeList(ctx, exec, actorId, t.ListId, permission.WRITE_ACTION)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if t.AssigneeId != old.AssigneeId {
+		return t.authorizeAssignee(ctx, exec, actorId, role)
+	}
+
+	return nil
+}
+
+// authorizeAssignee lets the users who may share the task, role being their
+// role on it, assign it to anyone. Since the assignee becomes an editor of
+// the task, the others may only assign it to users who can already read it.
+func (t *Task) authorizeAssignee(ctx context.Context, exec utils.Executor, actorId int64, role string) error {
+	if t.AssigneeId == 0 || permission.Authorize(permission.TASK_RESOURCE, role, permission.SHARE_ACTION) {
+		return nil
+	}
+
+	assigneeRole, err := roleOf(ctx, exec, t.AssigneeId, Task{UserId: t.UserId, ListId: t.ListId})
+
+	if err != nil {
+		return err
+	}
+
+	if !permission.Authorize(permission.TASK_RESOURCE, assigneeRole, permission.READ_ACTION) {
+		return fmt.Errorf("%w: user %d can not assign this task to user %d who has no access to it", permission.ErrForbidden, actorId, t.AssigneeId)
+	}
+
+	return nil
+}
+
+func nullableId(id int64) any {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
@@ -0,0 +1,120 @@
+package task
+
+import (
+	"errors"
+	"testing"
+	"todolist/permission"
+	"todolist/utils"
+)
+
+func share(listId int64, userId int64, role string) {
+	utils.SqliteInstance.DB.Exec("INSERT INTO list_members (list_id, user_id, role) VALUES (?, ?, ?)", listId, userId, role)
+}
+
+func TestSharingPermissions(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	share(1, 1, permission.OWNER_ROLE)
+	share(1, 2, permission.EDITOR_ROLE)
+	share(1, 3, permission.VIEWER_ROLE)
+
+	task := NewTask(TASK_NAME)
+	task.UserId = 1
+	task.ListId = 1
+
+	if err := task.SaveAs(1); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	roles := map[int64]string{1: permission.OWNER_ROLE, 2: permission.EDITOR_ROLE, 3: permission.VIEWER_ROLE, 4: ""}
+
+	for userId, expected := range roles {
+		if role, _ := task.Role(userId); role != expected {
+			t.Error("Role of user", userId, "should be", expected, "but got", role)
+		}
+	}
+
+	task.Priority = 2
+
+	if err := task.SaveAs(2); err != nil {
+		t.Error("Editor should save but got", err)
+	}
+
+	task.Priority = 3
+
+	if err := task.SaveAs(3); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Viewer should not save but got", err)
+	}
+	if err := task.DeleteAs(3); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Viewer should not delete but got", err)
+	}
+	if err := task.PurgeAs(4); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Stranger should not purge but got", err)
+	}
+
+	if _, err := GetTaskAs(task.Id, 3); err != nil {
+		t.Error("Viewer should read but got", err)
+	}
+	if _, err := GetTaskAs(task.Id, 4); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Stranger should not read but got", err)
+	}
+
+	task.UserId = 2
+
+	if err := task.SaveAs(2); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Editor should not take the task but got", err)
+	}
+
+	task.UserId = 1
+	task.ListId = 2
+
+	if err := task.SaveAs(2); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Editor should not move the task to a list they can not edit but got", err)
+	}
+
+	other := NewTask(TASK_NAME)
+	other.UserId = 3
+	other.ListId = 1
+
+	if err := other.SaveAs(3); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Viewer should not create tasks in the list but got", err)
+	}
+
+	other.UserId = 2
+
+	if err := other.SaveAs(3); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("User should not create tasks for someone else but got", err)
+	}
+
+	if err := task.DeleteAs(2); err != nil {
+		t.Error("Editor should delete but got", err)
+	}
+}
+
+func TestGetVisibleTasks(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	share(1, 1, permission.OWNER_ROLE)
+	share(1, 2, permission.VIEWER_ROLE)
+
+	for _, task := range []Task{{Name: "Own", UserId: 2}, {Name: "Shared", UserId: 1, ListId: 1}, {Name: "Private", UserId: 1}} {
+		task.Save()
+	}
+
+	tasks, err := GetVisibleTasks(2)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if len(tasks) != 2 || tasks[0].Name != "Own" || tasks[1].Name != "Shared" {
+		t.Error("Visible tasks should be Own and Shared but got", tasks)
+	}
+
+	tasks, _ = GetTasksByListId(1)
+
+	if len(tasks) != 1 || tasks[0].ListId != 1 {
+		t.Error("List should have 1 task but got", tasks)
+	}
+}
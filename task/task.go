@@ -1,43 +1,190 @@
 package task
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"time"
+	"todolist/events"
+	"todolist/history"
+	"todolist/permission"
+	"todolist/preferences"
 	"todolist/utils"
 
 	_ "github.com/glebarez/go-sqlite"
 )
 
 type Task struct {
-	Id          int64     `json:"id"`
-	Name        string    `json:"name"`
-	Completed   bool      `json:"completed"`
-	Description string    `json:"description"`
-	EndDate     time.Time `json:"end_date"`
-	BeginDate   time.Time `json:"begin_date"`
-	Priority    int       `json:"priority"`
-	Location    string    `json:"location"`
-	Label       string    `json:"label"`
-	UserId      int64     `json:"user_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	Id           int64      `json:"id"`
+	Name         string     `json:"name"`
+	Completed    bool       `json:"completed"`
+	Description  string     `json:"description"`
+	EndDate      time.Time  `json:"end_date"`
+	BeginDate    time.Time  `json:"begin_date"`
+	Priority     int        `json:"priority"`
+	Location     string     `json:"location"`
+	Label        string     `json:"label"`
+	UserId       int64      `json:"user_id"`
+	ListId       int64      `json:"list_id"`
+	AssigneeId   int64      `json:"assignee_id"`
+	AutoComplete bool       `json:"auto_complete"`
+	Status       string     `json:"status"`
+	Estimate     int        `json:"estimate"`
+	EstimateUnit string     `json:"estimate_unit"`
+	CompletedAt  time.Time  `json:"completed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+
+	// counted when the task is loaded, they are not saved with it
+	Comments         int `json:"comments"`
+	ChecklistItems   int `json:"checklist_items"`
+	ChecklistChecked int `json:"checklist_checked"`
 }
 
 type TaskInterface interface {
+	Validate() utils.ValidationErrors
 	Complete()
 	Save() error
+	SaveAs(actorId int64) error
+	SaveContext(ctx context.Context, actorId int64) error
+	SaveWith(ctx context.Context, exec utils.Executor, actorId int64) error
 	Delete() error
+	DeleteAs(actorId int64) error
+	DeleteContext(ctx context.Context, actorId int64) error
+	IsDeleted() bool
+	Restore() error
+	RestoreAs(actorId int64) error
+	RestoreContext(ctx context.Context, actorId int64) error
+	Purge() error
+	PurgeAs(actorId int64) error
+	PurgeContext(ctx context.Context, actorId int64) error
+	Snapshot() map[string]string
+	Apply(changes []history.Change, old bool) error
+	History() ([]history.Entry, error)
+	HistoryContext(ctx context.Context) ([]history.Entry, error)
+	Column(column string, p preferences.Preferences) string
 	Print()
+	PrintWith(p preferences.Preferences)
 	PrintDetails()
+	PrintDetailsWith(p preferences.Preferences)
+}
+
+const (
+	MIN_PRIORITY = NONE_PRIORITY
+	MAX_PRIORITY = CRITICAL_PRIORITY
+)
+
+const (
+	TASK_COLUMNS = "id, name, description, completed, end_date, begin_date, priority, location, label, user_id, created_at, updated_at, deleted_at, list_id, assignee_id, auto_complete, status, estimate, estimate_unit, completed_at, (SELECT COUNT(*) FROM comments WHERE comments.task_id = tasks.id), (SELECT COUNT(*) FROM checklist_items WHERE checklist_items.task_id = tasks.id), (SELECT COUNT(*) FROM checklist_items WHERE checklist_items.task_id = tasks.id AND checked)"
+)
+
+var (
+	ErrTaskNotFound = errors.New("Task not found")
+)
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask reads a row selected with TASK_COLUMNS, NULL columns are left to
+// their zero value.
+func scanTask(row scanner, task *Task) error {
+	var name, description, location, label, status, estimateUnit sql.NullString
+	var completed, autoComplete sql.NullBool
+	var priority, userId, listId, assigneeId, estimate sql.NullInt64
+	var endDate, beginDate, createdAt, updatedAt, deletedAt, completedAt sql.NullTime
+
+	err := row.Scan(
+		&task.Id,
+		&name,
+		&description,
+		&completed,
+		&endDate,
+		&beginDate,
+		&priority,
+		&location,
+		&label,
+		&userId,
+		&createdAt,
+		&updatedAt,
+		&deletedAt,
+		&listId,
+		&assigneeId,
+		&autoComplete,
+		&status,
+		&estimate,
+		&estimateUnit,
+		&completedAt,
+		&task.Comments,
+		&task.ChecklistItems,
+		&task.ChecklistChecked,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	task.Name = name.String
+	task.Description = description.String
+	task.Completed = completed.Bool
+	task.EndDate = endDate.Time
+	task.BeginDate = beginDate.Time
+	task.Priority = int(priority.Int64)
+	task.Location = location.String
+	task.Label = label.String
+	task.UserId = userId.Int64
+	task.ListId = listId.Int64
+	task.AssigneeId = assigneeId.Int64
+	task.AutoComplete = autoComplete.Bool
+	task.Status = status.String
+	task.Estimate = int(estimate.Int64)
+	task.EstimateUnit = estimateUnit.String
+	task.CompletedAt = completedAt.Time
+	task.CreatedAt = createdAt.Time
+	task.UpdatedAt = updatedAt.Time
+	task.DeletedAt = nil
+
+	if deletedAt.Valid {
+		task.DeletedAt = &deletedAt.Time
+	}
+
+	return nil
 }
 
 func IsTaskExist(id int64) bool {
+	exist, _ := IsTaskExistContext(context.Background(), id)
+	return exist
+}
+
+func IsTaskExistContext(ctx context.Context, id int64) (bool, error) {
+	var count int
+
+	row := utils.SqliteInstance.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks WHERE id = ? AND deleted_at IS NULL", id)
+	err := row.Scan(&count)
+
+	if err != nil {
+		return false, fmt.Errorf("Checking task %d: %w", id, err)
+	}
+
+	return count > 0, nil
+}
+
+func isRowExist(ctx context.Context, exec utils.Executor, id int64) (bool, error) {
 	var count int
 
-	row := utils.SqliteInstance.DB.QueryRow("SELECT COUNT(*) FROM tasks WHERE id = ?", id)
-	row.Scan(&count)
+	row := exec.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks WHERE id = ?", id)
+	err := row.Scan(&count)
+
+	if err != nil {
+		return false, fmt.Errorf("Checking task %d: %w", id, err)
+	}
 
-	return count > 0
+	return count > 0, nil
 }
 
 func NewTask(name string) Task {
@@ -50,83 +197,342 @@ func NewTask(name string) Task {
 	return task
 }
 
+// GetTask returns the task with the given id, even when it is in the trash,
+// or a zero Task when it does not exist.
 func GetTask(id int64) Task {
+	task, _ := GetTaskContext(context.Background(), id)
+	return task
+}
+
+func GetTaskContext(ctx context.Context, id int64) (Task, error) {
+	return getTask(ctx, utils.SqliteInstance.DB, id)
+}
+
+// GetTaskWith returns the task with the given id read through exec.
+func GetTaskWith(ctx context.Context, exec utils.Executor, id int64) (Task, error) {
+	return getTask(ctx, exec, id)
+}
+
+func getTask(ctx context.Context, exec utils.Executor, id int64) (Task, error) {
 	var task Task
 
-	row := utils.SqliteInstance.DB.QueryRow("SELECT * FROM tasks WHERE id = ?", id)
+	row := exec.QueryRowContext(ctx, "SELECT "+TASK_COLUMNS+" FROM tasks WHERE id = ?", id)
+	err := scanTask(row, &task)
 
-	row.Scan(
-		&task.Id,
-		&task.Name,
-		&task.Description,
-		&task.Completed,
-		&task.EndDate,
-		&task.BeginDate,
-		&task.Priority,
-		&task.Location,
-		&task.Label,
-		&task.UserId,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-	)
+	if err == sql.ErrNoRows {
+		return Task{}, fmt.Errorf("%w: %d", ErrTaskNotFound, id)
+	}
 
-	return task
+	if err != nil {
+		return Task{}, fmt.Errorf("Scanning task %d: %w", id, err)
+	}
+
+	return task, nil
 }
 
 func GetTasksByUserId(id int64) ([]Task, error) {
+	return GetTasksByUserIdContext(context.Background(), id)
+}
+
+func GetTasksByUserIdContext(ctx context.Context, id int64) ([]Task, error) {
+	return queryTasks(ctx, "SELECT "+TASK_COLUMNS+" FROM tasks WHERE user_id = ? AND deleted_at IS NULL", id)
+}
+
+func queryTasks(ctx context.Context, query string, args ...any) ([]Task, error) {
 	var tasks []Task
 
-	row, err := utils.SqliteInstance.DB.Query("SELECT * FROM tasks WHERE user_id = ?", id)
+	rows, err := utils.SqliteInstance.DB.QueryContext(ctx, query, args...)
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Querying tasks: %w", err)
 	}
 
-	for row.Next() {
+	defer rows.Close()
+
+	for rows.Next() {
 		var task Task
 
-		row.Scan(
-			&task.Id,
-			&task.Name,
-			&task.Description,
-			&task.Completed,
-			&task.EndDate,
-			&task.BeginDate,
-			&task.Priority,
-			&task.Location,
-			&task.Label,
-			&task.UserId,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		)
+		err := scanTask(rows, &task)
+
+		if err != nil {
+			return nil, fmt.Errorf("Scanning task: %w", err)
+		}
 
 		tasks = append(tasks, task)
 	}
 
+	err = rows.Err()
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying tasks: %w", err)
+	}
+
 	return tasks, nil
 }
 
+func (t *Task) Validate() utils.ValidationErrors {
+	var errs utils.ValidationErrors
+
+	if strings.TrimSpace(t.Name) == "" {
+		errs.Add("name", "is required")
+	}
+
+	if !t.BeginDate.IsZero() && !t.EndDate.IsZero() && t.EndDate.Before(t.BeginDate) {
+		errs.Add("end_date", "is before begin_date")
+	}
+
+	if t.Priority < MIN_PRIORITY || t.Priority > MAX_PRIORITY {
+		errs.Add("priority", fmt.Sprintf("must be between %d (%s) and %d (%s)", MIN_PRIORITY, PRIORITIES[MIN_PRIORITY], MAX_PRIORITY, PRIORITIES[MAX_PRIORITY]))
+	}
+
+	if t.Estimate < 0 {
+		errs.Add("estimate", "must not be negative")
+	}
+
+	if t.EstimateUnit != "" && t.EstimateUnit != MINUTES_UNIT && t.EstimateUnit != POINTS_UNIT {
+		errs.Add("estimate_unit", fmt.Sprintf("must be %s or %s", MINUTES_UNIT, POINTS_UNIT))
+	}
+
+	return errs
+}
+
+// Complete toggles the completion of the task, the TaskCompleted event is
+// published once the task is saved.
 func (t *Task) Complete() {
 	t.Completed = !t.Completed
 }
 
+// Snapshot returns the audited fields of the task formatted as strings.
+func (t *Task) Snapshot() map[string]string {
+	return map[string]string{
+		"name":          t.Name,
+		"description":   t.Description,
+		"completed":     strconv.FormatBool(t.Completed),
+		"end_date":      formatDate(t.EndDate),
+		"begin_date":    formatDate(t.BeginDate),
+		"priority":      strconv.Itoa(t.Priority),
+		"location":      t.Location,
+		"label":         t.Label,
+		"user_id":       strconv.FormatInt(t.UserId, 10),
+		"list_id":       strconv.FormatInt(t.ListId, 10),
+		"assignee_id":   strconv.FormatInt(t.AssigneeId, 10),
+		"auto_complete": strconv.FormatBool(t.AutoComplete),
+		"status":        t.Status,
+		"estimate":      strconv.Itoa(t.Estimate),
+		"estimate_unit": t.EstimateUnit,
+		"deleted_at":    formatDeletedAt(t.DeletedAt),
+	}
+}
+
+func formatDeletedAt(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+
+	return formatDate(*date)
+}
+
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+
+	return date.Format(time.RFC3339Nano)
+}
+
+// Apply sets the fields listed in changes to their old or new value.
+func (t *Task) Apply(changes []history.Change, old bool) error {
+	for _, change := range changes {
+		value := change.NewValue
+
+		if old {
+			value = change.OldValue
+		}
+
+		var err error
+
+		switch change.Field {
+		case "name":
+			t.Name = value
+		case "description":
+			t.Description = value
+		case "completed":
+			t.Completed, err = strconv.ParseBool(value)
+		case "end_date":
+			t.EndDate, err = parseDate(value)
+		case "begin_date":
+			t.BeginDate, err = parseDate(value)
+		case "priority":
+			t.Priority, err = strconv.Atoi(value)
+		case "location":
+			t.Location = value
+		case "label":
+			t.Label = value
+		case "user_id":
+			t.UserId, err = strconv.ParseInt(value, 10, 64)
+		case "list_id":
+			t.ListId, err = strconv.ParseInt(value, 10, 64)
+		case "assignee_id":
+			t.AssigneeId, err = strconv.ParseInt(value, 10, 64)
+		case "auto_complete":
+			t.AutoComplete, err = strconv.ParseBool(value)
+		case "status":
+			t.Status = value
+		case "estimate":
+			t.Estimate, err = strconv.Atoi(value)
+		case "estimate_unit":
+			t.EstimateUnit = value
+		case "deleted_at":
+			t.DeletedAt = nil
+
+			if value != "" {
+				var date time.Time
+				date, err = parseDate(value)
+				t.DeletedAt = &date
+			}
+		default:
+			err = fmt.Errorf("Unknown task field %s", change.Field)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+func (t *Task) History() ([]history.Entry, error) {
+	return t.HistoryContext(context.Background())
+}
+
+func (t *Task) HistoryContext(ctx context.Context) ([]history.Entry, error) {
+	return history.GetHistoryContext(ctx, history.TASK_ENTITY, t.Id)
+}
+
 func (t *Task) Save() error {
+	return t.SaveAs(t.UserId)
+}
+
+func (t *Task) SaveAs(actorId int64) error {
+	return t.SaveContext(context.Background(), actorId)
+}
+
+func (t *Task) SaveContext(ctx context.Context, actorId int64) error {
+	return t.SaveWith(ctx, utils.SqliteInstance.DB, actorId)
+}
+
+// SaveWith saves the task through exec, events are only published once exec
+// is committed when it is a transaction. A task without id is created, a
+// task with the id of a missing row is not saved, see RecreateWith.
+func (t *Task) SaveWith(ctx context.Context, exec utils.Executor, actorId int64) error {
+	return t.save(ctx, exec, actorId, false)
+}
+
+// RecreateWith saves the task like SaveWith but inserts it back under its id
+// when its row is gone, for undo to bring back a task that was removed.
+func (t *Task) RecreateWith(ctx context.Context, exec utils.Executor, actorId int64) error {
+	return t.save(ctx, exec, actorId, true)
+}
+
+func (t *Task) save(ctx context.Context, exec utils.Executor, actorId int64, recreate bool) error {
+	if errs := t.Validate(); len(errs) > 0 {
+		return errs
+	}
+
+	var old Task
+	var err error
+
+	exist := false
+
+	if t.Id != 0 {
+		exist, err = isRowExist(ctx, exec, t.Id)
+
+		if err != nil {
+			return err
+		}
+
+		if !exist && !recreate {
+			return fmt.Errorf("%w: %d", ErrTaskNotFound, t.Id)
+		}
+	}
+
+	if exist {
+		old, err = getTask(ctx, exec, t.Id)
+
+		if err != nil {
+			return err
+		}
+
+		err = t.authorizeUpdate(ctx, exec, actorId, old)
+	} else {
+		err = t.authorizeCreate(ctx, exec, actorId)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = t.resolveStatus(ctx, exec, old, exist)
+
+	if err != nil {
+		return err
+	}
+
+	return t.write(ctx, exec, actorId, old, exist)
+}
+
+// write stores the task once it is validated and authorized, old being the
+// stored task when exist. It records the history and the status change and
+// publishes the events after the commit.
+func (t *Task) write(ctx context.Context, exec utils.Executor, actorId int64, old Task, exist bool) error {
 	var query string
+	var id any
+
+	// the completion date follows the completed flag, it is kept while the
+	// task stays completed
+	switch {
+	case !t.Completed:
+		t.CompletedAt = time.Time{}
+	case old.Completed && !old.CompletedAt.IsZero():
+		t.CompletedAt = old.CompletedAt
+	case t.CompletedAt.IsZero():
+		t.CompletedAt = time.Now()
+	}
+
+	// a recreated task is inserted back under its id
+	insert := !exist
 
-	if t.Id == 0 {
-		query = "INSERT INTO tasks (name, description, completed, end_date, begin_date, priority, location, label, user_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	if t.Id != 0 {
+		id = t.Id
+	}
+
+	if insert {
+		query = "INSERT INTO tasks (id, name, description, completed, end_date, begin_date, priority, location, label, user_id, created_at, updated_at, deleted_at, list_id, assignee_id, auto_complete, status, estimate, estimate_unit, completed_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 	} else {
-		query = "UPDATE tasks SET name = ?, description = ?, completed = ?, end_date = ?, begin_date = ?, priority = ?, location = ?, label = ?, user_id = ?, created_at = ?, updated_at = ? WHERE id = ?"
+		query = "UPDATE tasks SET name = ?, description = ?, completed = ?, end_date = ?, begin_date = ?, priority = ?, location = ?, label = ?, user_id = ?, created_at = ?, updated_at = ?, deleted_at = ?, list_id = ?, assignee_id = ?, auto_complete = ?, status = ?, estimate = ?, estimate_unit = ?, completed_at = ? WHERE id = ?"
 	}
 
-	stmt, err := utils.SqliteInstance.DB.Prepare(query)
+	stmt, err := exec.PrepareContext(ctx, query)
 
 	if err != nil {
 		return err
 	}
 
-	if t.Id == 0 {
-		res, err := stmt.Exec(
+	defer stmt.Close()
+
+	if insert {
+		res, err := stmt.ExecContext(
+			ctx,
+			id,
 			t.Name,
 			t.Description,
 			t.Completed,
@@ -138,16 +544,45 @@ func (t *Task) Save() error {
 			t.UserId,
 			t.CreatedAt,
 			t.UpdatedAt,
+			t.DeletedAt,
+			nullableId(t.ListId),
+			nullableId(t.AssigneeId),
+			t.AutoComplete,
+			t.Status,
+			t.Estimate,
+			t.EstimateUnit,
+			t.CompletedAt,
 		)
 
 		if err != nil {
 			return err
 		}
 
-		t.Id, _ = res.LastInsertId()
+		t.Id, err = res.LastInsertId()
+
+		if err != nil {
+			return err
+		}
+
+		_, err = history.RecordWith(ctx, exec, history.TASK_ENTITY, t.Id, actorId, history.CREATE_ACTION, history.Diff(nil, t.Snapshot()))
+
+		if err != nil {
+			return err
+		}
+
+		created := events.TaskCreated{TaskId: t.Id, UserId: t.UserId, Name: t.Name}
+
+		err = utils.AfterCommit(exec, func() error {
+			return events.Publish(created)
+		})
+
+		if err != nil {
+			return err
+		}
 	} else {
 		t.UpdatedAt = time.Now()
-		_, err := stmt.Exec(
+		_, err = stmt.ExecContext(
+			ctx,
 			t.Name,
 			t.Description,
 			t.Completed,
@@ -159,58 +594,133 @@ func (t *Task) Save() error {
 			t.UserId,
 			t.CreatedAt,
 			t.UpdatedAt,
+			t.DeletedAt,
+			nullableId(t.ListId),
+			nullableId(t.AssigneeId),
+			t.AutoComplete,
+			t.Status,
+			t.Estimate,
+			t.EstimateUnit,
+			t.CompletedAt,
 			t.Id,
 		)
 
 		if err != nil {
 			return err
 		}
+
+		changes := history.Diff(old.Snapshot(), t.Snapshot())
+
+		if len(changes) > 0 {
+			_, err = history.RecordWith(ctx, exec, history.TASK_ENTITY, t.Id, actorId, history.UPDATE_ACTION, changes)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if t.Status != old.Status {
+		err = recordStatus(ctx, exec, t.Id, old.Status, t.Status, actorId)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if t.Completed && !old.Completed {
+		completed := events.TaskCompleted{TaskId: t.Id, UserId: t.UserId}
+
+		err = utils.AfterCommit(exec, func() error {
+			return events.Publish(completed)
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// users are not notified of the tasks they assign to themselves
+	if t.AssigneeId != 0 && t.AssigneeId != old.AssigneeId && t.AssigneeId != actorId {
+		assigned := events.TaskAssigned{TaskId: t.Id, Name: t.Name, AssigneeId: t.AssigneeId, AssignerId: actorId}
+
+		return utils.AfterCommit(exec, func() error {
+			return events.Publish(assigned)
+		})
 	}
 
 	return nil
 }
 
 func (t *Task) Delete() error {
-	stmt, err := utils.SqliteInstance.DB.Prepare("DELETE FROM tasks WHERE id = ?")
+	return t.DeleteAs(t.UserId)
+}
+
+func (t *Task) DeleteAs(actorId int64) error {
+	return t.DeleteContext(context.Background(), actorId)
+}
+
+// DeleteContext moves the task to the trash, use Purge to remove it for good.
+func (t *Task) DeleteContext(ctx context.Context, actorId int64) error {
+	return t.DeleteWith(ctx, utils.SqliteInstance.DB, actorId)
+}
+
+// DeleteWith moves the task to the trash through exec, the TaskDeleted event
+// is only published once exec is committed when it is a transaction.
+func (t *Task) DeleteWith(ctx context.Context, exec utils.Executor, actorId int64) error {
+	old, err := getTask(ctx, exec, t.Id)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = stmt.Exec(t.Id)
+	err = authorize(ctx, exec, actorId, old, permission.WRITE_ACTION)
 
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	now := time.Now()
 
-func (t *Task) PrintDetails() {
-	fmt.Println("Id:          ", t.Id)
-	fmt.Println("Name:        ", t.Name)
-	fmt.Println("Completed:   ", t.Completed)
+	_, err = exec.ExecContext(ctx, "UPDATE tasks SET deleted_at = ? WHERE id = ?", now, t.Id)
 
-	if t.Description != "" {
-		fmt.Println("Description: ", t.Description)
-	}
-	if t.Priority != 0 {
-		fmt.Println("Priority:    ", t.Priority)
-	}
-	if t.Location != "" {
-		fmt.Println("Location:    ", t.Location)
+	if err != nil {
+		return err
 	}
-	if t.Label != "" {
-		fmt.Println("Label:       ", t.Label)
+
+	t.DeletedAt = &now
+
+	// only deleted_at was written, unsaved edits of t are not part of it
+	trashed := old
+	trashed.DeletedAt = &now
+
+	_, err = history.RecordWith(ctx, exec, history.TASK_ENTITY, t.Id, actorId, history.DELETE_ACTION, history.Diff(old.Snapshot(), trashed.Snapshot()))
+
+	if err != nil {
+		return err
 	}
+
+	deleted := events.TaskDeleted{TaskId: t.Id, UserId: old.UserId}
+
+	return utils.AfterCommit(exec, func() error {
+		return events.Publish(deleted)
+	})
 }
 
-func (t *Task) Print() {
-	completed := " "
+func (t *Task) PrintDetails() {
+	t.PrintDetailsWith(preferences.Default())
+}
 
-	if t.Completed {
-		completed = "x"
-	}
+// PrintDetailsWith prints every field set on the task, labels follow the
+// locale and dates the timezone and format of p.
+func (t *Task) PrintDetailsWith(p preferences.Preferences) {
+	(&TextRenderer{Preferences: p}).RenderDetails(os.Stdout, *t)
+}
+
+func (t *Task) Print() {
+	t.PrintWith(preferences.Default())
+}
 
-	fmt.Printf("[%s] [%d] %s\n", completed, t.Id, t.Name)
+func (t *Task) PrintWith(p preferences.Preferences) {
+	(&TextRenderer{Preferences: p}).Render(os.Stdout, []Task{*t})
 }
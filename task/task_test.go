@@ -1,9 +1,17 @@
 package task
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 	"testing"
 	"time"
+	"todolist/events"
+	"todolist/history"
+	"todolist/preferences"
 	"todolist/utils"
 
 	_ "github.com/glebarez/go-sqlite"
@@ -256,7 +264,7 @@ func TestDelete(t *testing.T) {
 		taskDB.Delete()
 	}
 
-	row := utils.SqliteInstance.DB.QueryRow("SELECT COUNT(*) FROM tasks")
+	row := utils.SqliteInstance.DB.QueryRow("SELECT COUNT(*) FROM tasks WHERE deleted_at IS NULL")
 
 	var count int
 	row.Scan(&count)
@@ -264,6 +272,13 @@ func TestDelete(t *testing.T) {
 	if count != 0 {
 		t.Error("Tasks should have 0 tasks but has", count)
 	}
+
+	row = utils.SqliteInstance.DB.QueryRow("SELECT COUNT(*) FROM tasks")
+	row.Scan(&count)
+
+	if count != len(tasks) {
+		t.Error("Deleted tasks should stay in the trash but", count, "are left")
+	}
 }
 
 func TestComplete(t *testing.T) {
@@ -299,3 +314,375 @@ func TestIsTaskExist(t *testing.T) {
 		}
 	}
 }
+
+func TestTaskEvents(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+	defer events.BusInstance.Reset()
+
+	var published []string
+	for _, name := range []string{events.TASK_CREATED, events.TASK_COMPLETED, events.TASK_DELETED} {
+		events.Subscribe(name, func(event events.Event) error {
+			published = append(published, event.Type())
+			return nil
+		})
+	}
+
+	task := NewTask(TASK_NAME)
+	task.Save()
+	task.Complete()
+
+	if len(published) != 1 {
+		t.Error("Completion should only be published once saved but got", published)
+	}
+
+	task.Save()
+	task.Delete()
+
+	expected := []string{events.TASK_CREATED, events.TASK_COMPLETED, events.TASK_DELETED}
+
+	if len(published) != len(expected) {
+		t.Fatal("Events should be", expected, "but got", published)
+	}
+	for i, name := range expected {
+		if published[i] != name {
+			t.Error("Event", i, "should be", name, "but got", published[i])
+		}
+	}
+}
+
+func TestTaskHistory(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	// user 2 edits the task through a list shared by user 1
+	utils.SqliteInstance.DB.Exec("INSERT INTO list_members (list_id, user_id, role) VALUES (1, 1, 'owner'), (1, 2, 'editor')")
+
+	task := NewTask(TASK_NAME)
+	task.UserId = 1
+	task.ListId = 1
+	task.Save()
+
+	task.Priority = 3
+	task.SaveAs(2)
+
+	// saving without changes should not add an entry
+	task.Save()
+
+	// the edits not saved before the deletion are not logged
+	task.Name = "Unsaved"
+	task.Delete()
+
+	entries, err := task.History()
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if len(entries) != 3 {
+		t.Fatal("History should have 3 entries but has", len(entries))
+	}
+
+	actions := []string{history.CREATE_ACTION, history.UPDATE_ACTION, history.DELETE_ACTION}
+	actors := []int64{1, 2, 1}
+
+	for i, entry := range entries {
+		if entry.Action != actions[i] {
+			t.Error("Action should be", actions[i], "but is", entry.Action)
+		}
+		if entry.ActorId != actors[i] {
+			t.Error("Actor should be", actors[i], "but is", entry.ActorId)
+		}
+	}
+
+	update := entries[1].Changes
+
+	if len(update) != 1 || update[0].Field != "priority" || update[0].OldValue != "0" || update[0].NewValue != "3" {
+		t.Error("Update should only change priority from 0 to 3 but got", update)
+	}
+	if deletion := entries[2].Changes; len(deletion) != 1 || deletion[0].Field != "deleted_at" {
+		t.Error("Deletion should only change deleted_at but got", deletion)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+	var userId int64 = 1
+
+	for _, task := range tasks {
+		task.UserId = userId
+		task.Save()
+	}
+
+	deleted := GetTask(2)
+	deleted.Delete()
+
+	if !deleted.IsDeleted() {
+		t.Error("Task should be marked as deleted")
+	}
+	if IsTaskExist(deleted.Id) {
+		t.Error("Deleted task should not exist")
+	}
+
+	tasksDB, _ := GetTasksByUserId(userId)
+
+	if len(tasksDB) != len(tasks)-1 {
+		t.Error("Tasks should have", len(tasks)-1, "tasks but has", len(tasksDB))
+	}
+
+	trash, _ := GetDeletedTasksByUserId(userId)
+
+	if len(trash) != 1 || trash[0].Id != deleted.Id || trash[0].DeletedAt == nil {
+		t.Fatal("Trash should only hold task", deleted.Id, "but has", trash)
+	}
+
+	trash[0].Restore()
+
+	if !IsTaskExist(deleted.Id) {
+		t.Error("Restored task should exist")
+	}
+
+	trash, _ = GetDeletedTasksByUserId(userId)
+
+	if len(trash) != 0 {
+		t.Error("Trash should be empty but has", len(trash), "tasks")
+	}
+}
+
+func TestPurgeExpired(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	for _, task := range tasks {
+		task.Save()
+	}
+
+	old := GetTask(1)
+	old.Delete()
+	utils.SqliteInstance.DB.Exec("UPDATE tasks SET deleted_at = ? WHERE id = ?", time.Now().AddDate(0, 0, -31), old.Id)
+
+	recent := GetTask(2)
+	recent.Delete()
+
+	count, err := PurgeExpired(30 * 24 * time.Hour)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if count != 1 {
+		t.Error("1 task should be purged but got", count)
+	}
+	if GetTask(old.Id).Id != 0 {
+		t.Error("Expired task should be removed")
+	}
+	if GetTask(recent.Id).Id != recent.Id {
+		t.Error("Recently deleted task should stay in the trash")
+	}
+
+	old.DeletedAt = nil
+
+	if err := old.SaveAs(0); !errors.Is(err, ErrTaskNotFound) {
+		t.Error("Error should be", ErrTaskNotFound, "but got", err)
+	}
+	if GetTask(old.Id).Id != 0 {
+		t.Error("Saving a purged task should not bring it back")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := NewTask(TASK_NAME)
+	task.Save()
+	task.AddItem(0, "Step")
+
+	if err := task.Purge(); !errors.Is(err, ErrNotInTrash) {
+		t.Error("Error should be", ErrNotInTrash, "but got", err)
+	}
+	if GetTask(task.Id).Id != task.Id {
+		t.Error("Task outside the trash should not be purged")
+	}
+
+	task.Delete()
+
+	// the purge fails on its last statements and is rolled back
+	utils.SqliteInstance.DB.Exec("DROP TABLE status_history")
+
+	if err := task.Purge(); err == nil {
+		t.Error("Purge should return an error")
+	}
+	if items, _ := task.Checklist(); len(items) != 1 || GetTask(task.Id).Id != task.Id {
+		t.Error("Failed purge should keep the task and its checklist but got", items)
+	}
+}
+
+func TestGetTaskContext(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := NewTask(TASK_NAME)
+	task.Save()
+
+	taskDB, err := GetTaskContext(context.Background(), task.Id)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if taskDB.Name != TASK_NAME {
+		t.Error("Task name should be", TASK_NAME, "but is", taskDB.Name)
+	}
+
+	_, err = GetTaskContext(context.Background(), 42)
+
+	if !errors.Is(err, ErrTaskNotFound) {
+		t.Error("Error should be", ErrTaskNotFound, "but got", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = GetTaskContext(ctx, task.Id)
+
+	if err == nil || errors.Is(err, ErrTaskNotFound) {
+		t.Error("Canceled query should fail with another error than", ErrTaskNotFound, "but got", err)
+	}
+
+	_, err = GetTasksByUserIdContext(ctx, 0)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Error("Error should wrap", context.Canceled, "but got", err)
+	}
+
+	utils.SqliteInstance.DB.Exec("DROP TABLE tasks")
+
+	_, err = GetTaskContext(context.Background(), task.Id)
+
+	if err == nil || errors.Is(err, ErrTaskNotFound) {
+		t.Error("Broken query should fail with another error than", ErrTaskNotFound, "but got", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Now()
+
+	cases := []struct {
+		name   string
+		task   Task
+		fields []string
+	}{
+		{"valid", Task{Name: TASK_NAME, BeginDate: now, EndDate: now.Add(time.Hour), Priority: MAX_PRIORITY}, nil},
+		{"only end date", Task{Name: TASK_NAME, EndDate: now}, nil},
+		{"empty name", Task{Name: "  "}, []string{"name"}},
+		{"end before begin", Task{Name: TASK_NAME, BeginDate: now, EndDate: now.Add(-time.Hour)}, []string{"end_date"}},
+		{"priority too high", Task{Name: TASK_NAME, Priority: MAX_PRIORITY + 1}, []string{"priority"}},
+		{"priority negative", Task{Name: TASK_NAME, Priority: -1}, []string{"priority"}},
+		{"everything", Task{BeginDate: now, EndDate: now.Add(-time.Hour), Priority: 10}, []string{"name", "end_date", "priority"}},
+	}
+
+	for _, c := range cases {
+		errs := c.task.Validate()
+
+		if len(errs) != len(c.fields) {
+			t.Error(c.name, "should fail on", c.fields, "but got", errs)
+			continue
+		}
+
+		for i, field := range c.fields {
+			if errs[i].Field != field {
+				t.Error(c.name, "should fail on", field, "but got", errs[i].Field)
+			}
+		}
+	}
+}
+
+func TestSaveInvalidTask(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := NewTask("")
+	err := task.Save()
+
+	if _, ok := utils.AsValidationErrors(err); !ok {
+		t.Error("Error should be a validation error but got", err)
+	}
+	if task.Id != 0 {
+		t.Error("Task should not be saved")
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	now := time.Date(2026, time.March, 10, 23, 30, 0, 0, time.UTC)
+
+	// 23:30 UTC is already March 11 in Paris
+	task := NewTask(TASK_NAME)
+	task.EndDate = time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	if !task.IsDueOn(now, time.UTC) {
+		t.Error("Task should be due today in UTC")
+	}
+	if task.IsOverdue(now, time.UTC) {
+		t.Error("Task should not be overdue in UTC")
+	}
+	if task.IsDueOn(now, paris) {
+		t.Error("Task should not be due today in Paris")
+	}
+	if !task.IsOverdue(now, paris) {
+		t.Error("Task should be overdue in Paris")
+	}
+
+	task.Completed = true
+
+	if task.IsOverdue(now, paris) {
+		t.Error("Completed task should never be overdue")
+	}
+
+	task.BeginDate = time.Date(2026, time.March, 11, 8, 0, 0, 0, time.UTC)
+
+	if task.IsStarted(now, time.UTC) {
+		t.Error("Task should not be started in UTC")
+	}
+	if !task.IsStarted(now, paris) {
+		t.Error("Task should be started in Paris")
+	}
+}
+
+func capture(print func()) string {
+	stdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	print()
+
+	w.Close()
+	os.Stdout = stdout
+	out, _ := io.ReadAll(r)
+
+	return string(out)
+}
+
+func TestPrintWith(t *testing.T) {
+	task := NewTask(TASK_NAME)
+	task.Id = 1
+	task.EndDate = time.Date(2026, time.March, 10, 23, 30, 0, 0, time.UTC)
+
+	if out := capture(task.Print); !strings.HasPrefix(out, "[ ] [1] TestTask (due ") {
+		t.Error("Print output is wrong:", out)
+	}
+
+	p := preferences.Default()
+	p.Timezone = "Europe/Paris"
+	p.Locale = "fr"
+	p.DateFormat = "02/01/2006"
+
+	if out := capture(func() { task.PrintWith(p) }); out != "[ ] [1] TestTask (pour le 11/03/2026)\n" {
+		t.Error("Print output should be localized but got", out)
+	}
+
+	out := capture(func() { task.PrintDetailsWith(p) })
+
+	if !strings.Contains(out, "Nom:          TestTask\n") || !strings.Contains(out, "Terminée:     non\n") || !strings.Contains(out, "Échéance:     11/03/2026\n") {
+		t.Error("Details should be localized but got", out)
+	}
+}
@@ -0,0 +1,136 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"todolist/events"
+	"todolist/history"
+	"todolist/permission"
+	"todolist/utils"
+)
+
+var (
+	ErrNotInTrash = errors.New("Task is not in the trash")
+)
+
+func GetDeletedTasksByUserId(id int64) ([]Task, error) {
+	return GetDeletedTasksByUserIdContext(context.Background(), id)
+}
+
+func GetDeletedTasksByUserIdContext(ctx context.Context, id int64) ([]Task, error) {
+	return queryTasks(ctx, "SELECT "+TASK_COLUMNS+" FROM tasks WHERE user_id = ? AND deleted_at IS NOT NULL ORDER BY deleted_at DESC", id)
+}
+
+func (t *Task) IsDeleted() bool {
+	return t.DeletedAt != nil
+}
+
+func (t *Task) Restore() error {
+	return t.RestoreAs(t.UserId)
+}
+
+func (t *Task) RestoreAs(actorId int64) error {
+	return t.RestoreContext(context.Background(), actorId)
+}
+
+func (t *Task) RestoreContext(ctx context.Context, actorId int64) error {
+	t.DeletedAt = nil
+
+	return t.SaveContext(ctx, actorId)
+}
+
+func (t *Task) Purge() error {
+	return t.PurgeAs(t.UserId)
+}
+
+func (t *Task) PurgeAs(actorId int64) error {
+	return t.PurgeContext(context.Background(), actorId)
+}
+
+// PurgeContext removes the task in the trash and its comments, checklist,
+// time entries and status history from the database, it can not be
+// restored. Subscribers of TaskPurged remove the rest once it is committed.
+func (t *Task) PurgeContext(ctx context.Context, actorId int64) (err error) {
+	tx, err := utils.SqliteInstance.BeginTx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil && !tx.Committed {
+			tx.Rollback()
+		}
+	}()
+
+	stored, err := getTask(ctx, tx, t.Id)
+
+	if err != nil {
+		return err
+	}
+
+	if actorId != 0 {
+		err = authorize(ctx, tx, actorId, stored, permission.WRITE_ACTION)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if !stored.IsDeleted() {
+		err = fmt.Errorf("%w: %d", ErrNotInTrash, t.Id)
+		return err
+	}
+
+	for _, query := range []string{"DELETE FROM comments WHERE task_id = ?", "DELETE FROM checklist_items WHERE task_id = ?", "DELETE FROM time_entries WHERE task_id = ?", "DELETE FROM status_history WHERE task_id = ?", "DELETE FROM tasks WHERE id = ?"} {
+		_, err = tx.ExecContext(ctx, query, t.Id)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = history.RecordWith(ctx, tx, history.TASK_ENTITY, t.Id, actorId, history.PURGE_ACTION, nil)
+
+	if err != nil {
+		return err
+	}
+
+	purged := events.TaskPurged{TaskId: t.Id, UserId: stored.UserId}
+
+	err = utils.AfterCommit(tx, func() error {
+		return events.Publish(purged)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func PurgeExpired(retention time.Duration) (int, error) {
+	return PurgeExpiredContext(context.Background(), retention)
+}
+
+// PurgeExpiredContext purges every task that stayed in the trash longer than
+// retention and returns how many were removed.
+func PurgeExpiredContext(ctx context.Context, retention time.Duration) (int, error) {
+	expired, err := queryTasks(ctx, "SELECT "+TASK_COLUMNS+" FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < ?", time.Now().Add(-retention))
+
+	if err != nil {
+		return 0, err
+	}
+
+	for i, task := range expired {
+		err := task.PurgeContext(ctx, 0)
+
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(expired), nil
+}
@@ -0,0 +1,104 @@
+package task
+
+import (
+	"strings"
+	"time"
+	"todolist/preferences"
+	"todolist/utils"
+)
+
+// the smart views, computed from the dates of the tasks in the timezone of
+// the user
+const (
+	TODAY_VIEW     = "today"
+	UPCOMING_VIEW  = "upcoming"
+	OVERDUE_VIEW   = "overdue"
+	NO_DATE_VIEW   = "no-date"
+	COMPLETED_VIEW = "completed"
+)
+
+var VIEWS = []string{TODAY_VIEW, UPCOMING_VIEW, OVERDUE_VIEW, NO_DATE_VIEW, COMPLETED_VIEW}
+
+const (
+	UPCOMING_DAYS = 7
+)
+
+func IsView(name string) bool {
+	for _, view := range VIEWS {
+		if name == view {
+			return true
+		}
+	}
+
+	return false
+}
+
+// InView tells whether the task belongs to the view at now, days follow the
+// timezone and the week the start day of p:
+//
+//   - today: open tasks due today or earlier, or beginning today
+//   - upcoming: open tasks due, or beginning when they have no end date, in
+//     the next UPCOMING_DAYS days after today
+//   - overdue: open tasks due before today
+//   - no-date: open tasks without begin nor end date
+//   - completed: tasks completed since the start of the week
+func (t *Task) InView(view string, now time.Time, p preferences.Preferences) bool {
+	loc := p.Location()
+
+	if view == COMPLETED_VIEW {
+		return t.Completed && !t.CompletedAt.IsZero() && !t.CompletedAt.Before(p.StartOfWeek(now))
+	}
+
+	if t.Completed {
+		return false
+	}
+
+	switch view {
+	case TODAY_VIEW:
+		return t.IsDueOn(now, loc) || t.IsOverdue(now, loc) || (!t.BeginDate.IsZero() && utils.SameDay(t.BeginDate, now, loc))
+	case UPCOMING_VIEW:
+		date := t.EndDate
+
+		if date.IsZero() {
+			date = t.BeginDate
+		}
+
+		if date.IsZero() {
+			return false
+		}
+
+		days := daysBetween(now, date, loc)
+
+		return days > 0 && days <= UPCOMING_DAYS
+	case OVERDUE_VIEW:
+		return t.IsOverdue(now, loc)
+	case NO_DATE_VIEW:
+		return t.BeginDate.IsZero() && t.EndDate.IsZero()
+	}
+
+	return false
+}
+
+// FilterView returns the tasks in the view, the most urgent first, and the
+// most recently completed first in the completed view.
+func FilterView(tasks []Task, view string, now time.Time, p preferences.Preferences) ([]Task, error) {
+	if !IsView(view) {
+		return nil, utils.ValidationErrors{{Field: "view", Message: "must be one of " + strings.Join(VIEWS, ", ")}}
+	}
+
+	filtered := []Task{}
+
+	for _, task := range tasks {
+		if task.InView(view, now, p) {
+			filtered = append(filtered, task)
+		}
+	}
+
+	by := URGENCY_SORT
+
+	if view == COMPLETED_VIEW {
+		by = COMPLETED_SORT
+	}
+
+	return filtered, SortTasks(filtered, by, now)
+}
@@ -0,0 +1,109 @@
+package task
+
+import (
+	"testing"
+	"time"
+	"todolist/preferences"
+	"todolist/utils"
+)
+
+func TestFilterView(t *testing.T) {
+	p := preferences.Default()
+	p.Timezone = "America/New_York"
+	loc := p.Location()
+
+	// Tuesday evening in New York, already Wednesday in UTC
+	now := time.Date(2026, time.March, 3, 21, 0, 0, 0, loc)
+	at := func(day int, hour int) time.Time {
+		return time.Date(2026, time.March, day, hour, 0, 0, 0, loc)
+	}
+
+	tasks := []Task{
+		{Id: 1, Name: "Due today", EndDate: at(3, 23)},
+		{Id: 2, Name: "Overdue", EndDate: at(2, 9)},
+		{Id: 3, Name: "Begins today", BeginDate: at(3, 10), EndDate: at(10, 9)},
+		{Id: 4, Name: "Tomorrow", EndDate: at(4, 12)},
+		{Id: 5, Name: "Later", EndDate: at(20, 9)},
+		{Id: 6, Name: "Someday"},
+		{Id: 7, Name: "Done on Monday", Completed: true, CompletedAt: at(2, 9), EndDate: at(2, 9)},
+		{Id: 8, Name: "Done on Sunday", Completed: true, CompletedAt: at(1, 9)},
+		{Id: 9, Name: "Done today", Completed: true, CompletedAt: at(3, 8)},
+	}
+
+	cases := []struct {
+		view     string
+		expected []int64
+	}{
+		{TODAY_VIEW, []int64{2, 1, 3}},
+		{UPCOMING_VIEW, []int64{4, 3}},
+		{OVERDUE_VIEW, []int64{2}},
+		{NO_DATE_VIEW, []int64{6}},
+		{COMPLETED_VIEW, []int64{9, 7}},
+	}
+
+	for _, c := range cases {
+		filtered, err := FilterView(tasks, c.view, now, p)
+
+		if err != nil {
+			t.Fatal("Error should be nil but got", err)
+		}
+
+		var ids []int64
+
+		for _, task := range filtered {
+			ids = append(ids, task.Id)
+		}
+
+		if len(ids) != len(c.expected) {
+			t.Error("View", c.view, "should be", c.expected, "but got", ids)
+			continue
+		}
+
+		for i := range ids {
+			if ids[i] != c.expected[i] {
+				t.Error("View", c.view, "should be", c.expected, "but got", ids)
+				break
+			}
+		}
+	}
+
+	if _, err := FilterView(tasks, "tomorrow", now, p); err == nil {
+		t.Error("Unknown view should return an error")
+	}
+}
+
+func TestCompletedAt(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := NewTask(TASK_NAME)
+	task.Save()
+
+	if !task.CompletedAt.IsZero() {
+		t.Error("Open task should not have a completion date but got", task.CompletedAt)
+	}
+
+	task.Completed = true
+	task.Save()
+	completedAt := task.CompletedAt
+
+	if completedAt.IsZero() {
+		t.Fatal("Completed task should have a completion date")
+	}
+
+	task.Name = "Renamed"
+	task.Save()
+	task = GetTask(task.Id)
+
+	if !task.CompletedAt.Equal(completedAt) {
+		t.Error("Completion date should stay", completedAt, "but got", task.CompletedAt)
+	}
+
+	task.Completed = false
+	task.Save()
+	task = GetTask(task.Id)
+
+	if !task.CompletedAt.IsZero() {
+		t.Error("Reopened task should not have a completion date but got", task.CompletedAt)
+	}
+}
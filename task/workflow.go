@@ -0,0 +1,525 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"todolist/permission"
+	"todolist/utils"
+)
+
+// the statuses of the default workflow, the migration of the databases
+// created before the workflows maps the completed tasks to DONE_STATUS and
+// the others to TODO_STATUS
+const (
+	TODO_STATUS        = "todo"
+	IN_PROGRESS_STATUS = "in progress"
+	REVIEW_STATUS      = "review"
+	DONE_STATUS        = "done"
+)
+
+// BLOCKED_STATUS is not in the default workflow, the lists adding a status
+// named exactly so lower the urgency of its tasks, a status with any other
+// name does not.
+const (
+	BLOCKED_STATUS = "blocked"
+)
+
+// Status is a column of the board of a list. A task in a Terminal status is
+// completed, a WIPLimit of 0 lets any number of tasks in the status.
+type Status struct {
+	Name     string `json:"name"`
+	Terminal bool   `json:"terminal"`
+	WIPLimit int    `json:"wip_limit"`
+}
+
+type Transition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Workflow lists the statuses of the tasks of a list in the order of the
+// board, new tasks start in the first one. Without Transitions a task may
+// move from any status to any other.
+type Workflow struct {
+	ListId      int64        `json:"list_id"`
+	Statuses    []Status     `json:"statuses"`
+	Transitions []Transition `json:"transitions"`
+}
+
+// StatusChange is a move of a task from one status to another, From is
+// empty when the task was created.
+type StatusChange struct {
+	Id        int64     `json:"id"`
+	TaskId    int64     `json:"task_id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	ActorId   int64     `json:"actor_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BoardColumn struct {
+	Status Status `json:"status"`
+	Tasks  []Task `json:"tasks"`
+}
+
+// DefaultWorkflow is the workflow of the tasks without a list and of the
+// lists that were not given their own.
+func DefaultWorkflow() Workflow {
+	return Workflow{
+		Statuses: []Status{
+			{Name: TODO_STATUS},
+			{Name: IN_PROGRESS_STATUS},
+			{Name: REVIEW_STATUS},
+			{Name: DONE_STATUS, Terminal: true},
+		},
+	}
+}
+
+func (w *Workflow) Validate() utils.ValidationErrors {
+	var errs utils.ValidationErrors
+
+	if w.ListId == 0 {
+		errs.Add("list_id", "is required")
+	}
+
+	if len(w.Statuses) == 0 {
+		errs.Add("statuses", "are required")
+		return errs
+	}
+
+	seen := map[string]bool{}
+	terminal := false
+
+	for i, status := range w.Statuses {
+		field := fmt.Sprintf("statuses[%d]", i)
+
+		if strings.TrimSpace(status.Name) == "" {
+			errs.Add(field+".name", "is required")
+		} else if seen[status.Name] {
+			errs.Add(field+".name", fmt.Sprintf("%q is repeated", status.Name))
+		}
+
+		if status.WIPLimit < 0 {
+			errs.Add(field+".wip_limit", "must not be negative")
+		}
+
+		seen[status.Name] = true
+		terminal = terminal || status.Terminal
+	}
+
+	if w.Statuses[0].Terminal {
+		errs.Add("statuses[0].terminal", "the first status must not be terminal")
+	}
+
+	if !terminal {
+		errs.Add("statuses", "need a terminal status")
+	}
+
+	for i, transition := range w.Transitions {
+		if !seen[transition.From] || !seen[transition.To] {
+			errs.Add(fmt.Sprintf("transitions[%d]", i), fmt.Sprintf("%q to %q is not between known statuses", transition.From, transition.To))
+		}
+	}
+
+	return errs
+}
+
+func (w *Workflow) Status(name string) (Status, bool) {
+	for _, status := range w.Statuses {
+		if status.Name == name {
+			return status, true
+		}
+	}
+
+	return Status{}, false
+}
+
+// Initial returns the status of the new tasks.
+func (w *Workflow) Initial() string {
+	return w.Statuses[0].Name
+}
+
+// Done returns the first terminal status, where completing a task moves it.
+func (w *Workflow) Done() string {
+	for _, status := range w.Statuses {
+		if status.Terminal {
+			return status.Name
+		}
+	}
+
+	return ""
+}
+
+func (w *Workflow) Allows(from string, to string) bool {
+	if len(w.Transitions) == 0 || from == to {
+		return true
+	}
+
+	for _, transition := range w.Transitions {
+		if transition.From == from && transition.To == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+func GetWorkflow(listId int64) (Workflow, error) {
+	return GetWorkflowContext(context.Background(), listId)
+}
+
+// GetWorkflowContext returns the workflow of the list, the default one when
+// the list has none.
+func GetWorkflowContext(ctx context.Context, listId int64) (Workflow, error) {
+	return getWorkflow(ctx, utils.SqliteInstance.DB, listId)
+}
+
+func getWorkflow(ctx context.Context, exec utils.Executor, listId int64) (Workflow, error) {
+	workflow := Workflow{ListId: listId}
+
+	rows, err := exec.QueryContext(ctx, "SELECT name, terminal, wip_limit FROM list_statuses WHERE list_id = ? ORDER BY position", listId)
+
+	if err != nil {
+		return Workflow{}, fmt.Errorf("Querying workflow of list %d: %w", listId, err)
+	}
+
+	for rows.Next() {
+		var status Status
+
+		err = rows.Scan(&status.Name, &status.Terminal, &status.WIPLimit)
+
+		if err != nil {
+			rows.Close()
+			return Workflow{}, fmt.Errorf("Scanning status: %w", err)
+		}
+
+		workflow.Statuses = append(workflow.Statuses, status)
+	}
+
+	rows.Close()
+
+	if len(workflow.Statuses) == 0 {
+		workflow.Statuses = DefaultWorkflow().Statuses
+		return workflow, nil
+	}
+
+	rows, err = exec.QueryContext(ctx, "SELECT from_status, to_status FROM status_transitions WHERE list_id = ? ORDER BY rowid", listId)
+
+	if err != nil {
+		return Workflow{}, fmt.Errorf("Querying transitions of list %d: %w", listId, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var transition Transition
+
+		err = rows.Scan(&transition.From, &transition.To)
+
+		if err != nil {
+			return Workflow{}, fmt.Errorf("Scanning transition: %w", err)
+		}
+
+		workflow.Transitions = append(workflow.Transitions, transition)
+	}
+
+	return workflow, rows.Err()
+}
+
+func (w *Workflow) SaveAs(actorId int64) error {
+	return w.SaveContext(context.Background(), actorId)
+}
+
+// SaveContext replaces the workflow of the list, only its owners may change
+// it. The tasks in a status that no longer exists go back to the first
+// status, or to the first terminal one when they are completed, then every
+// task is completed when its status is terminal and reopened otherwise.
+func (w *Workflow) SaveContext(ctx context.Context, actorId int64) (err error) {
+	if errs := w.Validate(); len(errs) > 0 {
+		return errs
+	}
+
+	tx, err := utils.SqliteInstance.BeginTx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil && !tx.Committed {
+			tx.Rollback()
+		}
+	}()
+
+	err = authorizeList(ctx, tx, actorId, w.ListId, permission.SHARE_ACTION)
+
+	if err != nil {
+		return err
+	}
+
+	for _, query := range []string{"DELETE FROM list_statuses WHERE list_id = ?", "DELETE FROM status_transitions WHERE list_id = ?"} {
+		_, err = tx.ExecContext(ctx, query, w.ListId)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, status := range w.Statuses {
+		_, err = tx.ExecContext(ctx, "INSERT INTO list_statuses (list_id, name, position, terminal, wip_limit) VALUES (?, ?, ?, ?, ?)", w.ListId, status.Name, i, status.Terminal, status.WIPLimit)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, transition := range w.Transitions {
+		_, err = tx.ExecContext(ctx, "INSERT INTO status_transitions (list_id, from_status, to_status) VALUES (?, ?, ?)", w.ListId, transition.From, transition.To)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = w.remap(ctx, tx, actorId)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// remap makes the tasks of the list agree with the workflow. The tasks are
+// stored one by one so that their history, their status history and their
+// events are recorded as for any other save, the transitions and the WIP
+// limits do not apply.
+func (w *Workflow) remap(ctx context.Context, exec utils.Executor, actorId int64) error {
+	rows, err := exec.QueryContext(ctx, "SELECT id FROM tasks WHERE list_id = ? ORDER BY id", w.ListId)
+
+	if err != nil {
+		return fmt.Errorf("Querying tasks of list %d: %w", w.ListId, err)
+	}
+
+	var ids []int64
+
+	for rows.Next() {
+		var id int64
+
+		err = rows.Scan(&id)
+
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("Querying tasks of list %d: %w", w.ListId, err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("Querying tasks of list %d: %w", w.ListId, err)
+	}
+
+	for _, id := range ids {
+		old, err := getTask(ctx, exec, id)
+
+		if err != nil {
+			return err
+		}
+
+		task := old
+		status, known := w.Status(task.Status)
+
+		// a removed status sends its tasks to the first status, or to the
+		// first terminal one when they are completed
+		if !known {
+			task.Status = w.Initial()
+
+			if task.Completed {
+				task.Status = w.Done()
+			}
+
+			status, _ = w.Status(task.Status)
+		}
+
+		task.Completed = status.Terminal
+
+		if task.Status == old.Status && task.Completed == old.Completed {
+			continue
+		}
+
+		err = task.write(ctx, exec, actorId, old, true)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Task) Move(actorId int64, status string) error {
+	return t.MoveContext(context.Background(), actorId, status)
+}
+
+// MoveContext puts the task in status and saves it, the task is completed
+// when the status is terminal.
+func (t *Task) MoveContext(ctx context.Context, actorId int64, status string) error {
+	previous, completed := t.Status, t.Completed
+	t.Status = status
+
+	err := t.SaveContext(ctx, actorId)
+
+	if err != nil {
+		t.Status, t.Completed = previous, completed
+	}
+
+	return err
+}
+
+// resolveStatus makes the status of the task agree with Completed before it
+// is saved. An explicit change of status wins and sets Completed, otherwise
+// completing or reopening the task moves it to the first terminal status or
+// to the first status. A task changing list keeps its status when the new
+// workflow has it, an empty status keeps the stored one.
+func (t *Task) resolveStatus(ctx context.Context, exec utils.Executor, old Task, exist bool) error {
+	workflow, err := getWorkflow(ctx, exec, t.ListId)
+
+	if err != nil {
+		return err
+	}
+
+	if t.Status == "" {
+		t.Status = old.Status
+	}
+
+	status, known := workflow.Status(t.Status)
+	moved := exist && t.Status != old.Status
+
+	if !known && moved {
+		return utils.ValidationErrors{{Field: "status", Message: fmt.Sprintf("%q is not a status of the workflow", t.Status)}}
+	}
+
+	if !moved && (!known || status.Terminal != t.Completed) {
+		t.Status = workflow.Initial()
+
+		if t.Completed {
+			t.Status = workflow.Done()
+		}
+
+		status, _ = workflow.Status(t.Status)
+	}
+
+	t.Completed = status.Terminal
+
+	if exist && t.Status == old.Status && t.ListId == old.ListId {
+		return nil
+	}
+
+	if exist && t.ListId == old.ListId && !workflow.Allows(old.Status, t.Status) {
+		return utils.ValidationErrors{{Field: "status", Message: fmt.Sprintf("can not move from %q to %q", old.Status, t.Status)}}
+	}
+
+	if status.WIPLimit == 0 {
+		return nil
+	}
+
+	var count int
+
+	row := exec.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks WHERE list_id = ? AND status = ? AND deleted_at IS NULL AND id != ?", t.ListId, t.Status, t.Id)
+	err = row.Scan(&count)
+
+	if err != nil {
+		return fmt.Errorf("Counting tasks in %q: %w", t.Status, err)
+	}
+
+	if count >= status.WIPLimit {
+		return utils.ValidationErrors{{Field: "status", Message: fmt.Sprintf("%q has reached its WIP limit of %d", t.Status, status.WIPLimit)}}
+	}
+
+	return nil
+}
+
+func recordStatus(ctx context.Context, exec utils.Executor, taskId int64, from string, to string, actorId int64) error {
+	_, err := exec.ExecContext(ctx, "INSERT INTO status_history (task_id, from_status, to_status, actor_id, created_at) VALUES (?, ?, ?, ?, ?)", taskId, from, to, actorId, time.Now())
+
+	if err != nil {
+		return fmt.Errorf("Recording status of task %d: %w", taskId, err)
+	}
+
+	return nil
+}
+
+func (t *Task) StatusHistory() ([]StatusChange, error) {
+	return t.StatusHistoryContext(context.Background())
+}
+
+// StatusHistoryContext returns the moves of the task, oldest first.
+func (t *Task) StatusHistoryContext(ctx context.Context) ([]StatusChange, error) {
+	var changes []StatusChange
+
+	rows, err := utils.SqliteInstance.DB.QueryContext(ctx, "SELECT id, task_id, from_status, to_status, actor_id, created_at FROM status_history WHERE task_id = ? ORDER BY id", t.Id)
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying status history: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var change StatusChange
+		var createdAt sql.NullTime
+
+		err := rows.Scan(&change.Id, &change.TaskId, &change.From, &change.To, &change.ActorId, &createdAt)
+
+		if err != nil {
+			return nil, fmt.Errorf("Scanning status change: %w", err)
+		}
+
+		change.CreatedAt = createdAt.Time
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func GetBoard(listId int64) ([]BoardColumn, error) {
+	return GetBoardContext(context.Background(), listId)
+}
+
+// GetBoardContext returns the tasks of the list grouped by status, in the
+// order of the workflow.
+func GetBoardContext(ctx context.Context, listId int64) ([]BoardColumn, error) {
+	workflow, err := GetWorkflowContext(ctx, listId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := GetTasksByListIdContext(ctx, listId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var board []BoardColumn
+
+	for _, status := range workflow.Statuses {
+		column := BoardColumn{Status: status, Tasks: []Task{}}
+
+		for _, task := range tasks {
+			if task.Status == status.Name {
+				column.Tasks = append(column.Tasks, task)
+			}
+		}
+
+		board = append(board, column)
+	}
+
+	return board, nil
+}
@@ -0,0 +1,202 @@
+package task
+
+import (
+	"errors"
+	"testing"
+	"todolist/events"
+	"todolist/history"
+	"todolist/permission"
+	"todolist/utils"
+)
+
+func TestDefaultWorkflow(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := NewTask(TASK_NAME)
+	task.Save()
+
+	if task.Status != TODO_STATUS || task.Completed {
+		t.Error("New task should be", TODO_STATUS, "but got", task.Status, task.Completed)
+	}
+
+	task.Complete()
+	task.Save()
+
+	if task.Status != DONE_STATUS {
+		t.Error("Completed task should be", DONE_STATUS, "but got", task.Status)
+	}
+
+	if err := task.Move(0, REVIEW_STATUS); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if task.Completed {
+		t.Error("Task in review should not be completed")
+	}
+	if err := task.Move(0, "blocked"); err == nil || task.Status != REVIEW_STATUS {
+		t.Error("Unknown status should return an error and keep", REVIEW_STATUS, "but got", err, task.Status)
+	}
+
+	changes, _ := task.StatusHistory()
+	expected := [][2]string{{"", TODO_STATUS}, {TODO_STATUS, DONE_STATUS}, {DONE_STATUS, REVIEW_STATUS}}
+
+	if len(changes) != len(expected) {
+		t.Fatal("Status history should be", expected, "but got", changes)
+	}
+	for i, change := range changes {
+		if change.From != expected[i][0] || change.To != expected[i][1] {
+			t.Error("Change", i, "should be", expected[i], "but got", change.From, change.To)
+		}
+	}
+}
+
+func TestWorkflow(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	share(1, 1, permission.OWNER_ROLE)
+	share(1, 2, permission.EDITOR_ROLE)
+
+	task := NewTask(TASK_NAME)
+	task.UserId = 1
+	task.ListId = 1
+	task.Completed = true
+	task.Save()
+
+	workflow := Workflow{
+		ListId: 1,
+		Statuses: []Status{
+			{Name: "backlog"},
+			{Name: "doing", WIPLimit: 1},
+			{Name: "shipped", Terminal: true},
+		},
+		Transitions: []Transition{{"backlog", "doing"}, {"doing", "shipped"}, {"doing", "backlog"}},
+	}
+
+	if err := workflow.SaveAs(2); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Editor should not change the workflow but got", err)
+	}
+	if err := (&Workflow{ListId: 1, Statuses: []Status{{Name: "done", Terminal: true}}}).SaveAs(1); err == nil {
+		t.Error("Workflow starting with a terminal status should return an error")
+	}
+	if err := workflow.SaveAs(1); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	// the task was done in the default workflow
+	task = GetTask(task.Id)
+
+	if task.Status != "shipped" || !task.Completed {
+		t.Error("Completed task should be migrated to shipped but got", task.Status)
+	}
+
+	first := NewTask(TASK_NAME)
+	first.UserId = 1
+	first.ListId = 1
+	first.Save()
+
+	if first.Status != "backlog" {
+		t.Error("New task should be in backlog but got", first.Status)
+	}
+	if err := first.Move(2, "shipped"); err == nil {
+		t.Error("Skipping doing should return an error")
+	}
+	if err := first.Move(2, "doing"); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	second := NewTask(TASK_NAME)
+	second.UserId = 1
+	second.ListId = 1
+	second.Save()
+
+	if err := second.Move(1, "doing"); err == nil {
+		t.Error("WIP limit should return an error")
+	}
+
+	var completed []events.TaskCompleted
+	events.Subscribe(events.TASK_COMPLETED, func(event events.Event) error {
+		completed = append(completed, event.(events.TaskCompleted))
+		return nil
+	})
+	defer events.BusInstance.Reset()
+
+	// backlog can not go straight to shipped
+	second.Complete()
+
+	if err := second.SaveAs(1); err == nil {
+		t.Error("Completing a task in backlog should return an error")
+	}
+	if len(completed) != 0 {
+		t.Error("Rejected completion should not be published but got", completed)
+	}
+
+	second.Complete()
+	if err := first.Move(1, "shipped"); err != nil || !first.Completed {
+		t.Error("Task should be shipped and completed but got", err, first.Completed)
+	}
+	if len(completed) != 1 || completed[0].TaskId != first.Id {
+		t.Error("Shipping should publish the completion but got", completed)
+	}
+	if err := second.Move(1, "doing"); err != nil {
+		t.Error("Error should be nil once the column is free but got", err)
+	}
+
+	board, _ := GetBoard(1)
+
+	if len(board) != 3 || len(board[0].Tasks) != 0 || len(board[1].Tasks) != 1 || len(board[2].Tasks) != 2 {
+		t.Error("Board should have 0, 1 and 2 tasks but got", board)
+	}
+
+	stored, _ := GetWorkflow(1)
+
+	if len(stored.Statuses) != 3 || stored.Statuses[1].WIPLimit != 1 || len(stored.Transitions) != 3 || !stored.Allows("doing", "backlog") || stored.Allows("backlog", "shipped") {
+		t.Error("Workflow should be stored but got", stored)
+	}
+
+	// doing becomes terminal and shipped stops being so
+	stored.Statuses[1].Terminal = true
+	stored.Statuses[2].Terminal = false
+
+	if err := stored.SaveAs(1); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	second, first = GetTask(second.Id), GetTask(first.Id)
+
+	if !second.Completed || second.CompletedAt.IsZero() {
+		t.Error("Task in a status turned terminal should be completed but got", second.Completed, second.CompletedAt)
+	}
+	if first.Completed || !first.CompletedAt.IsZero() {
+		t.Error("Task in a status no longer terminal should be reopened but got", first.Completed, first.CompletedAt)
+	}
+	if len(completed) != 2 || completed[1].TaskId != second.Id {
+		t.Error("Completion by the workflow should be published but got", completed)
+	}
+
+	entries, _ := second.History()
+
+	if last := entries[len(entries)-1]; last.Action != history.UPDATE_ACTION || last.ActorId != 1 || len(last.Changes) != 1 || last.Changes[0].Field != "completed" {
+		t.Error("Completion by the workflow should be audited but got", last)
+	}
+
+	// shipped is removed, the task in it goes back to backlog
+	stored.Statuses = stored.Statuses[:2]
+	stored.Transitions = nil
+
+	if err := stored.SaveAs(1); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	changes, _ := first.StatusHistory()
+
+	if last := changes[len(changes)-1]; last.From != "shipped" || last.To != "backlog" || last.ActorId != 1 {
+		t.Error("Move by the workflow should be in the status history but got", last)
+	}
+
+	entries, _ = first.History()
+
+	if last := entries[len(entries)-1]; last.Action != history.UPDATE_ACTION || last.ActorId != 1 || len(last.Changes) != 1 || last.Changes[0].Field != "status" {
+		t.Error("Move by the workflow should be audited but got", last)
+	}
+}
@@ -0,0 +1,148 @@
+package timer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+	taskLib "todolist/task"
+	"todolist/utils"
+)
+
+type TaskTotal struct {
+	TaskId   int64         `json:"task_id"`
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+}
+
+// LabelTotal sums the time of the tasks with the label, a task with several
+// labels counts in each of them and tasks without label are under "".
+type LabelTotal struct {
+	Label    string        `json:"label"`
+	Duration time.Duration `json:"duration"`
+}
+
+type Report struct {
+	From   time.Time     `json:"from"`
+	To     time.Time     `json:"to"`
+	Tasks  []TaskTotal   `json:"tasks"`
+	Labels []LabelTotal  `json:"labels"`
+	Total  time.Duration `json:"total"`
+}
+
+func GetReport(userId int64, from time.Time, to time.Time) (Report, error) {
+	return GetReportContext(context.Background(), userId, from, to)
+}
+
+// GetReportContext sums the time the user spent in [from, to), the entries
+// overlapping the bounds only count for their part inside and a running
+// timer counts until now. Tasks come longest first, labels in alphabetical
+// order.
+func GetReportContext(ctx context.Context, userId int64, from time.Time, to time.Time) (Report, error) {
+	report := Report{From: from, To: to, Tasks: []TaskTotal{}, Labels: []LabelTotal{}}
+
+	if !to.After(from) {
+		return Report{}, utils.ValidationErrors{{Field: "to", Message: "must be after from"}}
+	}
+
+	// the dates are stored as text in UTC, the bounds are compared in UTC too
+	rows, err := utils.SqliteInstance.DB.QueryContext(
+		ctx,
+		"SELECT e.task_id, e.started_at, e.ended_at, t.name, t.label FROM time_entries e LEFT JOIN tasks t ON t.id = e.task_id WHERE e.user_id = ? AND e.started_at < ? AND (e.ended_at IS NULL OR e.ended_at > ?) ORDER BY e.task_id",
+		userId, to.UTC(), from.UTC(),
+	)
+
+	if err != nil {
+		return Report{}, fmt.Errorf("Querying time entries: %w", err)
+	}
+
+	defer rows.Close()
+
+	tasks := map[int64]*TaskTotal{}
+	labels := map[string]time.Duration{}
+	now := time.Now()
+
+	for rows.Next() {
+		var taskId int64
+		var startedAt time.Time
+		var endedAt sql.NullTime
+		var name, label sql.NullString
+
+		err := rows.Scan(&taskId, &startedAt, &endedAt, &name, &label)
+
+		if err != nil {
+			return Report{}, fmt.Errorf("Scanning time entry: %w", err)
+		}
+
+		end := now
+
+		if endedAt.Valid {
+			end = endedAt.Time
+		}
+
+		duration := overlap(startedAt, end, from, to)
+
+		if duration <= 0 {
+			continue
+		}
+
+		if tasks[taskId] == nil {
+			tasks[taskId] = &TaskTotal{TaskId: taskId, Name: name.String}
+		}
+
+		tasks[taskId].Duration += duration
+		report.Total += duration
+
+		names := taskLib.SplitLabels(label.String)
+
+		// the time of the tasks without label is reported under ""
+		if len(names) == 0 {
+			names = []string{""}
+		}
+
+		for _, l := range names {
+			labels[l] += duration
+		}
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return Report{}, fmt.Errorf("Querying time entries: %w", err)
+	}
+
+	for _, total := range tasks {
+		report.Tasks = append(report.Tasks, *total)
+	}
+
+	sort.Slice(report.Tasks, func(i, j int) bool {
+		if report.Tasks[i].Duration != report.Tasks[j].Duration {
+			return report.Tasks[i].Duration > report.Tasks[j].Duration
+		}
+
+		return report.Tasks[i].TaskId < report.Tasks[j].TaskId
+	})
+
+	for label, duration := range labels {
+		report.Labels = append(report.Labels, LabelTotal{Label: label, Duration: duration})
+	}
+
+	sort.Slice(report.Labels, func(i, j int) bool {
+		return report.Labels[i].Label < report.Labels[j].Label
+	})
+
+	return report, nil
+}
+
+func overlap(start time.Time, end time.Time, from time.Time, to time.Time) time.Duration {
+	if start.Before(from) {
+		start = from
+	}
+
+	if end.After(to) {
+		end = to
+	}
+
+	return end.Sub(start)
+}
@@ -0,0 +1,330 @@
+package timer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"todolist/permission"
+	taskLib "todolist/task"
+	"todolist/utils"
+)
+
+// Entry is time spent by a user on a task, EndedAt is nil while the timer
+// runs.
+type Entry struct {
+	Id        int64      `json:"id"`
+	TaskId    int64      `json:"task_id"`
+	UserId    int64      `json:"user_id"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Note      string     `json:"note"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type EntryInterface interface {
+	Validate() utils.ValidationErrors
+	IsRunning() bool
+	Duration() time.Duration
+	Save() error
+	SaveAs(actorId int64) error
+	SaveContext(ctx context.Context, actorId int64) error
+	Delete() error
+	DeleteAs(actorId int64) error
+	DeleteContext(ctx context.Context, actorId int64) error
+}
+
+const (
+	ENTRY_COLUMNS = "id, task_id, user_id, started_at, ended_at, note, created_at"
+)
+
+var (
+	ErrEntryNotFound   = errors.New("Time entry not found")
+	ErrTimerRunning    = errors.New("A timer is already running")
+	ErrTimerNotRunning = errors.New("No timer is running")
+)
+
+// NewEntry returns a manual entry of duration ending at end.
+func NewEntry(taskId int64, userId int64, end time.Time, duration time.Duration) Entry {
+	return Entry{
+		TaskId:    taskId,
+		UserId:    userId,
+		StartedAt: end.Add(-duration),
+		EndedAt:   &end,
+		CreatedAt: time.Now(),
+	}
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEntry(row scanner, entry *Entry) error {
+	var endedAt sql.NullTime
+	var note sql.NullString
+
+	err := row.Scan(&entry.Id, &entry.TaskId, &entry.UserId, &entry.StartedAt, &endedAt, &note, &entry.CreatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	entry.EndedAt = nil
+	entry.Note = note.String
+
+	if endedAt.Valid {
+		entry.EndedAt = &endedAt.Time
+	}
+
+	return nil
+}
+
+func GetEntry(id int64) (Entry, error) {
+	return GetEntryContext(context.Background(), id)
+}
+
+func GetEntryContext(ctx context.Context, id int64) (Entry, error) {
+	var entry Entry
+
+	row := utils.SqliteInstance.DB.QueryRowContext(ctx, "SELECT "+ENTRY_COLUMNS+" FROM time_entries WHERE id = ?", id)
+	err := scanEntry(row, &entry)
+
+	if err == sql.ErrNoRows {
+		return Entry{}, fmt.Errorf("%w: %d", ErrEntryNotFound, id)
+	}
+
+	if err != nil {
+		return Entry{}, fmt.Errorf("Scanning time entry %d: %w", id, err)
+	}
+
+	return entry, nil
+}
+
+// GetEntriesByTaskId returns the time spent on the task by every user,
+// oldest first.
+func GetEntriesByTaskId(taskId int64) ([]Entry, error) {
+	return GetEntriesByTaskIdContext(context.Background(), taskId)
+}
+
+func GetEntriesByTaskIdContext(ctx context.Context, taskId int64) ([]Entry, error) {
+	return queryEntries(ctx, "SELECT "+ENTRY_COLUMNS+" FROM time_entries WHERE task_id = ? ORDER BY started_at, id", taskId)
+}
+
+func queryEntries(ctx context.Context, query string, args ...any) ([]Entry, error) {
+	var entries []Entry
+
+	rows, err := utils.SqliteInstance.DB.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying time entries: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry Entry
+
+		err := scanEntry(rows, &entry)
+
+		if err != nil {
+			return nil, fmt.Errorf("Scanning time entry: %w", err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying time entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Running returns the timer of the user, ErrTimerNotRunning when there is
+// none.
+func Running(userId int64) (Entry, error) {
+	return RunningContext(context.Background(), userId)
+}
+
+func RunningContext(ctx context.Context, userId int64) (Entry, error) {
+	return running(ctx, utils.SqliteInstance.DB, userId)
+}
+
+func running(ctx context.Context, exec utils.Executor, userId int64) (Entry, error) {
+	var entry Entry
+
+	row := exec.QueryRowContext(ctx, "SELECT "+ENTRY_COLUMNS+" FROM time_entries WHERE user_id = ? AND ended_at IS NULL", userId)
+	err := scanEntry(row, &entry)
+
+	if err == sql.ErrNoRows {
+		return Entry{}, ErrTimerNotRunning
+	}
+
+	if err != nil {
+		return Entry{}, fmt.Errorf("Scanning running timer: %w", err)
+	}
+
+	return entry, nil
+}
+
+func Start(taskId int64, userId int64, note string) (Entry, error) {
+	return StartContext(context.Background(), taskId, userId, note)
+}
+
+// StartContext starts a timer of the user on the task, a user runs one
+// timer at a time.
+func StartContext(ctx context.Context, taskId int64, userId int64, note string) (Entry, error) {
+	entry := Entry{TaskId: taskId, UserId: userId, StartedAt: time.Now(), Note: note}
+	entry.CreatedAt = entry.StartedAt
+
+	err := entry.SaveContext(ctx, userId)
+
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return entry, nil
+}
+
+func Stop(userId int64) (Entry, error) {
+	return StopContext(context.Background(), userId)
+}
+
+// StopContext ends the running timer of the user.
+func StopContext(ctx context.Context, userId int64) (Entry, error) {
+	entry, err := RunningContext(ctx, userId)
+
+	if err != nil {
+		return Entry{}, err
+	}
+
+	now := time.Now()
+	entry.EndedAt = &now
+
+	_, err = utils.SqliteInstance.DB.ExecContext(ctx, "UPDATE time_entries SET ended_at = ? WHERE id = ?", utc(entry.EndedAt), entry.Id)
+
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return entry, nil
+}
+
+func (e *Entry) IsRunning() bool {
+	return e.EndedAt == nil
+}
+
+// Duration returns the time spent so far on a running timer.
+func (e *Entry) Duration() time.Duration {
+	if e.EndedAt == nil {
+		return time.Since(e.StartedAt)
+	}
+
+	return e.EndedAt.Sub(e.StartedAt)
+}
+
+func (e *Entry) Validate() utils.ValidationErrors {
+	var errs utils.ValidationErrors
+
+	if e.TaskId == 0 {
+		errs.Add("task_id", "is required")
+	}
+
+	if e.UserId == 0 {
+		errs.Add("user_id", "is required")
+	}
+
+	if e.StartedAt.IsZero() {
+		errs.Add("started_at", "is required")
+	}
+
+	if e.EndedAt != nil && !e.EndedAt.After(e.StartedAt) {
+		errs.Add("ended_at", "must be after started_at")
+	}
+
+	if e.EndedAt != nil && e.EndedAt.After(time.Now()) {
+		errs.Add("ended_at", "must not be in the future")
+	}
+
+	return errs
+}
+
+func (e *Entry) Save() error {
+	return e.SaveAs(e.UserId)
+}
+
+func (e *Entry) SaveAs(actorId int64) error {
+	return e.SaveContext(context.Background(), actorId)
+}
+
+// SaveContext records the entry of actorId on a task the actor may edit.
+// Entries are not edited, delete and record them again.
+func (e *Entry) SaveContext(ctx context.Context, actorId int64) (err error) {
+	if e.Id != 0 {
+		return fmt.Errorf("Time entry %d is already saved", e.Id)
+	}
+
+	if errs := e.Validate(); len(errs) > 0 {
+		return errs
+	}
+
+	if actorId != 0 && actorId != e.UserId {
+		return fmt.Errorf("%w: user %d can not track time of user %d", permission.ErrForbidden, actorId, e.UserId)
+	}
+
+	err = taskLib.AuthorizeWrite(ctx, actorId, e.TaskId)
+
+	if err != nil {
+		return err
+	}
+
+	// the time_entries_running index keeps one running timer per user, even
+	// when two timers are started at once
+	res, err := utils.SqliteInstance.DB.ExecContext(ctx, "INSERT INTO time_entries (task_id, user_id, started_at, ended_at, note, created_at) VALUES (?, ?, ?, ?, ?, ?)", e.TaskId, e.UserId, e.StartedAt.UTC(), utc(e.EndedAt), e.Note, e.CreatedAt)
+
+	if utils.IsUniqueViolation(err) {
+		return ErrTimerRunning
+	}
+
+	if err != nil {
+		return err
+	}
+
+	e.Id, err = res.LastInsertId()
+
+	return err
+}
+
+func (e *Entry) Delete() error {
+	return e.DeleteAs(e.UserId)
+}
+
+func (e *Entry) DeleteAs(actorId int64) error {
+	return e.DeleteContext(context.Background(), actorId)
+}
+
+// DeleteContext removes an entry of actorId.
+func (e *Entry) DeleteContext(ctx context.Context, actorId int64) error {
+	if actorId != 0 && actorId != e.UserId {
+		return fmt.Errorf("%w: user %d can not delete time entry %d", permission.ErrForbidden, actorId, e.Id)
+	}
+
+	_, err := utils.SqliteInstance.DB.ExecContext(ctx, "DELETE FROM time_entries WHERE id = ?", e.Id)
+
+	return err
+}
+
+// utc returns t in UTC, the dates of the entries are stored in UTC so that
+// the report can compare them as text.
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	u := t.UTC()
+
+	return &u
+}
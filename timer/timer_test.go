@@ -0,0 +1,240 @@
+package timer
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+	"todolist/permission"
+	taskLib "todolist/task"
+	"todolist/utils"
+)
+
+func newTask(userId int64, label string) taskLib.Task {
+	task := taskLib.NewTask("Client work")
+	task.UserId = userId
+	task.Label = label
+	task.Save()
+
+	return task
+}
+
+func TestTimer(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := newTask(1, "")
+
+	if _, err := Stop(1); !errors.Is(err, ErrTimerNotRunning) {
+		t.Error("Error should be", ErrTimerNotRunning, "but got", err)
+	}
+	if _, err := Start(task.Id, 2, ""); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Stranger should not track time but got", err)
+	}
+
+	entry, err := Start(task.Id, 1, "Kickoff")
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if !entry.IsRunning() {
+		t.Error("Entry should be running")
+	}
+	if _, err := Start(task.Id, 1, ""); !errors.Is(err, ErrTimerRunning) {
+		t.Error("Error should be", ErrTimerRunning, "but got", err)
+	}
+
+	running, err := Running(1)
+
+	if err != nil || running.Id != entry.Id || running.Note != "Kickoff" {
+		t.Error("Running timer should be", entry.Id, "but got", running, err)
+	}
+
+	stopped, err := Stop(1)
+
+	if err != nil || stopped.IsRunning() || stopped.Duration() < 0 {
+		t.Error("Timer should be stopped but got", stopped, err)
+	}
+	if _, err := Start(task.Id, 1, ""); err != nil {
+		t.Error("Timer should start again but got", err)
+	}
+
+	entries, _ := GetEntriesByTaskId(task.Id)
+
+	if len(entries) != 2 {
+		t.Error("Task should have", 2, "entries but got", len(entries))
+	}
+}
+
+func TestConcurrentStart(t *testing.T) {
+	// a file database opens a connection per goroutine
+	utils.SqliteInstance, _ = utils.Open(utils.DRIVER, filepath.Join(t.TempDir(), utils.DB_FILE))
+	defer utils.SqliteInstance.Close()
+
+	task := newTask(1, "")
+	errs := make(chan error, 5)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := Start(task.Id, 1, "")
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	started := 0
+
+	for err := range errs {
+		if err == nil {
+			started++
+		}
+	}
+
+	if started != 1 {
+		t.Error("1 timer should start but got", started)
+	}
+}
+
+func TestManualEntry(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := newTask(1, "")
+	now := time.Now()
+
+	invalid := []Entry{
+		NewEntry(task.Id, 1, now, 0),
+		NewEntry(task.Id, 1, now.Add(time.Hour), time.Hour),
+		NewEntry(0, 1, now, time.Hour),
+	}
+
+	for _, entry := range invalid {
+		if err := entry.Save(); err == nil {
+			t.Error("Entry", entry, "should return an error")
+		}
+	}
+
+	entry := NewEntry(task.Id, 1, now, 90*time.Minute)
+
+	if err := entry.SaveAs(2); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("User should not record the time of another but got", err)
+	}
+	if err := entry.Save(); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if entry.Duration() != 90*time.Minute {
+		t.Error("Duration should be", 90*time.Minute, "but got", entry.Duration())
+	}
+
+	// a manual entry does not stop or block the timer
+	if _, err := Start(task.Id, 1, ""); err != nil {
+		t.Error("Error should be nil but got", err)
+	}
+
+	stored, _ := GetEntry(entry.Id)
+
+	if err := stored.DeleteAs(2); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("User should not delete the entry of another but got", err)
+	}
+	if err := stored.Delete(); err != nil {
+		t.Error("Error should be nil but got", err)
+	}
+	if _, err := GetEntry(entry.Id); !errors.Is(err, ErrEntryNotFound) {
+		t.Error("Error should be", ErrEntryNotFound, "but got", err)
+	}
+}
+
+func TestReport(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	website := newTask(1, "acme,design")
+	invoice := newTask(1, "acme")
+	other := newTask(1, "")
+
+	from := time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+
+	for _, entry := range []Entry{
+		NewEntry(website.Id, 1, from.Add(10*time.Hour), 2*time.Hour),
+		NewEntry(website.Id, 1, from.Add(30*time.Hour), time.Hour),
+		NewEntry(invoice.Id, 1, from.Add(time.Hour), 3*time.Hour), // 1 hour in the range
+		NewEntry(other.Id, 1, to.Add(time.Hour), time.Hour),       // after the range
+	} {
+		if err := entry.Save(); err != nil {
+			t.Fatal("Error should be nil but got", err)
+		}
+	}
+
+	report, err := GetReport(1, from, to)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if report.Total != 4*time.Hour {
+		t.Error("Total should be", 4*time.Hour, "but got", report.Total)
+	}
+	if len(report.Tasks) != 2 || report.Tasks[0].TaskId != website.Id || report.Tasks[0].Duration != 3*time.Hour || report.Tasks[1].Duration != time.Hour {
+		t.Error("Task totals are wrong:", report.Tasks)
+	}
+
+	expected := []LabelTotal{{"acme", 4 * time.Hour}, {"design", 3 * time.Hour}}
+
+	if len(report.Labels) != len(expected) {
+		t.Fatal("Label totals should be", expected, "but got", report.Labels)
+	}
+	for i, total := range expected {
+		if report.Labels[i] != total {
+			t.Error("Label total", i, "should be", total, "but got", report.Labels[i])
+		}
+	}
+
+	// the dates of another zone are compared in UTC
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	late := NewEntry(other.Id, 1, to.Add(30*time.Minute).In(zone), time.Hour) // 30 minutes in the range
+
+	if err := late.Save(); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if report, _ := GetReport(1, from, to); report.Total != 4*time.Hour+30*time.Minute {
+		t.Error("Total should be", 4*time.Hour+30*time.Minute, "but got", report.Total)
+	}
+
+	if report, _ := GetReport(2, from, to); len(report.Tasks) != 0 || report.Total != 0 {
+		t.Error("Report of another user should be empty but got", report)
+	}
+	if _, err := GetReport(1, to, from); err == nil {
+		t.Error("Reversed range should return an error")
+	}
+}
+
+func TestReportLegacyEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.db")
+	db, _ := utils.Open(utils.DRIVER, path)
+
+	// entries recorded with the offset of the server
+	db.DB.Exec("INSERT INTO time_entries (task_id, user_id, started_at, ended_at) VALUES (1, 1, '2026-03-09 01:30:00+02:00', '2026-03-09 02:30:00+02:00')")
+	db.Close()
+
+	utils.SqliteInstance, _ = utils.Open(utils.DRIVER, path)
+	defer utils.SqliteInstance.Close()
+
+	from := time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+	report, err := GetReport(1, from, from.AddDate(0, 0, 7))
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if report.Total != 30*time.Minute {
+		t.Error("Total should be", 30*time.Minute, "but got", report.Total)
+	}
+}
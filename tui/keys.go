@@ -0,0 +1,90 @@
+package tui
+
+import "unicode/utf8"
+
+var sequences = map[string]string{
+	"\x1b[A":  KEY_UP,
+	"\x1b[B":  KEY_DOWN,
+	"\x1b[C":  KEY_RIGHT,
+	"\x1b[D":  KEY_LEFT,
+	"\x1b[H":  KEY_HOME,
+	"\x1b[F":  KEY_END,
+	"\x1bOA":  KEY_UP,
+	"\x1bOB":  KEY_DOWN,
+	"\x1bOC":  KEY_RIGHT,
+	"\x1bOD":  KEY_LEFT,
+	"\x1bOH":  KEY_HOME,
+	"\x1bOF":  KEY_END,
+	"\x1b[1~": KEY_HOME,
+	"\x1b[4~": KEY_END,
+	"\x1b[Z":  KEY_SHIFT_TAB,
+}
+
+// DecodeKeys splits the bytes read from the terminal into key names, a
+// lone escape is KEY_ESC and unknown escape sequences are dropped.
+func DecodeKeys(input []byte) []string {
+	var keys []string
+
+	for len(input) > 0 {
+		switch input[0] {
+		case 0x1b:
+			key, size := decodeEscape(input)
+
+			if key != "" {
+				keys = append(keys, key)
+			}
+
+			input = input[size:]
+			continue
+		case '\r', '\n':
+			keys = append(keys, KEY_ENTER)
+		case '\t':
+			keys = append(keys, KEY_TAB)
+		case 0x7f, 0x08:
+			keys = append(keys, KEY_BACKSPACE)
+		case 0x03:
+			keys = append(keys, KEY_CTRL_C)
+		default:
+			r, size := utf8.DecodeRune(input)
+
+			if r != utf8.RuneError && r >= ' ' {
+				keys = append(keys, string(r))
+			}
+
+			input = input[size:]
+			continue
+		}
+
+		input = input[1:]
+	}
+
+	return keys
+}
+
+func decodeEscape(input []byte) (string, int) {
+	if len(input) == 1 {
+		return KEY_ESC, 1
+	}
+
+	for sequence, key := range sequences {
+		if len(input) >= len(sequence) && string(input[:len(sequence)]) == sequence {
+			return key, len(sequence)
+		}
+	}
+
+	if input[1] != '[' && input[1] != 'O' {
+		return KEY_ESC, 1
+	}
+
+	size := 2
+
+	for size < len(input) && (input[size] < 0x40 || input[size] > 0x7e) {
+		size++
+	}
+
+	if size < len(input) {
+		size++
+	}
+
+	return "", size
+}
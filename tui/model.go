@@ -0,0 +1,368 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"todolist/preferences"
+	taskLib "todolist/task"
+)
+
+const (
+	LIST_MODE   = "list"
+	EDIT_MODE   = "edit"
+	FILTER_MODE = "filter"
+)
+
+const (
+	KEY_UP        = "up"
+	KEY_DOWN      = "down"
+	KEY_LEFT      = "left"
+	KEY_RIGHT     = "right"
+	KEY_HOME      = "home"
+	KEY_END       = "end"
+	KEY_ENTER     = "enter"
+	KEY_ESC       = "esc"
+	KEY_TAB       = "tab"
+	KEY_SHIFT_TAB = "shift+tab"
+	KEY_BACKSPACE = "backspace"
+	KEY_CTRL_C    = "ctrl+c"
+)
+
+const (
+	EDIT_DATE_FORMAT = "2006-01-02 15:04"
+)
+
+var FIELDS = []string{"name", "description", "priority", "label", "location", "begin_date", "end_date"}
+
+type Model struct {
+	Tasks       []taskLib.Task
+	Visible     []int
+	Cursor      int
+	Mode        string
+	Field       int
+	Input       string
+	Filter      string
+	Message     string
+	Quit        bool
+	Preferences preferences.Preferences
+	Save        func(task *taskLib.Task) error
+	selected    int64
+}
+
+type ModelInterface interface {
+	Selected() *taskLib.Task
+	Update(key string)
+	View(width int, height int) string
+}
+
+func NewModel(tasks []taskLib.Task, p preferences.Preferences, save func(task *taskLib.Task) error) *Model {
+	m := &Model{
+		Tasks:       tasks,
+		Mode:        LIST_MODE,
+		Preferences: p,
+		Save:        save,
+	}
+
+	m.applyFilter()
+
+	return m
+}
+
+func (m *Model) Selected() *taskLib.Task {
+	if m.Cursor < 0 || m.Cursor >= len(m.Visible) {
+		return nil
+	}
+
+	return &m.Tasks[m.Visible[m.Cursor]]
+}
+
+func (m *Model) Update(key string) {
+	if key == KEY_CTRL_C {
+		m.Quit = true
+		return
+	}
+
+	switch m.Mode {
+	case EDIT_MODE:
+		m.updateEdit(key)
+	case FILTER_MODE:
+		m.updateFilter(key)
+	default:
+		m.updateList(key)
+	}
+}
+
+func (m *Model) updateList(key string) {
+	m.Message = ""
+
+	switch key {
+	case "q":
+		m.Quit = true
+	case KEY_UP, "k":
+		m.move(-1)
+	case KEY_DOWN, "j":
+		m.move(1)
+	case KEY_HOME, "g":
+		m.Cursor = 0
+	case KEY_END, "G":
+		m.Cursor = len(m.Visible) - 1
+	case " ", "x":
+		m.toggle()
+	case KEY_ENTER, "e":
+		if m.Selected() != nil {
+			m.Mode = EDIT_MODE
+			m.Field = 0
+			m.Input = fieldValue(m.Selected(), FIELDS[m.Field], m.Preferences.Location())
+		}
+	case "/":
+		m.Mode = FILTER_MODE
+	case "c":
+		m.Filter = ""
+		m.applyFilter()
+	}
+}
+
+func (m *Model) updateEdit(key string) {
+	switch key {
+	case KEY_ESC:
+		m.Mode = LIST_MODE
+		m.Input = ""
+	case KEY_TAB, KEY_DOWN:
+		m.selectField(m.Field + 1)
+	case KEY_SHIFT_TAB, KEY_UP:
+		m.selectField(m.Field - 1)
+	case KEY_ENTER:
+		m.commit()
+	case KEY_BACKSPACE:
+		m.Input = dropLastRune(m.Input)
+	default:
+		if isText(key) {
+			m.Input += key
+		}
+	}
+}
+
+func (m *Model) updateFilter(key string) {
+	switch key {
+	case KEY_ESC:
+		m.Filter = ""
+		m.Mode = LIST_MODE
+	case KEY_ENTER:
+		m.Mode = LIST_MODE
+	case KEY_BACKSPACE:
+		m.Filter = dropLastRune(m.Filter)
+	default:
+		if isText(key) {
+			m.Filter += key
+		}
+	}
+
+	m.applyFilter()
+}
+
+func (m *Model) move(delta int) {
+	m.Cursor += delta
+
+	if m.Cursor >= len(m.Visible) {
+		m.Cursor = len(m.Visible) - 1
+	}
+
+	if m.Cursor < 0 {
+		m.Cursor = 0
+	}
+}
+
+func (m *Model) selectField(index int) {
+	m.Field = (index + len(FIELDS)) % len(FIELDS)
+	m.Input = fieldValue(m.Selected(), FIELDS[m.Field], m.Preferences.Location())
+}
+
+func (m *Model) toggle() {
+	task := m.Selected()
+
+	if task == nil {
+		return
+	}
+
+	old := *task
+	task.Complete()
+
+	if err := m.Save(task); err != nil {
+		*task = old
+		m.Message = err.Error()
+	}
+}
+
+// commit writes the input to the edited field and saves the task, the task
+// is left untouched when the value is invalid.
+func (m *Model) commit() {
+	task := m.Selected()
+	old := *task
+
+	err := setField(task, FIELDS[m.Field], m.Input, m.Preferences.Location())
+
+	if err == nil {
+		if errs := task.Validate(); len(errs) > 0 {
+			err = errs
+		}
+	}
+
+	if err == nil {
+		err = m.Save(task)
+	}
+
+	if err != nil {
+		*task = old
+		m.Message = err.Error()
+		return
+	}
+
+	m.Message = fmt.Sprintf("Saved %s", FIELDS[m.Field])
+	m.applyFilter()
+}
+
+// applyFilter keeps the tasks matching every word of the filter: #word on
+// the label, !N or !name on the priority and any other word on the name. The
+// selected task stays selected while typing, even across filters that hide
+// it.
+func (m *Model) applyFilter() {
+	if task := m.Selected(); task != nil {
+		m.selected = task.Id
+	}
+
+	m.Visible = nil
+	m.Cursor = 0
+
+	for i, task := range m.Tasks {
+		if matchFilter(&task, m.Filter) {
+			if task.Id == m.selected {
+				m.Cursor = len(m.Visible)
+			}
+
+			m.Visible = append(m.Visible, i)
+		}
+	}
+}
+
+func matchFilter(task *taskLib.Task, filter string) bool {
+	for _, word := range strings.Fields(strings.ToLower(filter)) {
+		switch {
+		case strings.HasPrefix(word, "#") && len(word) > 1:
+			if !hasLabel(task.Label, word[1:]) {
+				return false
+			}
+		case strings.HasPrefix(word, "!") && len(word) > 1:
+			if priority, err := taskLib.ParsePriority(word[1:]); err != nil || priority != task.Priority {
+				return false
+			}
+		default:
+			if !strings.Contains(strings.ToLower(task.Name), word) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func hasLabel(labels string, label string) bool {
+	for _, l := range taskLib.SplitLabels(labels) {
+		if strings.ToLower(l) == label {
+			return true
+		}
+	}
+
+	return false
+}
+
+func fieldValue(task *taskLib.Task, field string, loc *time.Location) string {
+	if task == nil {
+		return ""
+	}
+
+	switch field {
+	case "name":
+		return task.Name
+	case "description":
+		return task.Description
+	case "priority":
+		return strconv.Itoa(task.Priority)
+	case "label":
+		return task.Label
+	case "location":
+		return task.Location
+	case "begin_date":
+		return formatEditDate(task.BeginDate, loc)
+	case "end_date":
+		return formatEditDate(task.EndDate, loc)
+	}
+
+	return ""
+}
+
+func setField(task *taskLib.Task, field string, value string, loc *time.Location) error {
+	var err error
+
+	switch field {
+	case "name":
+		task.Name = value
+	case "description":
+		task.Description = value
+	case "priority":
+		task.Priority, err = taskLib.ParsePriority(value)
+	case "label":
+		task.Label = value
+	case "location":
+		task.Location = value
+	case "begin_date":
+		task.BeginDate, err = parseEditDate(value, loc)
+	case "end_date":
+		task.EndDate, err = parseEditDate(value, loc)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Invalid %s %q", field, value)
+	}
+
+	return nil
+}
+
+func formatEditDate(date time.Time, loc *time.Location) string {
+	if date.IsZero() {
+		return ""
+	}
+
+	return date.In(loc).Format(EDIT_DATE_FORMAT)
+}
+
+// parseEditDate accepts a date with or without time of day, an empty value
+// clears the date.
+func parseEditDate(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if date, err := time.ParseInLocation(EDIT_DATE_FORMAT, value, loc); err == nil {
+		return date, nil
+	}
+
+	return time.ParseInLocation("2006-01-02", value, loc)
+}
+
+func isText(key string) bool {
+	return len([]rune(key)) == 1 && key >= " "
+}
+
+func dropLastRune(value string) string {
+	runes := []rune(value)
+
+	if len(runes) == 0 {
+		return value
+	}
+
+	return string(runes[:len(runes)-1])
+}
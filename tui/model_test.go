@@ -0,0 +1,288 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"todolist/preferences"
+	taskLib "todolist/task"
+)
+
+func newModel() (*Model, *[]int64) {
+	var saved []int64
+
+	tasks := []taskLib.Task{
+		{Id: 1, Name: "Buy milk", Label: "home", Priority: 1},
+		{Id: 2, Name: "Write report", Label: "work,urgent", Priority: 3},
+		{Id: 3, Name: "Call dentist", Label: "health", Priority: 3},
+	}
+
+	save := func(task *taskLib.Task) error {
+		saved = append(saved, task.Id)
+		return nil
+	}
+
+	p := preferences.Default()
+	p.Timezone = "UTC"
+
+	return NewModel(tasks, p, save), &saved
+}
+
+func press(m *Model, keys ...string) {
+	for _, key := range keys {
+		m.Update(key)
+	}
+}
+
+func typeText(m *Model, text string) {
+	for _, r := range text {
+		m.Update(string(r))
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	m, _ := newModel()
+
+	press(m, "j", KEY_DOWN, KEY_DOWN)
+
+	if m.Selected().Id != 3 {
+		t.Error("Selected should be", 3, "but got", m.Selected().Id)
+	}
+
+	press(m, "k")
+
+	if m.Selected().Id != 2 {
+		t.Error("Selected should be", 2, "but got", m.Selected().Id)
+	}
+
+	press(m, KEY_HOME, KEY_UP)
+
+	if m.Selected().Id != 1 {
+		t.Error("Selected should be", 1, "but got", m.Selected().Id)
+	}
+
+	press(m, "G")
+
+	if m.Selected().Id != 3 {
+		t.Error("Selected should be", 3, "but got", m.Selected().Id)
+	}
+
+	press(m, "q")
+
+	if !m.Quit {
+		t.Error("Quit should be", true, "but got", m.Quit)
+	}
+}
+
+func TestToggle(t *testing.T) {
+	m, saved := newModel()
+
+	press(m, "j", " ")
+
+	if !m.Tasks[1].Completed {
+		t.Error("Completed should be", true, "but got", m.Tasks[1].Completed)
+	}
+
+	if len(*saved) != 1 || (*saved)[0] != 2 {
+		t.Error("Saved should be", []int64{2}, "but got", *saved)
+	}
+
+	m.Save = func(task *taskLib.Task) error {
+		return fmt.Errorf("Database is locked")
+	}
+
+	press(m, "x")
+
+	if !m.Tasks[1].Completed {
+		t.Error("Completed should be", true, "but got", m.Tasks[1].Completed)
+	}
+
+	if m.Message != "Database is locked" {
+		t.Error("Message should be", "Database is locked", "but got", m.Message)
+	}
+}
+
+func TestEdit(t *testing.T) {
+	m, saved := newModel()
+
+	press(m, "e")
+
+	if m.Mode != EDIT_MODE || m.Input != "Buy milk" {
+		t.Error("Input should be", "Buy milk", "but got", m.Input)
+	}
+
+	press(m, KEY_BACKSPACE, KEY_BACKSPACE, KEY_BACKSPACE, KEY_BACKSPACE)
+	typeText(m, "eggs")
+	press(m, KEY_ENTER)
+
+	if m.Tasks[0].Name != "Buy eggs" {
+		t.Error("Name should be", "Buy eggs", "but got", m.Tasks[0].Name)
+	}
+
+	press(m, KEY_SHIFT_TAB)
+
+	if FIELDS[m.Field] != "end_date" {
+		t.Error("Field should be", "end_date", "but got", FIELDS[m.Field])
+	}
+
+	typeText(m, "2026-03-12 15:00")
+	press(m, KEY_ENTER)
+
+	expected := time.Date(2026, time.March, 12, 15, 0, 0, 0, time.UTC)
+
+	if !m.Tasks[0].EndDate.Equal(expected) {
+		t.Error("EndDate should be", expected, "but got", m.Tasks[0].EndDate)
+	}
+
+	if len(*saved) != 2 {
+		t.Error("Saved should have", 2, "entries but got", len(*saved))
+	}
+
+	press(m, KEY_ESC)
+
+	if m.Mode != LIST_MODE {
+		t.Error("Mode should be", LIST_MODE, "but got", m.Mode)
+	}
+}
+
+func TestEditInvalid(t *testing.T) {
+	m, saved := newModel()
+
+	press(m, "e", KEY_TAB, KEY_TAB)
+
+	if FIELDS[m.Field] != "priority" {
+		t.Error("Field should be", "priority", "but got", FIELDS[m.Field])
+	}
+
+	press(m, KEY_BACKSPACE)
+	typeText(m, "9")
+	press(m, KEY_ENTER)
+
+	if m.Tasks[0].Priority != 1 {
+		t.Error("Priority should be", 1, "but got", m.Tasks[0].Priority)
+	}
+
+	if !strings.Contains(m.Message, "priority") {
+		t.Error("Message should mention", "priority", "but got", m.Message)
+	}
+
+	press(m, KEY_BACKSPACE)
+	typeText(m, "urgent")
+	press(m, KEY_ENTER)
+
+	if m.Message != `Invalid priority "urgent"` {
+		t.Error("Message should be", `Invalid priority "urgent"`, "but got", m.Message)
+	}
+
+	if len(*saved) != 0 {
+		t.Error("Saved should be empty but got", *saved)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	cases := []struct {
+		filter   string
+		expected []int64
+	}{
+		{"", []int64{1, 2, 3}},
+		{"#work", []int64{2}},
+		{"#urgent", []int64{2}},
+		{"#wor", nil},
+		{"!3", []int64{2, 3}},
+		{"!3 #health", []int64{3}},
+		{"!low", []int64{1}},
+		{"MILK", []int64{1}},
+		{"call !1", nil},
+	}
+
+	for _, c := range cases {
+		m, _ := newModel()
+
+		press(m, "/")
+		typeText(m, c.filter)
+		press(m, KEY_ENTER)
+
+		var ids []int64
+
+		for _, index := range m.Visible {
+			ids = append(ids, m.Tasks[index].Id)
+		}
+
+		if fmt.Sprint(ids) != fmt.Sprint(c.expected) {
+			t.Error("Filter", c.filter, "should match", c.expected, "but got", ids)
+		}
+	}
+}
+
+func TestFilterKeepsSelection(t *testing.T) {
+	m, _ := newModel()
+
+	press(m, "G", "/")
+	typeText(m, "!3")
+
+	if m.Selected().Id != 3 {
+		t.Error("Selected should be", 3, "but got", m.Selected().Id)
+	}
+
+	press(m, KEY_ESC)
+
+	if m.Filter != "" || len(m.Visible) != 3 {
+		t.Error("Visible should have", 3, "tasks but got", len(m.Visible))
+	}
+
+	press(m, "/")
+	typeText(m, "nothing")
+	press(m, KEY_ENTER, " ", "e")
+
+	if m.Selected() != nil || m.Mode != LIST_MODE {
+		t.Error("Mode should be", LIST_MODE, "but got", m.Mode)
+	}
+}
+
+func TestView(t *testing.T) {
+	m, _ := newModel()
+
+	press(m, "j", "x", "e")
+
+	view := m.View(80, 10)
+	lines := strings.Split(view, "\r\n")
+
+	if len(lines) != 10 {
+		t.Error("View should have", 10, "lines but got", len(lines))
+	}
+
+	for _, expected := range []string{"todolist - 3/3 tasks", "[x] Write report", "Write report_", "work,urgent", EDIT_HELP} {
+		if !strings.Contains(view, expected) {
+			t.Error("View should contain", expected)
+		}
+	}
+
+	if m.View(10, 2) != "Terminal too small" {
+		t.Error("View should be", "Terminal too small", "but got", m.View(10, 2))
+	}
+}
+
+func TestDecodeKeys(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []string
+	}{
+		{"j", []string{"j"}},
+		{"\x1b[A\x1b[B", []string{KEY_UP, KEY_DOWN}},
+		{"\x1bOA", []string{KEY_UP}},
+		{"\x1b", []string{KEY_ESC}},
+		{"\x1b[Z\t\r", []string{KEY_SHIFT_TAB, KEY_TAB, KEY_ENTER}},
+		{"\x7f\x03", []string{KEY_BACKSPACE, KEY_CTRL_C}},
+		{"été", []string{"é", "t", "é"}},
+		{"\x1b[15~x", []string{"x"}},
+	}
+
+	for _, c := range cases {
+		keys := DecodeKeys([]byte(c.input))
+
+		if fmt.Sprint(keys) != fmt.Sprint(c.expected) {
+			t.Error("Keys of", c.input, "should be", c.expected, "but got", keys)
+		}
+	}
+}
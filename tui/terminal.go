@@ -0,0 +1,83 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package tui
+
+import (
+	"fmt"
+	"os"
+	"todolist/preferences"
+	taskLib "todolist/task"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	ENTER_SCREEN = "\x1b[?1049h\x1b[?25l"
+	LEAVE_SCREEN = "\x1b[?25h\x1b[?1049l"
+	CLEAR_SCREEN = "\x1b[H\x1b[2J"
+)
+
+// Run opens the full screen interface on the terminal until the user quits,
+// every change is persisted with save.
+func Run(tasks []taskLib.Task, p preferences.Preferences, save func(task *taskLib.Task) error) error {
+	in := int(os.Stdin.Fd())
+
+	old, err := unix.IoctlGetTermios(in, ioctlGetTermios)
+
+	if err != nil {
+		return fmt.Errorf("The tui needs an interactive terminal")
+	}
+
+	err = unix.IoctlSetTermios(in, ioctlSetTermios, makeRaw(*old))
+
+	if err != nil {
+		return err
+	}
+
+	defer unix.IoctlSetTermios(in, ioctlSetTermios, old)
+
+	fmt.Print(ENTER_SCREEN)
+	defer fmt.Print(LEAVE_SCREEN)
+
+	model := NewModel(tasks, p, save)
+	buffer := make([]byte, 64)
+
+	for !model.Quit {
+		width, height := size(in)
+		fmt.Print(CLEAR_SCREEN + model.View(width, height))
+
+		n, err := os.Stdin.Read(buffer)
+
+		if err != nil {
+			return err
+		}
+
+		for _, key := range DecodeKeys(buffer[:n]) {
+			model.Update(key)
+		}
+	}
+
+	return nil
+}
+
+func makeRaw(termios unix.Termios) *unix.Termios {
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+
+	return &termios
+}
+
+func size(fd int) (int, int) {
+	ws, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+
+	if err != nil || ws.Col == 0 || ws.Row == 0 {
+		return 80, 24
+	}
+
+	return int(ws.Col), int(ws.Row)
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package tui
+
+import (
+	"fmt"
+	"todolist/preferences"
+	taskLib "todolist/task"
+)
+
+func Run(tasks []taskLib.Task, p preferences.Preferences, save func(task *taskLib.Task) error) error {
+	return fmt.Errorf("The tui is not supported on this platform")
+}
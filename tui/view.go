@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	REVERSE = "\x1b[7m"
+	BOLD    = "\x1b[1m"
+	RESET   = "\x1b[0m"
+)
+
+const (
+	LIST_HELP   = "j/k move  space toggle  e edit  / filter  c clear  q quit"
+	EDIT_HELP   = "tab next field  enter save  esc back"
+	FILTER_HELP = "#label !priority text  enter apply  esc clear"
+)
+
+// View renders the model as width x height cells, lines are separated by
+// "\r\n" since the terminal is in raw mode.
+func (m *Model) View(width int, height int) string {
+	if width < 20 || height < 4 {
+		return "Terminal too small"
+	}
+
+	listWidth := width / 2
+	detailWidth := width - listWidth - 1
+	bodyHeight := height - 2
+
+	list := m.listLines(listWidth, bodyHeight)
+	details := m.detailLines(detailWidth)
+
+	lines := []string{BOLD + pad(m.title(), width) + RESET}
+
+	for i := 0; i < bodyHeight; i++ {
+		left := pad("", listWidth)
+		right := ""
+
+		if i < len(list) {
+			left = list[i]
+		}
+		if i < len(details) {
+			right = details[i]
+		}
+
+		lines = append(lines, left+"|"+right)
+	}
+
+	lines = append(lines, pad(m.status(), width))
+
+	return strings.Join(lines, "\r\n")
+}
+
+func (m *Model) title() string {
+	title := fmt.Sprintf("todolist - %d/%d tasks", len(m.Visible), len(m.Tasks))
+
+	if m.Filter != "" || m.Mode == FILTER_MODE {
+		title += " - filter: " + m.Filter
+	}
+
+	return title
+}
+
+func (m *Model) status() string {
+	if m.Message != "" {
+		return m.Message
+	}
+
+	switch m.Mode {
+	case EDIT_MODE:
+		return EDIT_HELP
+	case FILTER_MODE:
+		return FILTER_HELP
+	}
+
+	return LIST_HELP
+}
+
+// listLines renders the visible tasks, scrolled so that the cursor stays on
+// screen.
+func (m *Model) listLines(width int, height int) []string {
+	var lines []string
+
+	offset := 0
+
+	if m.Cursor >= height {
+		offset = m.Cursor - height + 1
+	}
+
+	for i := offset; i < len(m.Visible) && i < offset+height; i++ {
+		task := m.Tasks[m.Visible[i]]
+		completed := " "
+
+		if task.Completed {
+			completed = "x"
+		}
+
+		line := pad(fmt.Sprintf(" [%s] %s", completed, task.Name), width)
+
+		if i == m.Cursor {
+			line = REVERSE + line + RESET
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func (m *Model) detailLines(width int) []string {
+	task := m.Selected()
+
+	if task == nil {
+		return []string{" " + m.Preferences.T("no_tasks")}
+	}
+
+	lines := []string{
+		" " + truncate(fmt.Sprintf("%-13s %d", m.Preferences.T("id")+":", task.Id), width-1),
+		" " + truncate(fmt.Sprintf("%-13s %s", m.Preferences.T("completed")+":", m.Preferences.T(fmt.Sprint(task.Completed))), width-1),
+	}
+
+	for i, field := range FIELDS {
+		value := fieldValue(task, field, m.Preferences.Location())
+
+		if m.Mode == EDIT_MODE && i == m.Field {
+			value = m.Input + "_"
+		}
+
+		line := " " + truncate(fmt.Sprintf("%-13s %s", m.Preferences.T(field)+":", value), width-1)
+
+		if m.Mode == EDIT_MODE && i == m.Field {
+			line = REVERSE + line + RESET
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func truncate(value string, width int) string {
+	runes := []rune(value)
+
+	if width < 0 {
+		return ""
+	}
+
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+
+	return value
+}
+
+func pad(value string, width int) string {
+	value = truncate(value, width)
+	return value + strings.Repeat(" ", width-len([]rune(value)))
+}
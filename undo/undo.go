@@ -0,0 +1,215 @@
+package undo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"todolist/history"
+	taskLib "todolist/task"
+	"todolist/utils"
+)
+
+const (
+	UNDO_ACTION = "undo"
+	REDO_ACTION = "redo"
+)
+
+var (
+	ErrNothingToUndo = errors.New("Nothing to undo")
+	ErrNothingToRedo = errors.New("Nothing to redo")
+)
+
+// regular operations are the task history entries of a user that were not
+// written by an undo or a redo, purged tasks can not be brought back
+const regularOperations = `SELECT h.id FROM history h
+	WHERE h.entity = 'task' AND h.actor_id = ? AND h.action != 'purge'
+	AND h.entity_id NOT IN (SELECT entity_id FROM history WHERE entity = 'task' AND action = 'purge')
+	AND NOT EXISTS (SELECT 1 FROM undo_log u WHERE h.id BETWEEN u.result_from_id AND u.result_history_id)`
+
+// Undo reverts the last count operations of a user on tasks, most recent
+// first, and returns the history entries that were reverted.
+func Undo(userId int64, count int) ([]history.Entry, error) {
+	var undone []history.Entry
+
+	for i := 0; i < count; i++ {
+		var historyId int64
+
+		row := utils.SqliteInstance.DB.QueryRow(regularOperations+`
+			AND COALESCE((SELECT action FROM undo_log u WHERE u.history_id = h.id ORDER BY u.id DESC LIMIT 1), 'redo') != 'undo'
+			ORDER BY h.id DESC LIMIT 1`, userId)
+
+		err := row.Scan(&historyId)
+
+		if err == sql.ErrNoRows {
+			if len(undone) == 0 {
+				return nil, ErrNothingToUndo
+			}
+
+			break
+		}
+
+		if err != nil {
+			return undone, err
+		}
+
+		entry, err := replay(userId, historyId, UNDO_ACTION)
+
+		if err != nil {
+			return undone, err
+		}
+
+		undone = append(undone, entry)
+	}
+
+	return undone, nil
+}
+
+// Redo applies again the last count operations reverted by Undo, as long as
+// the user did not run another operation since.
+func Redo(userId int64, count int) ([]history.Entry, error) {
+	var redone []history.Entry
+
+	for i := 0; i < count; i++ {
+		var historyId int64
+		var cursor int64
+		var newer int
+
+		row := utils.SqliteInstance.DB.QueryRow(`SELECT u.history_id, u.history_cursor FROM undo_log u
+			WHERE u.user_id = ? AND u.action = 'undo'
+			AND u.id = (SELECT MAX(id) FROM undo_log WHERE history_id = u.history_id)
+			ORDER BY u.id DESC LIMIT 1`, userId)
+
+		err := row.Scan(&historyId, &cursor)
+
+		if err == nil {
+			row = utils.SqliteInstance.DB.QueryRow("SELECT COUNT(*) FROM ("+regularOperations+" AND h.id > ?)", userId, cursor)
+			err = row.Scan(&newer)
+		}
+
+		if err == sql.ErrNoRows || (err == nil && newer > 0) {
+			if len(redone) == 0 {
+				return nil, ErrNothingToRedo
+			}
+
+			break
+		}
+
+		if err != nil {
+			return redone, err
+		}
+
+		entry, err := replay(userId, historyId, REDO_ACTION)
+
+		if err != nil {
+			return redone, err
+		}
+
+		redone = append(redone, entry)
+	}
+
+	return redone, nil
+}
+
+// replay undoes or redoes the history entry in a single transaction, the
+// history entries it writes are logged as its results.
+func replay(userId int64, historyId int64, action string) (entry history.Entry, err error) {
+	ctx := context.Background()
+	tx, err := utils.SqliteInstance.BeginTx(ctx)
+
+	if err != nil {
+		return history.Entry{}, err
+	}
+
+	defer func() {
+		if err != nil && !tx.Committed {
+			tx.Rollback()
+		}
+	}()
+
+	entry, err = history.GetEntryWith(ctx, tx, historyId)
+
+	if err != nil {
+		return history.Entry{}, err
+	}
+
+	before, err := history.LastIdWith(ctx, tx)
+
+	if err != nil {
+		return history.Entry{}, err
+	}
+
+	err = apply(ctx, tx, userId, entry, action == UNDO_ACTION)
+
+	if err != nil {
+		return history.Entry{}, err
+	}
+
+	after, err := history.LastIdWith(ctx, tx)
+
+	if err != nil {
+		return history.Entry{}, err
+	}
+
+	var from, to any
+
+	if after > before {
+		from, to = before+1, after
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO undo_log (user_id, history_id, action, result_from_id, result_history_id, history_cursor, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		userId,
+		historyId,
+		action,
+		from,
+		to,
+		after,
+		time.Now(),
+	)
+
+	if err != nil {
+		return history.Entry{}, err
+	}
+
+	return entry, tx.Commit()
+}
+
+// apply brings the task of entry back to the state before the entry when old
+// is true, or to the state after it otherwise.
+func apply(ctx context.Context, exec utils.Executor, userId int64, entry history.Entry, old bool) error {
+	exists := entry.Action != history.CREATE_ACTION
+
+	if !old {
+		exists = entry.Action != history.DELETE_ACTION
+	}
+
+	// a task deleted before the trash existed has no row left, it is
+	// brought back from its history
+	task, err := taskLib.GetTaskWith(ctx, exec, entry.EntityId)
+
+	if err != nil && !errors.Is(err, taskLib.ErrTaskNotFound) {
+		return err
+	}
+
+	if !exists {
+		return task.DeleteWith(ctx, exec, userId)
+	}
+
+	task.Id = entry.EntityId
+	task.DeletedAt = nil
+
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
+		task.UpdatedAt = time.Now()
+	}
+
+	err = task.Apply(entry.Changes, old)
+
+	if err != nil {
+		return err
+	}
+
+	return task.RecreateWith(ctx, exec, userId)
+}
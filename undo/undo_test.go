@@ -0,0 +1,228 @@
+package undo
+
+import (
+	"strings"
+	"testing"
+	"todolist/history"
+	taskLib "todolist/task"
+	"todolist/utils"
+)
+
+func TestUndoRedoEdit(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := taskLib.NewTask("TestTask")
+	task.UserId = 1
+	task.Save()
+
+	task.Priority = 2
+	task.Save()
+
+	task.Complete()
+	task.Save()
+
+	undone, err := Undo(1, 2)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if len(undone) != 2 {
+		t.Fatal("2 operations should be undone but got", len(undone))
+	}
+
+	taskDB := taskLib.GetTask(task.Id)
+
+	if taskDB.Completed || taskDB.Priority != 0 {
+		t.Error("Task should be back to its initial state but is", taskDB)
+	}
+
+	redone, err := Redo(1, 1)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if len(redone) != 1 {
+		t.Fatal("1 operation should be redone but got", len(redone))
+	}
+
+	taskDB = taskLib.GetTask(task.Id)
+
+	if taskDB.Priority != 2 || taskDB.Completed {
+		t.Error("Only the priority change should be redone but task is", taskDB)
+	}
+
+	Redo(1, 1)
+
+	if !taskLib.GetTask(task.Id).Completed {
+		t.Error("Task should be completed again")
+	}
+
+	_, err = Redo(1, 1)
+
+	if err != ErrNothingToRedo {
+		t.Error("Error should be", ErrNothingToRedo, "but got", err)
+	}
+}
+
+func TestUndoDelete(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := taskLib.NewTask("TestTask")
+	task.UserId = 1
+	task.Label = "work"
+	task.Save()
+	task.Delete()
+
+	if taskLib.IsTaskExist(task.Id) {
+		t.Fatal("Task should be deleted")
+	}
+
+	_, err := Undo(1, 1)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	taskDB := taskLib.GetTask(task.Id)
+
+	if taskDB.Id != task.Id || taskDB.Name != task.Name || taskDB.Label != task.Label {
+		t.Error("Task should be restored but is", taskDB)
+	}
+
+	Undo(1, 1)
+
+	if taskLib.IsTaskExist(task.Id) {
+		t.Error("Undoing the creation should delete the task")
+	}
+
+	Redo(1, 2)
+
+	if taskLib.IsTaskExist(task.Id) {
+		t.Error("Redoing the creation and the deletion should leave the task deleted")
+	}
+}
+
+func TestRedoClearedByNewOperation(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := taskLib.NewTask("TestTask")
+	task.UserId = 1
+	task.Save()
+
+	task.Priority = 1
+	task.Save()
+
+	Undo(1, 1)
+
+	task = taskLib.GetTask(task.Id)
+	task.Location = "home"
+	task.Save()
+
+	_, err := Redo(1, 1)
+
+	if err != ErrNothingToRedo {
+		t.Error("Error should be", ErrNothingToRedo, "but got", err)
+	}
+}
+
+func TestUndoPerUser(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := taskLib.NewTask("TestTask")
+	task.UserId = 1
+	task.Save()
+
+	_, err := Undo(2, 1)
+
+	if err != ErrNothingToUndo {
+		t.Error("Error should be", ErrNothingToUndo, "but got", err)
+	}
+	if !taskLib.IsTaskExist(task.Id) {
+		t.Error("Task of another user should not be touched")
+	}
+}
+
+func TestUndoPurgedTask(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := taskLib.NewTask("TestTask")
+	task.UserId = 1
+	task.Save()
+
+	task.Priority = 2
+	task.Save()
+	task.Delete()
+	task.Purge()
+
+	if _, err := Undo(1, 1); err != ErrNothingToUndo {
+		t.Error("Error should be", ErrNothingToUndo, "but got", err)
+	}
+	if taskLib.GetTask(task.Id).Id != 0 {
+		t.Error("Purged task should not be brought back")
+	}
+}
+
+func TestUndoHardDeletedTask(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := taskLib.NewTask("TestTask")
+	task.UserId = 1
+	task.Save()
+
+	// tasks deleted before the trash existed lost their row
+	utils.SqliteInstance.DB.Exec("DELETE FROM tasks WHERE id = ?", task.Id)
+	history.Record(history.TASK_ENTITY, task.Id, 1, history.DELETE_ACTION, history.Diff(task.Snapshot(), nil))
+
+	if _, err := Undo(1, 1); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if taskDB := taskLib.GetTask(task.Id); taskDB.Id != task.Id || taskDB.Name != task.Name {
+		t.Error("Task should be brought back but is", taskDB)
+	}
+
+	// the entries written by the undo are not operations to undo
+	if _, err := Undo(1, 1); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if taskLib.IsTaskExist(task.Id) {
+		t.Error("Undoing the creation should delete the task")
+	}
+	if _, err := Undo(1, 1); err != ErrNothingToUndo {
+		t.Error("Error should be", ErrNothingToUndo, "but got", err)
+	}
+}
+
+func TestUndoReadError(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	task := taskLib.NewTask("TestTask")
+	task.UserId = 1
+	task.Save()
+
+	task.Priority = 2
+	task.Save()
+
+	// a row that can not be read is not a missing row
+	utils.SqliteInstance.DB.Exec("UPDATE tasks SET created_at = 'not a date' WHERE id = ?", task.Id)
+
+	if _, err := Undo(1, 1); err == nil || !strings.Contains(err.Error(), "Scanning task") {
+		t.Error("Undo should return the read error but got", err)
+	}
+
+	var priority int
+	utils.SqliteInstance.DB.QueryRow("SELECT priority FROM tasks WHERE id = ?", task.Id).Scan(&priority)
+
+	if priority != 2 {
+		t.Error("Failed undo should leave the task unchanged but priority is", priority)
+	}
+	if _, err := Redo(1, 1); err != ErrNothingToRedo {
+		t.Error("Failed undo should not be logged but got", err)
+	}
+}
@@ -1,10 +1,19 @@
 package user
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/mail"
+	"strconv"
+	"strings"
 	"time"
-	"todolist/services"
+	"todolist/config"
+	"todolist/events"
+	"todolist/history"
+	"todolist/permission"
+	"todolist/preferences"
 	taskLib "todolist/task"
 	"todolist/utils"
 )
@@ -19,31 +28,57 @@ type User struct {
 	Tasks     []taskLib.Task `json:"task"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
+
+	Preferences preferences.Preferences `json:"preferences"`
 }
 
 type UserInterface interface {
 	IsValid() bool
+	Validate() utils.ValidationErrors
 	GetAge() int
+	GetAgeAt(now time.Time) int
+	IsBirthday(now time.Time) bool
+	Location() *time.Location
+	NewTask(name string) (taskLib.Task, error)
+	DefaultListId() (int64, error)
 	AddTask(task taskLib.Task) error
+	AssignTask(task *taskLib.Task, assigneeId int64) error
+	AssignTaskContext(ctx context.Context, task *taskLib.Task, assigneeId int64) error
+	Load() (int, error)
+	Plan(now time.Time, days int) (taskLib.Plan, error)
+	View(view string, now time.Time) ([]taskLib.Task, error)
 	GetTask(index int64) taskLib.Task
 	GetTasks() []taskLib.Task
 	DeleteTask(index int64) error
 	CompleteTask(index int64)
 	Save() error
+	SaveAs(actorId int64) error
+	SaveContext(ctx context.Context, actorId int64) error
+	Snapshot() map[string]string
+	History() ([]history.Entry, error)
+	HistoryContext(ctx context.Context) ([]history.Entry, error)
+}
+
+func IsListExist(name string) (bool, error) {
+	return IsListExistContext(context.Background(), name)
 }
 
-func IsListExist(name string) bool {
+func IsListExistContext(ctx context.Context, name string) (bool, error) {
 	var count int64
 
-	row := utils.SqliteInstance.DB.QueryRow("SELECT COUNT(*) FROM users WHERE name = ?", name)
-	row.Scan(&count)
+	row := utils.SqliteInstance.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM lists WHERE name = ?", name)
+	err := row.Scan(&count)
+
+	if err != nil {
+		return false, fmt.Errorf("Checking list %q: %w", name, err)
+	}
 
-	return count > 0
+	return count > 0, nil
 }
 
 func isValidEmail(email string) bool {
-	_, err := mail.ParseAddress(email)
-	return err == nil
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
 }
 
 func isValidDate(date string) bool {
@@ -63,89 +98,399 @@ func NewUser(firstname string, lastname string, email string, tasks []taskLib.Ta
 		Tasks:     tasks,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
+
+		Preferences: preferences.Default(),
 	}
 }
 
-func GetUser(id int64) (User, error) {
-	var user User
+const (
+	USER_COLUMNS = "id, firstname, lastname, email, birthdate, password, timezone, locale, date_format, week_start, default_list, default_priority, daily_capacity, point_minutes, created_at, updated_at"
+)
 
-	row := utils.SqliteInstance.DB.QueryRow("SELECT * FROM users WHERE id = ?", id)
+const (
+	MIN_AGE = 13
+)
+
+var (
+	ErrUserNotFound = errors.New("User not found")
+)
+
+type scanner interface {
+	Scan(dest ...any) error
+}
 
-	row.Scan(
+// scanUser reads a row selected with USER_COLUMNS, preferences left NULL by
+// older databases get their default value.
+func scanUser(row scanner, user *User) error {
+	var timezone, locale, dateFormat, defaultList sql.NullString
+	var weekStart, defaultPriority, dailyCapacity, pointMinutes sql.NullInt64
+
+	err := row.Scan(
 		&user.Id,
 		&user.Firstname,
 		&user.Lastname,
 		&user.Email,
 		&user.Birthdate,
 		&user.Password,
+		&timezone,
+		&locale,
+		&dateFormat,
+		&weekStart,
+		&defaultList,
+		&defaultPriority,
+		&dailyCapacity,
+		&pointMinutes,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 
-	user.Tasks, _ = taskLib.GetTasksByUserId(id)
+	if err != nil {
+		return err
+	}
+
+	user.Preferences = preferences.Default()
+	user.Preferences.Timezone = timezone.String
+	user.Preferences.DefaultList = defaultList.String
+	user.Preferences.DefaultPriority = int(defaultPriority.Int64)
+	user.Preferences.DailyCapacity = int(dailyCapacity.Int64)
+	user.Preferences.PointMinutes = int(pointMinutes.Int64)
+
+	if locale.String != "" {
+		user.Preferences.Locale = locale.String
+	}
+
+	if dateFormat.String != "" {
+		user.Preferences.DateFormat = dateFormat.String
+	}
+
+	if weekStart.Valid {
+		user.Preferences.WeekStart = time.Weekday(weekStart.Int64)
+	}
+
+	return nil
+}
+
+func GetUser(id int64) (User, error) {
+	return GetUserContext(context.Background(), id)
+}
+
+func GetUserContext(ctx context.Context, id int64) (User, error) {
+	user, err := getUser(ctx, utils.SqliteInstance.DB, id)
+
+	if err != nil {
+		return User{}, err
+	}
+
+	user.Tasks, err = taskLib.GetTasksByUserIdContext(ctx, id)
+
+	if err != nil {
+		return User{}, err
+	}
+
+	return user, nil
+}
+
+// GetUserByEmail returns the first user registered with email.
+func GetUserByEmail(email string) (User, error) {
+	return GetUserByEmailContext(context.Background(), email)
+}
+
+func GetUserByEmailContext(ctx context.Context, email string) (User, error) {
+	var id int64
+
+	row := utils.SqliteInstance.DB.QueryRowContext(ctx, "SELECT id FROM users WHERE email = ? ORDER BY id LIMIT 1", email)
+	err := row.Scan(&id)
+
+	if err == sql.ErrNoRows {
+		return User{}, fmt.Errorf("%w: %s", ErrUserNotFound, email)
+	}
+
+	if err != nil {
+		return User{}, fmt.Errorf("Reading user %s: %w", email, err)
+	}
+
+	return GetUserContext(ctx, id)
+}
+
+// GetUserAs returns the user when actorId may read it, that is the user
+// itself and the members of its workspaces.
+func GetUserAs(id int64, actorId int64) (User, error) {
+	return GetUserAsContext(context.Background(), id, actorId)
+}
+
+func GetUserAsContext(ctx context.Context, id int64, actorId int64) (User, error) {
+	user, err := GetUserContext(ctx, id)
+
+	if err != nil {
+		return User{}, err
+	}
+
+	err = authorize(ctx, utils.SqliteInstance.DB, actorId, id, permission.READ_ACTION)
+
+	if err != nil {
+		return User{}, err
+	}
 
 	return user, nil
+}
+
+// roleOf returns the role of actorId on the user: self on its own account,
+// the strongest role of the actor in the workspaces of the user otherwise.
+// Only accepted memberships count, a user added to a workspace grants nothing
+// before accepting it.
+func roleOf(ctx context.Context, exec utils.Executor, actorId int64, userId int64) (string, error) {
+	var role string
+
+	if actorId == userId {
+		return permission.SELF_ROLE, nil
+	}
+
+	rows, err := exec.QueryContext(ctx, "SELECT actor.role FROM workspace_members actor JOIN workspace_members member ON member.workspace_id = actor.workspace_id WHERE actor.user_id = ? AND member.user_id = ? AND actor.accepted_at IS NOT NULL AND member.accepted_at IS NOT NULL", actorId, userId)
+
+	if err != nil {
+		return "", fmt.Errorf("Querying workspace roles: %w", err)
+	}
+
+	defer rows.Close()
 
+	for rows.Next() {
+		var workspaceRole string
+
+		err := rows.Scan(&workspaceRole)
+
+		if err != nil {
+			return "", fmt.Errorf("Scanning workspace role: %w", err)
+		}
+
+		role = permission.Strongest(role, workspaceRole)
+	}
+
+	return role, rows.Err()
+}
+
+// authorize checks that actorId may do action on the user, the actor 0 is
+// the application itself.
+func authorize(ctx context.Context, exec utils.Executor, actorId int64, userId int64, action string) error {
+	if actorId == 0 {
+		return nil
+	}
+
+	role, err := roleOf(ctx, exec, actorId, userId)
+
+	if err != nil {
+		return err
+	}
+
+	if !permission.Authorize(permission.USER_RESOURCE, role, action) {
+		return fmt.Errorf("%w: user %d can not %s user %d", permission.ErrForbidden, actorId, action, userId)
+	}
+
+	return nil
+}
+
+func getUser(ctx context.Context, exec utils.Executor, id int64) (User, error) {
+	var user User
+
+	row := exec.QueryRowContext(ctx, "SELECT "+USER_COLUMNS+" FROM users WHERE id = ?", id)
+	err := scanUser(row, &user)
+
+	if err == sql.ErrNoRows {
+		return User{}, fmt.Errorf("%w: %d", ErrUserNotFound, id)
+	}
+
+	if err != nil {
+		return User{}, fmt.Errorf("Scanning user %d: %w", id, err)
+	}
+
+	return user, nil
 }
 
 func GetUsers() ([]User, error) {
+	return GetUsersContext(context.Background())
+}
+
+func GetUsersContext(ctx context.Context) ([]User, error) {
 	var users []User
 
-	rows, err := utils.SqliteInstance.DB.Query("SELECT * FROM users")
+	rows, err := utils.SqliteInstance.DB.QueryContext(ctx, "SELECT "+USER_COLUMNS+" FROM users")
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Querying users: %w", err)
 	}
 
 	for rows.Next() {
 		var user User
 
-		rows.Scan(
-			&user.Id,
-			&user.Firstname,
-			&user.Lastname,
-			&user.Email,
-			&user.Birthdate,
-			&user.Password,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
+		err := scanUser(rows, &user)
 
-		user.Tasks, _ = taskLib.GetTasksByUserId(user.Id)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("Scanning user: %w", err)
+		}
 
 		users = append(users, user)
 	}
 
+	err = rows.Err()
+	rows.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying users: %w", err)
+	}
+
+	// tasks are loaded once rows is closed, an in-memory database only has
+	// one connection
+	for i := range users {
+		users[i].Tasks, err = taskLib.GetTasksByUserIdContext(ctx, users[i].Id)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return users, nil
 }
 
 func (u *User) IsValid() bool {
-	if u.Firstname == "" ||
-		u.Lastname == "" ||
-		u.Email == "" ||
-		!isValidEmail(u.Email) ||
-		!isValidDate(u.Birthdate.Format("2006-01-02")) ||
-		u.GetAge() < 13 {
-		return false
+	return len(u.Validate()) == 0
+}
+
+func (u *User) Validate() utils.ValidationErrors {
+	var errs utils.ValidationErrors
+
+	if strings.TrimSpace(u.Firstname) == "" {
+		errs.Add("firstname", "is required")
 	}
 
-	return true
+	if strings.TrimSpace(u.Lastname) == "" {
+		errs.Add("lastname", "is required")
+	}
+
+	if u.Email == "" {
+		errs.Add("email", "is required")
+	} else if !isValidEmail(u.Email) {
+		errs.Add("email", "is malformed")
+	}
+
+	if u.Birthdate.IsZero() || !isValidDate(u.Birthdate.Format("2006-01-02")) {
+		errs.Add("birthdate", "is required")
+	} else if u.Birthdate.After(time.Now()) {
+		errs.Add("birthdate", "is in the future")
+	} else if u.GetAge() < MIN_AGE {
+		errs.Add("birthdate", fmt.Sprintf("must be at least %d years old", MIN_AGE))
+	}
+
+	errs = append(errs, u.Preferences.Validate(taskLib.MIN_PRIORITY, taskLib.MAX_PRIORITY)...)
+
+	return errs
+}
+
+func (u *User) Location() *time.Location {
+	return u.Preferences.Location()
+}
+
+// NewTask returns a task owned by the user, filled with the default list and
+// priority of the user preferences.
+func (u *User) NewTask(name string) (taskLib.Task, error) {
+	task := taskLib.NewTask(name)
+	task.UserId = u.Id
+	task.Priority = u.Preferences.DefaultPriority
+
+	listId, err := u.DefaultListId()
+
+	if err != nil {
+		return taskLib.Task{}, err
+	}
+
+	task.ListId = listId
+
+	return task, nil
+}
+
+func (u *User) DefaultListId() (int64, error) {
+	return u.DefaultListIdContext(context.Background())
+}
+
+// DefaultListIdContext returns the id of the list named by the default list
+// preference among the lists of the user, 0 when none has this name.
+func (u *User) DefaultListIdContext(ctx context.Context) (int64, error) {
+	var id int64
+
+	if u.Preferences.DefaultList == "" {
+		return 0, nil
+	}
+
+	row := utils.SqliteInstance.DB.QueryRowContext(ctx, "SELECT id FROM lists WHERE name = ? AND (id IN (SELECT list_id FROM list_members WHERE user_id = ?) OR id IN ("+taskLib.WORKSPACE_LISTS+")) ORDER BY id LIMIT 1", u.Preferences.DefaultList, u.Id, u.Id)
+	err := row.Scan(&id)
+
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("Resolving list %q: %w", u.Preferences.DefaultList, err)
+	}
+
+	return id, nil
 }
 
 func (u *User) GetAge() int {
-	return time.Now().Year() - u.Birthdate.Year()
+	return u.GetAgeAt(time.Now())
+}
+
+// GetAgeAt returns the age of the user at now, as seen in the user timezone.
+// The birthdate is a calendar date, its own timezone is ignored.
+func (u *User) GetAgeAt(now time.Time) int {
+	now = now.In(u.Location())
+	age := now.Year() - u.Birthdate.Year()
+
+	if now.Month() < u.Birthdate.Month() || (now.Month() == u.Birthdate.Month() && now.Day() < u.Birthdate.Day()) {
+		age--
+	}
+
+	return age
+}
+
+// IsBirthday tells whether now is the birthday of the user in the user
+// timezone, people born on February 29 celebrate on March 1 in common years.
+func (u *User) IsBirthday(now time.Time) bool {
+	now = now.In(u.Location())
+	month, day := u.Birthdate.Month(), u.Birthdate.Day()
+
+	if month == time.February && day == 29 && !isLeapYear(now.Year()) {
+		month, day = time.March, 1
+	}
+
+	return now.Month() == month && now.Day() == day
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
 }
 
+// AddTask appends task when the quota of the user responsible for it
+// allows it: the assignee of a task delegated to someone else, the user
+// otherwise. The responsible user is warned once the number of tasks reaches
+// the warning threshold. A quota of 0 is unlimited.
 func (u *User) AddTask(task taskLib.Task) error {
-	if len(u.Tasks) >= 8 {
-		err := services.SendEmail(u.Email, "wake up", "You have 2 tasks left")
+	if task.AssigneeId != 0 && task.AssigneeId != u.Id {
+		assignee, err := GetUser(task.AssigneeId)
+
 		if err != nil {
 			return err
 		}
-	}
 
-	if len(u.Tasks) >= 10 {
-		return fmt.Errorf("You have too many tasks")
+		err = assignee.checkQuota(fmt.Errorf("%s %s has too many tasks", assignee.Firstname, assignee.Lastname))
+
+		if err != nil {
+			return err
+		}
+	} else {
+		err := u.checkQuota(fmt.Errorf("You have too many tasks"))
+
+		if err != nil {
+			return err
+		}
 	}
 
 	u.Tasks = append(u.Tasks, task)
@@ -153,6 +498,147 @@ func (u *User) AddTask(task taskLib.Task) error {
 	return nil
 }
 
+func (u *User) AssignTask(task *taskLib.Task, assigneeId int64) error {
+	return u.AssignTaskContext(context.Background(), task, assigneeId)
+}
+
+// AssignTaskContext assigns task to assigneeId on behalf of the user, 0
+// unassigns it. When the task changes hands, the quota of the new responsible
+// user is checked as AddTask does.
+func (u *User) AssignTaskContext(ctx context.Context, task *taskLib.Task, assigneeId int64) error {
+	responsible := task.AssigneeId
+
+	if responsible == 0 {
+		responsible = task.UserId
+	}
+
+	if assigneeId != 0 && assigneeId != responsible {
+		assignee, err := GetUserContext(ctx, assigneeId)
+
+		if err != nil {
+			return err
+		}
+
+		err = assignee.checkQuota(utils.ValidationErrors{{Field: "user", Message: fmt.Sprintf("%s %s has too many tasks", assignee.Firstname, assignee.Lastname)}})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return task.AssignContext(ctx, u.Id, assigneeId)
+}
+
+func (u *User) checkQuota(exceeded error) error {
+	quota := config.Current.Quota
+
+	if quota.MaxTasks == 0 {
+		return nil
+	}
+
+	load, err := u.Load()
+
+	if err != nil {
+		return err
+	}
+
+	if load >= quota.WarningTasks && load < quota.MaxTasks {
+		err := events.Publish(events.QuotaWarning{
+			UserId:    u.Id,
+			Email:     u.Email,
+			TasksLeft: quota.MaxTasks - load,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if load >= quota.MaxTasks {
+		return exceeded
+	}
+
+	return nil
+}
+
+func (u *User) Plan(now time.Time, days int) (taskLib.Plan, error) {
+	return u.PlanContext(context.Background(), now, days)
+}
+
+// PlanContext proposes a schedule of the open tasks the user is responsible
+// for over days days from now, against the daily capacity of the user.
+func (u *User) PlanContext(ctx context.Context, now time.Time, days int) (taskLib.Plan, error) {
+	var tasks []taskLib.Task
+
+	for _, task := range u.Tasks {
+		if task.AssigneeId == 0 || task.AssigneeId == u.Id {
+			tasks = append(tasks, task)
+		}
+	}
+
+	assigned, err := taskLib.GetTasksByAssigneeIdContext(ctx, u.Id)
+
+	if err != nil {
+		return taskLib.Plan{}, err
+	}
+
+	for _, task := range assigned {
+		if task.UserId != u.Id {
+			tasks = append(tasks, task)
+		}
+	}
+
+	capacity := taskLib.Capacity{DailyMinutes: u.Preferences.DailyCapacity, PointMinutes: u.Preferences.MinutesPerPoint()}
+
+	return taskLib.PlanTasks(tasks, now, days, capacity, u.Location())
+}
+
+func (u *User) View(view string, now time.Time) ([]taskLib.Task, error) {
+	return u.ViewContext(context.Background(), view, now)
+}
+
+// ViewContext returns the tasks visible to the user in the smart view, the
+// days follow the timezone and the week start of the user.
+func (u *User) ViewContext(ctx context.Context, view string, now time.Time) ([]taskLib.Task, error) {
+	tasks, err := taskLib.GetVisibleTasksContext(ctx, u.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return taskLib.FilterView(tasks, view, now, u.Preferences)
+}
+
+// Load returns how many tasks the user is responsible for: the own tasks
+// not delegated to someone else and the tasks assigned by other users.
+func (u *User) Load() (int, error) {
+	load := 0
+
+	for _, task := range u.Tasks {
+		if task.AssigneeId == 0 || task.AssigneeId == u.Id {
+			load++
+		}
+	}
+
+	if u.Id == 0 {
+		return load, nil
+	}
+
+	assigned, err := taskLib.GetTasksByAssigneeId(u.Id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, task := range assigned {
+		if task.UserId != u.Id {
+			load++
+		}
+	}
+
+	return load, nil
+}
+
 func (u *User) GetTask(index int64) taskLib.Task {
 	return u.Tasks[index]
 }
@@ -177,41 +663,217 @@ func (u *User) CompleteTask(index int64) {
 	u.Tasks[index].Complete()
 }
 
+// Snapshot returns the audited fields of the user, the password is left out
+// so it never ends up in the history.
+func (u *User) Snapshot() map[string]string {
+	birthdate := ""
+
+	if !u.Birthdate.IsZero() {
+		birthdate = u.Birthdate.Format("2006-01-02")
+	}
+
+	return map[string]string{
+		"firstname":        u.Firstname,
+		"lastname":         u.Lastname,
+		"email":            u.Email,
+		"birthdate":        birthdate,
+		"timezone":         u.Preferences.Timezone,
+		"locale":           u.Preferences.Locale,
+		"date_format":      u.Preferences.DateFormat,
+		"week_start":       u.Preferences.WeekStart.String(),
+		"default_list":     u.Preferences.DefaultList,
+		"default_priority": strconv.Itoa(u.Preferences.DefaultPriority),
+		"daily_capacity":   strconv.Itoa(u.Preferences.DailyCapacity),
+		"point_minutes":    strconv.Itoa(u.Preferences.PointMinutes),
+	}
+}
+
+func (u *User) History() ([]history.Entry, error) {
+	return u.HistoryContext(context.Background())
+}
+
+func (u *User) HistoryContext(ctx context.Context) ([]history.Entry, error) {
+	return history.GetHistoryContext(ctx, history.USER_ENTITY, u.Id)
+}
+
 func (u *User) Save() error {
+	return u.SaveAs(u.Id)
+}
+
+// SaveAs saves the user and all of its tasks in a single transaction, nothing
+// is written when one of them fails.
+func (u *User) SaveAs(actorId int64) error {
+	return u.SaveContext(context.Background(), actorId)
+}
+
+func (u *User) SaveContext(ctx context.Context, actorId int64) (err error) {
+	tx, err := utils.SqliteInstance.BeginTx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	id := u.Id
+	taskIds := make([]int64, len(u.Tasks))
+
+	for i, task := range u.Tasks {
+		taskIds[i] = task.Id
+	}
+
+	defer func() {
+		if err == nil || tx.Committed {
+			return
+		}
+
+		tx.Rollback()
+
+		u.Id = id
+
+		for i := range u.Tasks {
+			u.Tasks[i].Id = taskIds[i]
+		}
+	}()
+
+	err = u.saveWith(ctx, tx, actorId)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (u *User) saveWith(ctx context.Context, exec utils.Executor, actorId int64) error {
 	var query string
 
+	if errs := u.Validate(); len(errs) > 0 {
+		return errs
+	}
+
 	if u.Id == 0 {
-		query = "INSERT INTO users (firstname, lastname, email, birthdate, password, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+		query = "INSERT INTO users (firstname, lastname, email, birthdate, password, timezone, locale, date_format, week_start, default_list, default_priority, daily_capacity, point_minutes, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 	} else {
-		query = "UPDATE users SET firstname = ?, lastname = ?, email = ?, birthdate = ?, password = ?, created_at = ?, updated_at = ? WHERE id = ?"
+		query = "UPDATE users SET firstname = ?, lastname = ?, email = ?, birthdate = ?, password = ?, timezone = ?, locale = ?, date_format = ?, week_start = ?, default_list = ?, default_priority = ?, daily_capacity = ?, point_minutes = ?, created_at = ?, updated_at = ? WHERE id = ?"
 	}
 
-	stmt, err := utils.SqliteInstance.DB.Prepare(query)
+	stmt, err := exec.PrepareContext(ctx, query)
 
 	if err != nil {
 		return err
 	}
 
+	defer stmt.Close()
+
 	if u.Id == 0 {
-		res, err := stmt.Exec(u.Firstname, u.Lastname, u.Email, u.Birthdate, u.Password, u.CreatedAt, u.UpdatedAt)
+		res, err := stmt.ExecContext(
+			ctx,
+			u.Firstname,
+			u.Lastname,
+			u.Email,
+			u.Birthdate,
+			u.Password,
+			u.Preferences.Timezone,
+			u.Preferences.Locale,
+			u.Preferences.DateFormat,
+			u.Preferences.WeekStart,
+			u.Preferences.DefaultList,
+			u.Preferences.DefaultPriority,
+			u.Preferences.DailyCapacity,
+			u.Preferences.PointMinutes,
+			u.CreatedAt,
+			u.UpdatedAt,
+		)
 
 		if err != nil {
 			return err
 		}
 
-		u.Id, _ = res.LastInsertId()
+		u.Id, err = res.LastInsertId()
+
+		if err != nil {
+			return err
+		}
+
+		if actorId == 0 {
+			actorId = u.Id
+		}
+
+		_, err = history.RecordWith(ctx, exec, history.USER_ENTITY, u.Id, actorId, history.CREATE_ACTION, history.Diff(nil, u.Snapshot()))
+
+		if err != nil {
+			return err
+		}
+
+		registered := events.UserRegistered{UserId: u.Id, Email: u.Email}
+
+		err = utils.AfterCommit(exec, func() error {
+			return events.Publish(registered)
+		})
+
+		if err != nil {
+			return err
+		}
 	} else {
+		err := authorize(ctx, exec, actorId, u.Id, permission.WRITE_ACTION)
+
+		if err != nil {
+			return err
+		}
+
+		old, err := getUser(ctx, exec, u.Id)
+
+		if err != nil {
+			return err
+		}
+
 		u.UpdatedAt = time.Now()
-		_, err := stmt.Exec(u.Firstname, u.Lastname, u.Email, u.Birthdate, u.Password, u.CreatedAt, u.UpdatedAt, u.Id)
+		_, err = stmt.ExecContext(
+			ctx,
+			u.Firstname,
+			u.Lastname,
+			u.Email,
+			u.Birthdate,
+			u.Password,
+			u.Preferences.Timezone,
+			u.Preferences.Locale,
+			u.Preferences.DateFormat,
+			u.Preferences.WeekStart,
+			u.Preferences.DefaultList,
+			u.Preferences.DefaultPriority,
+			u.Preferences.DailyCapacity,
+			u.Preferences.PointMinutes,
+			u.CreatedAt,
+			u.UpdatedAt,
+			u.Id,
+		)
 
 		if err != nil {
 			return err
 		}
+
+		changes := history.Diff(old.Snapshot(), u.Snapshot())
+
+		if old.Password != u.Password {
+			changes = append(changes, history.Change{Field: "password", OldValue: "********", NewValue: "********"})
+		}
+
+		if len(changes) > 0 {
+			_, err = history.RecordWith(ctx, exec, history.USER_ENTITY, u.Id, actorId, history.UPDATE_ACTION, changes)
+
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	for _, task := range u.Tasks {
-		task.UserId = u.Id
-		task.Save()
+	for i := range u.Tasks {
+		u.Tasks[i].UserId = u.Id
+
+		err := u.Tasks[i].SaveWith(ctx, exec, actorId)
+
+		if err != nil {
+			return fmt.Errorf("Saving task %q: %w", u.Tasks[i].Name, err)
+		}
 	}
 
 	return nil
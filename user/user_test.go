@@ -1,9 +1,15 @@
 package user
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
+	"todolist/config"
+	"todolist/events"
+	"todolist/history"
+	"todolist/permission"
 	taskLib "todolist/task"
 	"todolist/utils"
 
@@ -25,7 +31,7 @@ var (
 			Firstname: faker.Person().Name(),
 			Lastname:  faker.Person().Name(),
 			Email:     faker.Internet().Email(),
-			Birthdate: faker.Time().Time(time.Now()),
+			Birthdate: faker.Time().TimeBetween(time.Now().AddDate(-80, 0, 0), time.Now().AddDate(-14, 0, 0)),
 			Password:  faker.Internet().Password(),
 			Tasks:     tasks,
 		},
@@ -33,7 +39,7 @@ var (
 			Firstname: faker.Person().Name(),
 			Lastname:  faker.Person().Name(),
 			Email:     faker.Internet().Email(),
-			Birthdate: faker.Time().Time(time.Now()),
+			Birthdate: faker.Time().TimeBetween(time.Now().AddDate(-80, 0, 0), time.Now().AddDate(-14, 0, 0)),
 			Password:  faker.Internet().Password(),
 			Tasks:     tasks,
 		},
@@ -41,13 +47,21 @@ var (
 			Firstname: faker.Person().Name(),
 			Lastname:  faker.Person().Name(),
 			Email:     faker.Internet().Email(),
-			Birthdate: faker.Time().Time(time.Now()),
+			Birthdate: faker.Time().TimeBetween(time.Now().AddDate(-80, 0, 0), time.Now().AddDate(-14, 0, 0)),
 			Password:  faker.Internet().Password(),
 			Tasks:     tasks,
 		},
 	}
 )
 
+// newUser builds a valid unsaved user from the fixture at index
+func newUser(index int, tasks []taskLib.Task) User {
+	user := NewUser(users[index].Firstname, users[index].Lastname, users[index].Email, tasks)
+	user.Birthdate = users[index].Birthdate
+
+	return user
+}
+
 func TestNewUser(t *testing.T) {
 	user := NewUser(users[0].Firstname, users[0].Lastname, users[0].Email, users[0].Tasks)
 
@@ -87,6 +101,8 @@ func TestSaveUser(t *testing.T) {
 			t.Error("User id should be 0 but is", user.Id)
 		}
 
+		// the users share the same tasks fixture, Save writes the ids back
+		user.Tasks = append([]taskLib.Task{}, user.Tasks...)
 		user.Save()
 
 		if user.Id != int64(i+1) {
@@ -160,14 +176,13 @@ func TestSaveUser(t *testing.T) {
 	}
 }
 
-// func mockSendEmail(email string, subject string, body string) error {
-// 	return nil
-// }
-
 func TestAddTask(t *testing.T) {
-	// origSendEmail := services.SendEmail
-	// defer func() { services.SendEmail = origSendEmail }()
-	// services.SendEmail = mockSendEmail
+	var warnings []events.QuotaWarning
+	events.Subscribe(events.QUOTA_WARNING, func(event events.Event) error {
+		warnings = append(warnings, event.(events.QuotaWarning))
+		return nil
+	})
+	defer events.BusInstance.Reset()
 
 	user := NewUser(faker.Person().Name(), faker.Person().Name(), faker.Internet().Email(), nil)
 
@@ -201,4 +216,563 @@ func TestAddTask(t *testing.T) {
 	if len(user.Tasks) > 10 {
 		t.Error("Tasks length should be lower than 10 but got", len(user.Tasks))
 	}
+	if len(warnings) != 0 {
+		t.Error("No quota warning should be published but got", len(warnings))
+	}
+
+	user = NewUser(faker.Person().Name(), faker.Person().Name(), faker.Internet().Email(), nil)
+
+	for i := 0; i < 8; i++ {
+		user.Tasks = append(user.Tasks, tasks[0])
+	}
+
+	user.AddTask(tasks[0])
+	user.AddTask(tasks[0])
+
+	if len(warnings) != 2 {
+		t.Fatal("2 quota warnings should be published but got", len(warnings))
+	}
+	if warnings[0].Email != user.Email {
+		t.Error("Warning email should be", user.Email, "but got", warnings[0].Email)
+	}
+	if warnings[0].TasksLeft != 2 || warnings[1].TasksLeft != 1 {
+		t.Error("Tasks left should be 2 then 1 but got", warnings[0].TasksLeft, "then", warnings[1].TasksLeft)
+	}
+}
+
+func TestAddTaskQuotaConfig(t *testing.T) {
+	defer func() { config.Current = config.Default() }()
+
+	user := NewUser(faker.Person().Name(), faker.Person().Name(), faker.Internet().Email(), nil)
+
+	for i := 0; i < 10; i++ {
+		user.Tasks = append(user.Tasks, tasks[0])
+	}
+
+	config.Current.Quota = config.Quota{MaxTasks: 0}
+
+	if err := user.AddTask(tasks[0]); err != nil {
+		t.Error("Error should be nil without quota but got", err)
+	}
+
+	config.Current.Quota = config.Quota{MaxTasks: 11, WarningTasks: 11}
+
+	if err := user.AddTask(tasks[0]); err == nil {
+		t.Error("Should return an error")
+	}
+	if len(user.Tasks) != 11 {
+		t.Error("Tasks length should be 11 but got", len(user.Tasks))
+	}
+}
+
+func TestAddTaskAssignee(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+	defer func() { config.Current = config.Default() }()
+
+	config.Current.Quota = config.Quota{MaxTasks: 2, WarningTasks: 2}
+
+	owner := newUser(0, nil)
+	owner.Save()
+	assignee := newUser(1, nil)
+	assignee.Save()
+
+	delegated := taskLib.NewTask("Delegated")
+	delegated.UserId = owner.Id
+	delegated.AssigneeId = assignee.Id
+	delegated.Save()
+
+	own := taskLib.NewTask("Own")
+	own.UserId = assignee.Id
+	own.Save()
+
+	assignee, _ = GetUser(assignee.Id)
+
+	if load, _ := assignee.Load(); load != 2 {
+		t.Error("Load should be", 2, "but got", load)
+	}
+
+	owner.Tasks = []taskLib.Task{delegated}
+
+	if load, _ := owner.Load(); load != 0 {
+		t.Error("Load should be", 0, "but got", load)
+	}
+
+	task := taskLib.NewTask("Another")
+	task.AssigneeId = assignee.Id
+
+	if err := owner.AddTask(task); err == nil {
+		t.Error("Should return an error when the assignee has too many tasks")
+	}
+	if err := owner.AddTask(taskLib.NewTask("Mine")); err != nil {
+		t.Error("Error should be nil but got", err)
+	}
+
+	task.AssigneeId = 42
+
+	if err := owner.AddTask(task); !errors.Is(err, ErrUserNotFound) {
+		t.Error("Error should be", ErrUserNotFound, "but got", err)
+	}
+
+	mine := taskLib.NewTask("Mine")
+	mine.UserId = owner.Id
+	mine.Save()
+
+	if err := owner.AssignTask(&mine, assignee.Id); err == nil {
+		t.Error("Should return an error when the assignee has too many tasks")
+	}
+	if mine.AssigneeId != 0 {
+		t.Error("AssigneeId should be", 0, "but got", mine.AssigneeId)
+	}
+	if err := owner.AssignTask(&delegated, owner.Id); err != nil {
+		t.Error("Error should be nil but got", err)
+	}
+	if err := owner.AssignTask(&mine, assignee.Id); err != nil {
+		t.Error("Error should be nil but got", err)
+	}
+}
+
+func TestAddTaskWarningError(t *testing.T) {
+	events.Subscribe(events.QUOTA_WARNING, func(event events.Event) error {
+		return fmt.Errorf("smtp down")
+	})
+	defer events.BusInstance.Reset()
+
+	user := NewUser(faker.Person().Name(), faker.Person().Name(), faker.Internet().Email(), nil)
+
+	for i := 0; i < 8; i++ {
+		user.Tasks = append(user.Tasks, tasks[0])
+	}
+
+	err := user.AddTask(tasks[0])
+
+	if err == nil {
+		t.Error("Should return an error")
+	}
+	if len(user.Tasks) != 8 {
+		t.Error("Tasks length should be 8 but got", len(user.Tasks))
+	}
+}
+
+func TestUserHistory(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	user := newUser(0, nil)
+	user.Password = users[0].Password
+	user.Save()
+
+	user.Lastname = fmt.Sprintf("%s_updated", user.Lastname)
+	user.Password = fmt.Sprintf("%s_updated", user.Password)
+	user.SaveAs(0)
+
+	entries, err := user.History()
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if len(entries) != 2 {
+		t.Fatal("History should have 2 entries but has", len(entries))
+	}
+	if entries[0].Action != history.CREATE_ACTION || entries[0].ActorId != user.Id {
+		t.Error("First entry should be a create by", user.Id, "but is", entries[0].Action, "by", entries[0].ActorId)
+	}
+	if entries[1].ActorId != 0 {
+		t.Error("Actor should be 0 but is", entries[1].ActorId)
+	}
+
+	for _, entry := range entries {
+		for _, change := range entry.Changes {
+			if change.Field == "password" && (change.OldValue == users[0].Password || change.NewValue == user.Password) {
+				t.Error("Password should not be stored in the history")
+			}
+		}
+	}
+
+	fields := []string{}
+	for _, change := range entries[1].Changes {
+		fields = append(fields, change.Field)
+	}
+
+	if len(fields) != 2 || fields[0] != "lastname" || fields[1] != "password" {
+		t.Error("Update should change lastname and password but got", fields)
+	}
+}
+
+func TestSaveUserTransaction(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	user := newUser(0, []taskLib.Task{
+		taskLib.NewTask(faker.Lorem().Word()),
+		taskLib.NewTask(faker.Lorem().Word()),
+	})
+
+	err := user.Save()
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	for _, task := range user.Tasks {
+		if task.Id == 0 || task.UserId != user.Id {
+			t.Error("Task should be saved for user", user.Id, "but is", task)
+		}
+	}
+
+	// saving again must update the tasks instead of inserting them twice
+	user.Save()
+
+	tasksDB, _ := taskLib.GetTasksByUserId(user.Id)
+
+	if len(tasksDB) != 2 {
+		t.Error("User should have 2 tasks but has", len(tasksDB))
+	}
+
+	utils.SqliteInstance.DB.Exec("DROP TABLE tasks")
+
+	failing := newUser(1, []taskLib.Task{
+		taskLib.NewTask(faker.Lorem().Word()),
+	})
+
+	err = failing.Save()
+
+	if err == nil {
+		t.Fatal("Should return an error")
+	}
+	if failing.Id != 0 || failing.Tasks[0].Id != 0 {
+		t.Error("Ids should be reset after a rollback but are", failing.Id, failing.Tasks[0].Id)
+	}
+
+	var count int
+	utils.SqliteInstance.DB.QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+
+	if count != 1 {
+		t.Error("Failed user should not be written but users has", count, "rows")
+	}
+}
+
+func TestSaveUserEventsAfterCommit(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+	defer events.BusInstance.Reset()
+
+	var created []int64
+	events.Subscribe(events.TASK_CREATED, func(event events.Event) error {
+		created = append(created, event.(events.TaskCreated).TaskId)
+
+		// subscribers run after the commit and can query the database
+		if !taskLib.IsTaskExist(event.(events.TaskCreated).TaskId) {
+			t.Error("Task should be committed when the event is published")
+		}
+
+		return nil
+	})
+
+	user := newUser(0, []taskLib.Task{
+		taskLib.NewTask(faker.Lorem().Word()),
+	})
+	user.Save()
+
+	if len(created) != 1 || created[0] != user.Tasks[0].Id {
+		t.Error("TaskCreated should be published for task", user.Tasks[0].Id, "but got", created)
+	}
+}
+
+func TestSaveUserEventError(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+	defer events.BusInstance.Reset()
+
+	events.Subscribe(events.TASK_CREATED, func(event events.Event) error {
+		return fmt.Errorf("smtp down")
+	})
+
+	user := newUser(0, []taskLib.Task{
+		taskLib.NewTask(faker.Lorem().Word()),
+	})
+
+	// the user is saved even though a subscriber failed after the commit
+	if err := user.Save(); err != nil {
+		t.Error("Error should be nil but got", err)
+	}
+	if !taskLib.IsTaskExist(user.Tasks[0].Id) {
+		t.Error("Task should be saved")
+	}
+}
+
+func TestGetUserContext(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	user := newUser(0, []taskLib.Task{
+		taskLib.NewTask(faker.Lorem().Word()),
+	})
+	user.Save()
+
+	userDB, err := GetUserContext(context.Background(), user.Id)
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if userDB.Email != user.Email || len(userDB.Tasks) != 1 {
+		t.Error("User should be", user.Email, "with 1 task but got", userDB.Email, "with", len(userDB.Tasks))
+	}
+
+	_, err = GetUser(42)
+
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Error("Error should be", ErrUserNotFound, "but got", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = GetUsersContext(ctx)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Error("Error should wrap", context.Canceled, "but got", err)
+	}
+	_, err = user.HistoryContext(ctx)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Error("Error should wrap", context.Canceled, "but got", err)
+	}
+}
+
+func TestIsListExist(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	utils.SqliteInstance.DB.Exec("INSERT INTO lists (name, owner_id) VALUES ('Groceries', 1)")
+
+	if exist, err := IsListExist("Groceries"); err != nil || !exist {
+		t.Error("List should exist but got", exist, err)
+	}
+	if exist, err := IsListExist("Chores"); err != nil || exist {
+		t.Error("List should not exist but got", exist, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := newUser(0, nil)
+
+	cases := []struct {
+		name   string
+		update func(user *User)
+		fields []string
+	}{
+		{"valid", func(user *User) {}, nil},
+		{"empty names", func(user *User) { user.Firstname = ""; user.Lastname = " " }, []string{"firstname", "lastname"}},
+		{"missing email", func(user *User) { user.Email = "" }, []string{"email"}},
+		{"malformed email", func(user *User) { user.Email = "John <john@example.com>" }, []string{"email"}},
+		{"missing birthdate", func(user *User) { user.Birthdate = time.Time{} }, []string{"birthdate"}},
+		{"future birthdate", func(user *User) { user.Birthdate = time.Now().AddDate(1, 0, 0) }, []string{"birthdate"}},
+		{"too young", func(user *User) { user.Birthdate = time.Now().AddDate(-10, 0, 0) }, []string{"birthdate"}},
+	}
+
+	for _, c := range cases {
+		user := valid
+		c.update(&user)
+
+		errs := user.Validate()
+
+		if len(errs) != len(c.fields) {
+			t.Error(c.name, "should fail on", c.fields, "but got", errs)
+			continue
+		}
+
+		for i, field := range c.fields {
+			if errs[i].Field != field {
+				t.Error(c.name, "should fail on", field, "but got", errs[i].Field)
+			}
+		}
+
+		if user.IsValid() != (len(c.fields) == 0) {
+			t.Error(c.name, "IsValid should be", len(c.fields) == 0)
+		}
+	}
+}
+
+func TestSaveInvalidUser(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	user := newUser(0, []taskLib.Task{taskLib.NewTask("")})
+	err := user.Save()
+
+	errs, ok := utils.AsValidationErrors(err)
+
+	if !ok {
+		t.Fatal("Error should be a validation error but got", err)
+	}
+	if len(errs.Fields()["name"]) != 1 {
+		t.Error("Task name should be reported but got", errs)
+	}
+	if user.Id != 0 {
+		t.Error("User should not be saved")
+	}
+
+	user.Email = "not an email"
+	err = user.Save()
+
+	errs, ok = utils.AsValidationErrors(err)
+
+	if !ok || len(errs.Fields()["email"]) != 1 {
+		t.Error("Email should be reported but got", err)
+	}
+}
+
+func TestGetAgeAt(t *testing.T) {
+	user := newUser(0, nil)
+	user.Birthdate = time.Date(2000, time.December, 15, 0, 0, 0, 0, time.UTC)
+	user.Preferences.Timezone = "UTC"
+
+	cases := []struct {
+		now time.Time
+		age int
+	}{
+		{time.Date(2026, time.January, 10, 12, 0, 0, 0, time.UTC), 25},
+		{time.Date(2026, time.December, 14, 23, 59, 0, 0, time.UTC), 25},
+		{time.Date(2026, time.December, 15, 0, 0, 0, 0, time.UTC), 26},
+	}
+
+	for _, c := range cases {
+		if age := user.GetAgeAt(c.now); age != c.age {
+			t.Error("Age at", c.now, "should be", c.age, "but got", age)
+		}
+	}
+
+	// it is already December 15 in Tokyo
+	user.Preferences.Timezone = "Asia/Tokyo"
+	now := time.Date(2026, time.December, 14, 20, 0, 0, 0, time.UTC)
+
+	if age := user.GetAgeAt(now); age != 26 {
+		t.Error("Age in Tokyo should be 26 but got", age)
+	}
+
+	user.Preferences.Timezone = "America/New_York"
+	now = time.Date(2026, time.December, 15, 3, 0, 0, 0, time.UTC)
+
+	if age := user.GetAgeAt(now); age != 25 {
+		t.Error("Age in New York should be 25 but got", age)
+	}
+}
+
+func TestIsBirthday(t *testing.T) {
+	user := newUser(0, nil)
+	user.Preferences.Timezone = "UTC"
+	user.Birthdate = time.Date(2004, time.February, 29, 0, 0, 0, 0, time.UTC)
+
+	if !user.IsBirthday(time.Date(2028, time.February, 29, 10, 0, 0, 0, time.UTC)) {
+		t.Error("February 29 should be the birthday in a leap year")
+	}
+	if user.IsBirthday(time.Date(2027, time.February, 28, 10, 0, 0, 0, time.UTC)) {
+		t.Error("February 28 should not be the birthday in a common year")
+	}
+	if !user.IsBirthday(time.Date(2027, time.March, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Error("March 1 should be the birthday in a common year")
+	}
+}
+
+func TestValidateTimezone(t *testing.T) {
+	user := newUser(0, nil)
+	user.Preferences.Timezone = "Europe/Paris"
+
+	if len(user.Validate()) != 0 {
+		t.Error("Europe/Paris should be valid but got", user.Validate())
+	}
+
+	user.Preferences.Timezone = "Mars/Olympus"
+	errs := user.Validate()
+
+	if len(errs) != 1 || errs[0].Field != "timezone" {
+		t.Error("Timezone should be reported but got", errs)
+	}
+}
+
+func TestSavePreferences(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	user := newUser(0, nil)
+	user.Preferences.Timezone = "Europe/Paris"
+	user.Preferences.Locale = "fr"
+	user.Preferences.DateFormat = "02/01/2006"
+	user.Preferences.WeekStart = time.Sunday
+	user.Preferences.DefaultList = "work"
+	user.Preferences.DefaultPriority = 2
+	user.Save()
+
+	userDB, _ := GetUser(user.Id)
+
+	if userDB.Preferences != user.Preferences {
+		t.Error("Preferences should be", user.Preferences, "but got", userDB.Preferences)
+	}
+
+	// a list of another user with the same name comes first
+	utils.SqliteInstance.DB.Exec("INSERT INTO lists (name, owner_id) VALUES ('work', 2)")
+	utils.SqliteInstance.DB.Exec("INSERT INTO lists (name, owner_id) VALUES ('work', ?)", user.Id)
+	utils.SqliteInstance.DB.Exec("INSERT INTO list_members (list_id, user_id, role) VALUES (1, 2, 'owner'), (2, ?, 'owner')", user.Id)
+
+	task, err := userDB.NewTask("TestTask")
+
+	if err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if task.UserId != user.Id || task.ListId != 2 || task.Label != "" || task.Priority != 2 {
+		t.Error("Task should use the user defaults but is", task)
+	}
+
+	userDB.Preferences.DefaultList = "home"
+
+	if task, _ := userDB.NewTask("TestTask"); task.ListId != 0 {
+		t.Error("Task should have no list when the default list does not exist but got", task.ListId)
+	}
+
+	user.Preferences.Locale = "tlh"
+	errs := user.Validate()
+
+	if len(errs) != 1 || errs[0].Field != "locale" {
+		t.Error("Locale should be reported but got", errs)
+	}
+}
+
+func TestUserAuthorization(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	var ids []int64
+
+	for i := 0; i < 4; i++ {
+		user := newUser(0, nil)
+		user.Save()
+		ids = append(ids, user.Id)
+	}
+
+	utils.SqliteInstance.DB.Exec("INSERT INTO workspace_members (workspace_id, user_id, role, accepted_at) VALUES (1, ?, 'admin', ?), (1, ?, 'member', ?), (1, ?, 'guest', ?)", ids[0], time.Now(), ids[1], time.Now(), ids[2], time.Now())
+	// the user 4 was added to the workspace but did not accept
+	utils.SqliteInstance.DB.Exec("INSERT INTO workspace_members (workspace_id, user_id, role) VALUES (1, ?, 'member')", ids[3])
+
+	if _, err := GetUserAs(ids[0], ids[2]); err != nil {
+		t.Error("Guest should read the workspace members but got", err)
+	}
+	if _, err := GetUserAs(ids[0], ids[3]); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Pending member should be forbidden but got", err)
+	}
+	if _, err := GetUserAs(ids[3], ids[0]); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Admin should not read a pending member but got", err)
+	}
+
+	user, _ := GetUser(ids[1])
+	user.Firstname = "Renamed"
+
+	if err := user.SaveAs(ids[2]); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Guest should not update a member but got", err)
+	}
+	if err := user.SaveAs(ids[0]); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Admin should not update a member but got", err)
+	}
+	if err := user.SaveAs(user.Id); err != nil {
+		t.Error("User should update itself but got", err)
+	}
 }
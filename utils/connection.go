@@ -2,6 +2,7 @@ package utils
 
 import (
 	"database/sql"
+	"strings"
 
 	_ "github.com/glebarez/go-sqlite"
 )
@@ -13,35 +14,126 @@ type Connection struct {
 var SqliteInstance Connection
 
 func ConnectDB(memory bool) (Connection, error) {
-	filename := ":memory:"
-
-	if !memory {
-		filename = DB_FILE
+	if memory {
+		return Open(DRIVER, MEMORY_DB)
 	}
 
-	db, err := sql.Open("sqlite", filename)
+	return Open(DRIVER, DB_FILE)
+}
+
+// Open connects to the database filename with driver and creates or migrates
+// the tables.
+func Open(driver string, filename string) (Connection, error) {
+	db, err := sql.Open(driver, filename)
 
 	if err != nil {
 		return Connection{}, err
 	}
 
-	db.Exec("CREATE TABLE IF NOT EXISTS tasks (id INTEGER PRIMARY KEY, name TEXT, description TEXT, completed BOOLEAN, end_date DATETIME, begin_date DATETIME, priority INTEGER, location TEXT, label TEXT, user_id INTEGER, created_at DATETIME, updated_at DATETIME)")
+	// every pooled connection to ":memory:" opens a new empty database
+	if filename == MEMORY_DB {
+		db.SetMaxOpenConns(1)
+	}
+
+	db.Exec("CREATE TABLE IF NOT EXISTS tasks (id INTEGER PRIMARY KEY, name TEXT, description TEXT, completed BOOLEAN, end_date DATETIME, begin_date DATETIME, priority INTEGER, location TEXT, label TEXT, user_id INTEGER, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME, list_id INTEGER, assignee_id INTEGER, auto_complete BOOLEAN, status TEXT, estimate INTEGER, estimate_unit TEXT, completed_at DATETIME)")
+
+	// databases created before the trash, the lists, the assignments, the
+	// checklists, the workflows, the estimates and the completion dates
+	// existed lack these columns
+	for _, column := range []string{"deleted_at DATETIME", "list_id INTEGER", "assignee_id INTEGER", "auto_complete BOOLEAN", "status TEXT", "estimate INTEGER", "estimate_unit TEXT", "completed_at DATETIME"} {
+		db.Exec("ALTER TABLE tasks ADD COLUMN " + column)
+	}
+
+	// the tasks saved before the workflows go to the statuses of the default
+	// workflow matching their completed flag
+	db.Exec("UPDATE tasks SET status = CASE WHEN completed THEN 'done' ELSE 'todo' END WHERE status IS NULL")
+
+	// the tasks completed before the completion dates were last completed
+	// when they were last updated
+	db.Exec("UPDATE tasks SET completed_at = updated_at WHERE completed AND completed_at IS NULL")
+
+	db.Exec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, firstname TEXT, lastname TEXT, email TEXT, birthdate DATETIME, password TEXT, timezone TEXT, locale TEXT, date_format TEXT, week_start INTEGER, default_list TEXT, default_priority INTEGER, daily_capacity INTEGER, point_minutes INTEGER, created_at DATETIME, updated_at DATETIME)")
+
+	// columns added after the first release, they already exist on new
+	// databases so the errors are ignored
+	for _, column := range []string{"timezone TEXT", "locale TEXT", "date_format TEXT", "week_start INTEGER", "default_list TEXT", "default_priority INTEGER", "daily_capacity INTEGER", "point_minutes INTEGER"} {
+		db.Exec("ALTER TABLE users ADD COLUMN " + column)
+	}
+
+	db.Exec("CREATE TABLE IF NOT EXISTS history (id INTEGER PRIMARY KEY, entity TEXT, entity_id INTEGER, action TEXT, actor_id INTEGER, created_at DATETIME)")
+
+	db.Exec("CREATE TABLE IF NOT EXISTS history_changes (id INTEGER PRIMARY KEY, history_id INTEGER, field TEXT, old_value TEXT, new_value TEXT)")
+
+	db.Exec("CREATE TABLE IF NOT EXISTS undo_log (id INTEGER PRIMARY KEY, user_id INTEGER, history_id INTEGER, action TEXT, result_from_id INTEGER, result_history_id INTEGER, history_cursor INTEGER, created_at DATETIME)")
+
+	// undo entries logged before the range of their results was kept wrote
+	// a single history entry
+	db.Exec("ALTER TABLE undo_log ADD COLUMN result_from_id INTEGER")
+	db.Exec("UPDATE undo_log SET result_from_id = result_history_id WHERE result_from_id IS NULL")
+
+	db.Exec("CREATE TABLE IF NOT EXISTS lists (id INTEGER PRIMARY KEY, name TEXT, owner_id INTEGER, created_at DATETIME, updated_at DATETIME, workspace_id INTEGER)")
+
+	// lists created before the workspaces
+	db.Exec("ALTER TABLE lists ADD COLUMN workspace_id INTEGER")
 
-	db.Exec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, firstname TEXT, lastname TEXT, email TEXT, birthdate DATETIME, password TEXT, created_at DATETIME, updated_at DATETIME)")
+	db.Exec("CREATE TABLE IF NOT EXISTS list_members (list_id INTEGER, user_id INTEGER, role TEXT, created_at DATETIME, PRIMARY KEY (list_id, user_id))")
+
+	db.Exec("CREATE TABLE IF NOT EXISTS invitations (id INTEGER PRIMARY KEY, list_id INTEGER, email TEXT, role TEXT, token TEXT UNIQUE, inviter_id INTEGER, created_at DATETIME, accepted_at DATETIME)")
+
+	db.Exec("CREATE TABLE IF NOT EXISTS workspaces (id INTEGER PRIMARY KEY, name TEXT, created_at DATETIME, updated_at DATETIME)")
+
+	db.Exec("CREATE TABLE IF NOT EXISTS workspace_members (workspace_id INTEGER, user_id INTEGER, role TEXT, created_at DATETIME, accepted_at DATETIME, PRIMARY KEY (workspace_id, user_id))")
+
+	// members added before the acceptance step stay pending until they
+	// accept, an admin could add any user without asking
+	db.Exec("ALTER TABLE workspace_members ADD COLUMN accepted_at DATETIME")
+
+	db.Exec("CREATE TABLE IF NOT EXISTS comments (id INTEGER PRIMARY KEY, task_id INTEGER, author_id INTEGER, body TEXT, created_at DATETIME, updated_at DATETIME)")
+
+	db.Exec("CREATE TABLE IF NOT EXISTS checklist_items (id INTEGER PRIMARY KEY, task_id INTEGER, position INTEGER, text TEXT, checked BOOLEAN, created_at DATETIME)")
+
+	db.Exec("CREATE TABLE IF NOT EXISTS list_statuses (list_id INTEGER, name TEXT, position INTEGER, terminal BOOLEAN, wip_limit INTEGER, PRIMARY KEY (list_id, name))")
+
+	db.Exec("CREATE TABLE IF NOT EXISTS status_transitions (list_id INTEGER, from_status TEXT, to_status TEXT, PRIMARY KEY (list_id, from_status, to_status))")
+
+	db.Exec("CREATE TABLE IF NOT EXISTS status_history (id INTEGER PRIMARY KEY, task_id INTEGER, from_status TEXT, to_status TEXT, actor_id INTEGER, created_at DATETIME)")
+
+	db.Exec("CREATE TABLE IF NOT EXISTS time_entries (id INTEGER PRIMARY KEY, task_id INTEGER, user_id INTEGER, started_at DATETIME, ended_at DATETIME, note TEXT, created_at DATETIME)")
+
+	// a user runs one timer at a time
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS time_entries_running ON time_entries (user_id) WHERE ended_at IS NULL")
+
+	// the entries recorded before the reports kept the offset of the server,
+	// the reports compare the dates as text in UTC
+	db.Exec("UPDATE time_entries SET started_at = strftime('%Y-%m-%d %H:%M:%f+00:00', started_at) WHERE started_at NOT LIKE '%+00:00'")
+	db.Exec("UPDATE time_entries SET ended_at = strftime('%Y-%m-%d %H:%M:%f+00:00', ended_at) WHERE ended_at NOT LIKE '%+00:00'")
+
+	db.Exec("CREATE INDEX IF NOT EXISTS time_entries_user_started ON time_entries (user_id, started_at)")
+
+	db.Exec("CREATE TABLE IF NOT EXISTS attachments (id INTEGER PRIMARY KEY, task_id INTEGER, uploader_id INTEGER, name TEXT, mime_type TEXT, size INTEGER, hash TEXT, created_at DATETIME)")
+
+	db.Exec("CREATE TABLE IF NOT EXISTS saved_views (id INTEGER PRIMARY KEY, user_id INTEGER, name TEXT, filter TEXT, sort TEXT, group_by TEXT, columns TEXT, created_at DATETIME, updated_at DATETIME, UNIQUE (user_id, name))")
 
 	return Connection{db}, nil
 }
 
+// IsUniqueViolation tells whether err comes from a statement breaking a
+// unique index or constraint.
+func IsUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
 func (c *Connection) Close() error {
 	return c.DB.Close()
 }
 
 func (c *Connection) ClearDB() error {
-	_, err := c.DB.Exec("DELETE FROM tasks")
-	_, err = c.DB.Exec("DELETE FROM users")
+	for _, table := range []string{"tasks", "users", "history", "history_changes", "undo_log", "lists", "list_members", "invitations", "workspaces", "workspace_members", "comments", "attachments", "checklist_items", "list_statuses", "status_transitions", "status_history", "time_entries", "saved_views"} {
+		_, err := c.DB.Exec("DELETE FROM " + table)
 
-	if err != nil {
-		return err
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
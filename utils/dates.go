@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"time"
+)
+
+func StartOfDay(date time.Time, loc *time.Location) time.Time {
+	date = date.In(loc)
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+}
+
+func SameDay(a time.Time, b time.Time, loc *time.Location) bool {
+	return StartOfDay(a, loc).Equal(StartOfDay(b, loc))
+}
+
+// LoadLocation returns the named IANA location, or time.Local when name is
+// empty.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+
+	return time.LoadLocation(name)
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+)
+
+// Executor is satisfied by both *sql.DB and *Tx so data functions can run
+// inside or outside of a transaction.
+type Executor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	Prepare(query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+type Tx struct {
+	*sql.Tx
+	Committed bool
+	// AfterCommitErr holds the errors of the AfterCommit callbacks, the
+	// writes of the transaction are kept whatever it is
+	AfterCommitErr error
+	afterCommit    []func() error
+}
+
+func (c *Connection) Begin() (*Tx, error) {
+	return c.BeginTx(context.Background())
+}
+
+func (c *Connection) BeginTx(ctx context.Context) (*Tx, error) {
+	tx, err := c.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{Tx: tx}, nil
+}
+
+// Commit commits the transaction then runs the AfterCommit callbacks. Only
+// the commit itself can fail, the errors of the callbacks are logged and kept
+// in AfterCommitErr for the callers that report them.
+func (tx *Tx) Commit() error {
+	err := tx.Tx.Commit()
+
+	if err != nil {
+		return err
+	}
+
+	tx.Committed = true
+
+	var errs []error
+
+	for _, fn := range tx.afterCommit {
+		if err := fn(); err != nil {
+			log.Println("utils: after commit:", err)
+			errs = append(errs, err)
+		}
+	}
+
+	tx.afterCommit = nil
+	tx.AfterCommitErr = errors.Join(errs...)
+
+	return nil
+}
+
+func (tx *Tx) Rollback() error {
+	tx.afterCommit = nil
+
+	return tx.Tx.Rollback()
+}
+
+// AfterCommit defers fn until exec is committed when it is a transaction, or
+// runs it right away otherwise.
+func AfterCommit(exec Executor, fn func() error) error {
+	if tx, ok := exec.(*Tx); ok {
+		tx.afterCommit = append(tx.afterCommit, fn)
+		return nil
+	}
+
+	return fn()
+}
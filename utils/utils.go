@@ -5,7 +5,9 @@ import (
 )
 
 const (
-	DB_FILE = "tasks.db"
+	DRIVER    = "sqlite"
+	DB_FILE   = "tasks.db"
+	MEMORY_DB = ":memory:"
 )
 
 func FileExist(file string) bool {
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors lists every invalid field of an entity, it is returned as
+// an error by Save so callers can report all of them at once.
+type ValidationErrors []ValidationError
+
+func (e *ValidationErrors) Add(field string, message string) {
+	*e = append(*e, ValidationError{Field: field, Message: message})
+}
+
+func (e ValidationErrors) Error() string {
+	var messages []string
+
+	for _, err := range e {
+		messages = append(messages, err.Field+": "+err.Message)
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+func (e ValidationErrors) Fields() map[string][]string {
+	fields := map[string][]string{}
+
+	for _, err := range e {
+		fields[err.Field] = append(fields[err.Field], err.Message)
+	}
+
+	return fields
+}
+
+func AsValidationErrors(err error) (ValidationErrors, bool) {
+	var validation ValidationErrors
+
+	ok := errors.As(err, &validation)
+
+	return validation, ok
+}
@@ -0,0 +1,458 @@
+package view
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	listLib "todolist/list"
+	"todolist/permission"
+	"todolist/preferences"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+	"todolist/utils"
+)
+
+// View is a listing saved by a user under a name: the tasks matching Filter
+// in the Sort order, split in groups by GroupBy and displayed with Columns.
+// An empty Sort is the urgency, empty Columns the default columns.
+type View struct {
+	Id        int64     `json:"id"`
+	UserId    int64     `json:"user_id"`
+	Name      string    `json:"name"`
+	Filter    string    `json:"filter"`
+	Sort      string    `json:"sort"`
+	GroupBy   string    `json:"group_by"`
+	Columns   []string  `json:"columns"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Group is a part of the result of a view, Key is empty for the tasks
+// without label, list or due date.
+type Group struct {
+	Key   string         `json:"key"`
+	Tasks []taskLib.Task `json:"tasks"`
+}
+
+type ViewInterface interface {
+	Validate() utils.ValidationErrors
+	Run(now time.Time) ([]Group, error)
+	RunContext(ctx context.Context, now time.Time) ([]Group, error)
+	Save() error
+	SaveAs(actorId int64) error
+	SaveContext(ctx context.Context, actorId int64) error
+	Delete() error
+	DeleteAs(actorId int64) error
+	DeleteContext(ctx context.Context, actorId int64) error
+}
+
+const (
+	VIEW_COLUMNS = "id, user_id, name, filter, sort, group_by, columns, created_at, updated_at"
+)
+
+const (
+	LABEL_GROUP    = "label"
+	PRIORITY_GROUP = "priority"
+	LIST_GROUP     = "list"
+	WEEK_GROUP     = "week"
+)
+
+var GROUPS = []string{LABEL_GROUP, PRIORITY_GROUP, LIST_GROUP, WEEK_GROUP}
+
+const (
+	MAX_NAME_LENGTH = 100
+)
+
+var (
+	ErrViewNotFound = errors.New("View not found")
+)
+
+func NewView(userId int64, name string) View {
+	now := time.Now()
+
+	return View{
+		UserId:    userId,
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanView(row scanner, view *View) error {
+	var filter, sort, groupBy, columns sql.NullString
+
+	err := row.Scan(&view.Id, &view.UserId, &view.Name, &filter, &sort, &groupBy, &columns, &view.CreatedAt, &view.UpdatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	view.Filter = filter.String
+	view.Sort = sort.String
+	view.GroupBy = groupBy.String
+	view.Columns = nil
+
+	if columns.String != "" {
+		view.Columns = strings.Split(columns.String, ",")
+	}
+
+	return nil
+}
+
+func GetView(id int64) (View, error) {
+	return GetViewContext(context.Background(), id)
+}
+
+func GetViewContext(ctx context.Context, id int64) (View, error) {
+	var view View
+
+	row := utils.SqliteInstance.DB.QueryRowContext(ctx, "SELECT "+VIEW_COLUMNS+" FROM saved_views WHERE id = ?", id)
+	err := scanView(row, &view)
+
+	if err == sql.ErrNoRows {
+		return View{}, fmt.Errorf("%w: %d", ErrViewNotFound, id)
+	}
+
+	if err != nil {
+		return View{}, fmt.Errorf("Scanning view %d: %w", id, err)
+	}
+
+	return view, nil
+}
+
+func GetViewByName(userId int64, name string) (View, error) {
+	return GetViewByNameContext(context.Background(), userId, name)
+}
+
+func GetViewByNameContext(ctx context.Context, userId int64, name string) (View, error) {
+	var view View
+
+	row := utils.SqliteInstance.DB.QueryRowContext(ctx, "SELECT "+VIEW_COLUMNS+" FROM saved_views WHERE user_id = ? AND name = ?", userId, name)
+	err := scanView(row, &view)
+
+	if err == sql.ErrNoRows {
+		return View{}, fmt.Errorf("%w: %s", ErrViewNotFound, name)
+	}
+
+	if err != nil {
+		return View{}, fmt.Errorf("Scanning view %s: %w", name, err)
+	}
+
+	return view, nil
+}
+
+// GetViewsByUserId returns the views of the user by name.
+func GetViewsByUserId(userId int64) ([]View, error) {
+	return GetViewsByUserIdContext(context.Background(), userId)
+}
+
+func GetViewsByUserIdContext(ctx context.Context, userId int64) ([]View, error) {
+	var views []View
+
+	rows, err := utils.SqliteInstance.DB.QueryContext(ctx, "SELECT "+VIEW_COLUMNS+" FROM saved_views WHERE user_id = ? ORDER BY name", userId)
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying views: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var view View
+
+		err := scanView(rows, &view)
+
+		if err != nil {
+			return nil, fmt.Errorf("Scanning view: %w", err)
+		}
+
+		views = append(views, view)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying views: %w", err)
+	}
+
+	return views, nil
+}
+
+func isGroup(name string) bool {
+	for _, group := range GROUPS {
+		if name == group {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks the view, the names of the smart views are reserved so
+// that both run by name.
+func (v *View) Validate() utils.ValidationErrors {
+	var errs utils.ValidationErrors
+
+	name := strings.TrimSpace(v.Name)
+
+	if name == "" {
+		errs.Add("name", "is required")
+	} else if len(name) > MAX_NAME_LENGTH {
+		errs.Add("name", fmt.Sprintf("must be at most %d characters", MAX_NAME_LENGTH))
+	} else if taskLib.IsView(name) {
+		errs.Add("name", fmt.Sprintf("%q is a smart view", name))
+	}
+
+	if v.UserId == 0 {
+		errs.Add("user_id", "is required")
+	}
+
+	if _, err := taskLib.ParseFilter(v.Filter); err != nil {
+		if filterErrs, ok := utils.AsValidationErrors(err); ok {
+			errs = append(errs, filterErrs...)
+		}
+	}
+
+	if v.Sort != "" && !taskLib.IsSort(v.Sort) {
+		errs.Add("sort", "must be one of "+strings.Join(taskLib.SORTS, ", "))
+	}
+
+	if v.GroupBy != "" && !isGroup(v.GroupBy) {
+		errs.Add("group_by", "must be one of "+strings.Join(GROUPS, ", "))
+	}
+
+	for _, column := range v.Columns {
+		if !taskLib.IsColumn(column) {
+			errs.Add("columns", fmt.Sprintf("%q is not one of %s", column, strings.Join(taskLib.COLUMNS, ", ")))
+		}
+	}
+
+	return errs
+}
+
+func (v *View) Run(now time.Time) ([]Group, error) {
+	return v.RunContext(context.Background(), now)
+}
+
+// RunContext lists the tasks visible to the owner of the view matching its
+// filter, sorted and grouped, the days follow the timezone of the owner.
+func (v *View) RunContext(ctx context.Context, now time.Time) ([]Group, error) {
+	filter, err := taskLib.ParseFilter(v.Filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := userLib.GetUserContext(ctx, v.UserId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := taskLib.GetVisibleTasksContext(ctx, v.UserId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tasks = taskLib.FilterTasks(tasks, filter, now, user.Preferences)
+
+	err = taskLib.SortTasks(tasks, v.Sort, now)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if v.GroupBy == "" {
+		return []Group{{Tasks: tasks}}, nil
+	}
+
+	lists := map[int64]string{}
+
+	if v.GroupBy == LIST_GROUP {
+		visible, err := listLib.GetListsByUserIdContext(ctx, v.UserId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, list := range visible {
+			lists[list.Id] = list.Name
+		}
+	}
+
+	return group(tasks, v.GroupBy, lists, user.Preferences), nil
+}
+
+// group splits the tasks by the keys of by, a task with several labels is
+// in the group of each one. Groups follow the labels and lists by name, the
+// priorities from critical down and the weeks in time, the group without
+// key comes last.
+func group(tasks []taskLib.Task, by string, lists map[int64]string, p preferences.Preferences) []Group {
+	var groups []Group
+
+	indexes := map[string]int{}
+	orders := map[string]string{}
+
+	for _, task := range tasks {
+		var keys []string
+
+		switch by {
+		case LABEL_GROUP:
+			for _, label := range taskLib.SplitLabels(task.Label) {
+				keys = append(keys, label)
+				orders[label] = strings.ToLower(label)
+			}
+		case PRIORITY_GROUP:
+			key := taskLib.PriorityName(task.Priority)
+			keys = append(keys, key)
+			orders[key] = strconv.Itoa(taskLib.MAX_PRIORITY - task.Priority)
+		case LIST_GROUP:
+			if task.ListId != 0 {
+				key, ok := lists[task.ListId]
+
+				if !ok {
+					key = strconv.FormatInt(task.ListId, 10)
+				}
+
+				keys = append(keys, key)
+				orders[key] = strings.ToLower(key)
+			}
+		case WEEK_GROUP:
+			if !task.EndDate.IsZero() {
+				start := p.StartOfWeek(task.EndDate)
+				key := p.FormatDate(start)
+				keys = append(keys, key)
+				orders[key] = start.Format("2006-01-02")
+			}
+		}
+
+		if len(keys) == 0 {
+			keys = append(keys, "")
+		}
+
+		for _, key := range keys {
+			index, ok := indexes[key]
+
+			if !ok {
+				index = len(groups)
+				indexes[key] = index
+				groups = append(groups, Group{Key: key})
+			}
+
+			groups[index].Tasks = append(groups[index].Tasks, task)
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i].Key, groups[j].Key
+
+		if (a == "") != (b == "") {
+			return b == ""
+		}
+
+		return orders[a] < orders[b]
+	})
+
+	return groups
+}
+
+func (v *View) Save() error {
+	return v.SaveAs(v.UserId)
+}
+
+func (v *View) SaveAs(actorId int64) error {
+	return v.SaveContext(context.Background(), actorId)
+}
+
+// SaveContext creates or updates a view of actorId, the names are unique
+// per user.
+func (v *View) SaveContext(ctx context.Context, actorId int64) error {
+	v.Name = strings.TrimSpace(v.Name)
+
+	for i := range v.Columns {
+		v.Columns[i] = strings.TrimSpace(v.Columns[i])
+	}
+
+	if errs := v.Validate(); len(errs) > 0 {
+		return errs
+	}
+
+	if actorId != 0 && actorId != v.UserId {
+		return fmt.Errorf("%w: user %d can not save the views of user %d", permission.ErrForbidden, actorId, v.UserId)
+	}
+
+	if v.Id != 0 {
+		old, err := GetViewContext(ctx, v.Id)
+
+		if err != nil {
+			return err
+		}
+
+		if old.UserId != v.UserId {
+			return fmt.Errorf("%w: view %d belongs to user %d", permission.ErrForbidden, v.Id, old.UserId)
+		}
+
+		v.CreatedAt = old.CreatedAt
+	}
+
+	var count int
+
+	row := utils.SqliteInstance.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM saved_views WHERE user_id = ? AND name = ? AND id != ?", v.UserId, v.Name, v.Id)
+	err := row.Scan(&count)
+
+	if err != nil {
+		return fmt.Errorf("Checking view %s: %w", v.Name, err)
+	}
+
+	if count > 0 {
+		return utils.ValidationErrors{{Field: "name", Message: fmt.Sprintf("%q is already used", v.Name)}}
+	}
+
+	columns := strings.Join(v.Columns, ",")
+
+	if v.Id == 0 {
+		res, err := utils.SqliteInstance.DB.ExecContext(ctx, "INSERT INTO saved_views (user_id, name, filter, sort, group_by, columns, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", v.UserId, v.Name, v.Filter, v.Sort, v.GroupBy, columns, v.CreatedAt, v.UpdatedAt)
+
+		if err != nil {
+			return err
+		}
+
+		v.Id, err = res.LastInsertId()
+
+		return err
+	}
+
+	v.UpdatedAt = time.Now()
+	_, err = utils.SqliteInstance.DB.ExecContext(ctx, "UPDATE saved_views SET name = ?, filter = ?, sort = ?, group_by = ?, columns = ?, updated_at = ? WHERE id = ?", v.Name, v.Filter, v.Sort, v.GroupBy, columns, v.UpdatedAt, v.Id)
+
+	return err
+}
+
+func (v *View) Delete() error {
+	return v.DeleteAs(v.UserId)
+}
+
+func (v *View) DeleteAs(actorId int64) error {
+	return v.DeleteContext(context.Background(), actorId)
+}
+
+// DeleteContext removes a view of actorId.
+func (v *View) DeleteContext(ctx context.Context, actorId int64) error {
+	if actorId != 0 && actorId != v.UserId {
+		return fmt.Errorf("%w: user %d can not delete view %d", permission.ErrForbidden, actorId, v.Id)
+	}
+
+	_, err := utils.SqliteInstance.DB.ExecContext(ctx, "DELETE FROM saved_views WHERE id = ?", v.Id)
+
+	return err
+}
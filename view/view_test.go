@@ -0,0 +1,167 @@
+package view
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	listLib "todolist/list"
+	"todolist/permission"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+	"todolist/utils"
+)
+
+func newUser() userLib.User {
+	user := userLib.NewUser("John", "Doe", "john@example.com", nil)
+	user.Birthdate = time.Now().AddDate(-30, 0, 0)
+	user.Preferences.Timezone = "UTC"
+	user.Save()
+
+	return user
+}
+
+func newTask(name string, label string, priority int, listId int64, end time.Time) {
+	task := taskLib.NewTask(name)
+	task.UserId = 1
+	task.Label = label
+	task.Priority = priority
+	task.ListId = listId
+	task.EndDate = end
+	task.Save()
+}
+
+func TestSaveView(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	view := NewView(1, " Work ")
+	view.Filter = "#work is:open"
+	view.Sort = taskLib.DUE_SORT
+	view.GroupBy = PRIORITY_GROUP
+	view.Columns = []string{"id", " name"}
+
+	if err := view.SaveAs(2); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Error should be", permission.ErrForbidden, "but got", err)
+	}
+	if err := view.Save(); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	saved, err := GetViewByName(1, "Work")
+
+	if err != nil || saved.Id != view.Id || saved.Filter != "#work is:open" || len(saved.Columns) != 2 || saved.Columns[1] != "name" {
+		t.Error("View should be saved but got", saved, err)
+	}
+
+	duplicate := NewView(1, "Work")
+
+	if err := duplicate.Save(); err == nil {
+		t.Error("Duplicate name should return an error")
+	}
+
+	other := NewView(2, "Work")
+
+	if err := other.Save(); err != nil {
+		t.Error("Other user should use the same name but got", err)
+	}
+
+	invalid := View{Name: "today", Filter: "!urgent", Sort: "name", GroupBy: "day", Columns: []string{"title"}}
+	errs := invalid.Validate()
+
+	if fields := errs.Fields(); len(fields) != 6 {
+		t.Error("Every field should be invalid but got", errs)
+	}
+
+	saved.Filter = "#work"
+	saved.GroupBy = ""
+
+	if err := saved.Save(); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if views, _ := GetViewsByUserId(1); len(views) != 1 || views[0].Filter != "#work" || views[0].GroupBy != "" {
+		t.Error("View should be updated but got", views)
+	}
+	if err := saved.DeleteAs(2); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Error should be", permission.ErrForbidden, "but got", err)
+	}
+	if err := saved.Delete(); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if _, err := GetView(saved.Id); !errors.Is(err, ErrViewNotFound) {
+		t.Error("Error should be", ErrViewNotFound, "but got", err)
+	}
+}
+
+func TestRunView(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	newUser()
+
+	list := listLib.NewList("Groceries", 1)
+	list.Save()
+
+	monday := time.Date(2026, time.March, 2, 12, 0, 0, 0, time.UTC)
+
+	newTask("Report", "work,urgent", taskLib.HIGH_PRIORITY, 0, monday.AddDate(0, 0, 8))
+	newTask("Milk", "home", taskLib.LOW_PRIORITY, list.Id, monday)
+	newTask("Taxes", "", taskLib.CRITICAL_PRIORITY, 0, monday.AddDate(0, 0, 2))
+	newTask("Garden", "home", taskLib.NONE_PRIORITY, 0, time.Time{})
+
+	cases := []struct {
+		groupBy  string
+		expected [][]string
+	}{
+		{"", [][]string{{"", "Taxes", "Report", "Milk", "Garden"}}},
+		{LABEL_GROUP, [][]string{{"home", "Milk", "Garden"}, {"urgent", "Report"}, {"work", "Report"}, {"", "Taxes"}}},
+		{PRIORITY_GROUP, [][]string{{"critical", "Taxes"}, {"high", "Report"}, {"low", "Milk"}, {"none", "Garden"}}},
+		{LIST_GROUP, [][]string{{"Groceries", "Milk"}, {"", "Taxes", "Report", "Garden"}}},
+		{WEEK_GROUP, [][]string{{"2026-03-02", "Taxes", "Milk"}, {"2026-03-09", "Report"}, {"", "Garden"}}},
+	}
+
+	for _, c := range cases {
+		view := NewView(1, "All")
+		view.Sort = taskLib.PRIORITY_SORT
+		view.GroupBy = c.groupBy
+
+		groups, err := view.Run(monday)
+
+		if err != nil {
+			t.Fatal("Error should be nil but got", err)
+		}
+
+		var got [][]string
+
+		for _, group := range groups {
+			names := []string{group.Key}
+
+			for _, task := range group.Tasks {
+				names = append(names, task.Name)
+			}
+
+			got = append(got, names)
+		}
+
+		if len(got) != len(c.expected) {
+			t.Error("Groups by", c.groupBy, "should be", c.expected, "but got", got)
+			continue
+		}
+
+		for i := range got {
+			if strings.Join(got[i], ",") != strings.Join(c.expected[i], ",") {
+				t.Error("Groups by", c.groupBy, "should be", c.expected, "but got", got)
+				break
+			}
+		}
+	}
+
+	view := NewView(1, "Home")
+	view.Filter = "#home -@store"
+
+	groups, err := view.Run(monday)
+
+	if err != nil || len(groups) != 1 || len(groups[0].Tasks) != 2 {
+		t.Error("View should list the 2 home tasks but got", groups, err)
+	}
+}
@@ -0,0 +1,479 @@
+package workspace
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	listLib "todolist/list"
+	"todolist/permission"
+	userLib "todolist/user"
+	"todolist/utils"
+)
+
+type Workspace struct {
+	Id        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Member struct {
+	WorkspaceId int64      `json:"workspace_id"`
+	UserId      int64      `json:"user_id"`
+	Role        string     `json:"role"`
+	CreatedAt   time.Time  `json:"created_at"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+}
+
+type WorkspaceInterface interface {
+	Validate() utils.ValidationErrors
+	SaveAs(actorId int64) error
+	SaveContext(ctx context.Context, actorId int64) error
+	DeleteAs(actorId int64) error
+	DeleteContext(ctx context.Context, actorId int64) error
+	Role(userId int64) (string, error)
+	RoleContext(ctx context.Context, userId int64) (string, error)
+	Members() ([]Member, error)
+	MembersContext(ctx context.Context) ([]Member, error)
+	AddMember(actorId int64, userId int64, role string) error
+	AddMemberContext(ctx context.Context, actorId int64, userId int64, role string) error
+	Accept(userId int64) error
+	AcceptContext(ctx context.Context, userId int64) error
+	RemoveMember(actorId int64, userId int64) error
+	RemoveMemberContext(ctx context.Context, actorId int64, userId int64) error
+	Lists() ([]listLib.List, error)
+}
+
+const (
+	WORKSPACE_COLUMNS = "id, name, created_at, updated_at"
+)
+
+var (
+	ErrWorkspaceNotFound  = errors.New("Workspace not found")
+	ErrInvitationNotFound = errors.New("Workspace invitation not found")
+)
+
+func NewWorkspace(name string) Workspace {
+	return Workspace{
+		Name:      name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func GetWorkspace(id int64) (Workspace, error) {
+	return GetWorkspaceContext(context.Background(), id)
+}
+
+func GetWorkspaceContext(ctx context.Context, id int64) (Workspace, error) {
+	var workspace Workspace
+
+	row := utils.SqliteInstance.DB.QueryRowContext(ctx, "SELECT "+WORKSPACE_COLUMNS+" FROM workspaces WHERE id = ?", id)
+	err := row.Scan(&workspace.Id, &workspace.Name, &workspace.CreatedAt, &workspace.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return Workspace{}, fmt.Errorf("%w: %d", ErrWorkspaceNotFound, id)
+	}
+
+	if err != nil {
+		return Workspace{}, fmt.Errorf("Scanning workspace %d: %w", id, err)
+	}
+
+	return workspace, nil
+}
+
+// GetWorkspacesByUserId returns the workspaces the user is a member of,
+// whatever the role. The workspaces the user was added to but did not accept
+// yet are left out.
+func GetWorkspacesByUserId(userId int64) ([]Workspace, error) {
+	return GetWorkspacesByUserIdContext(context.Background(), userId)
+}
+
+func GetWorkspacesByUserIdContext(ctx context.Context, userId int64) ([]Workspace, error) {
+	return queryWorkspaces(ctx, "SELECT "+WORKSPACE_COLUMNS+" FROM workspaces WHERE id IN (SELECT workspace_id FROM workspace_members WHERE user_id = ? AND accepted_at IS NOT NULL) ORDER BY id", userId)
+}
+
+// GetInvitedWorkspaces returns the workspaces the user was added to and has
+// not accepted yet.
+func GetInvitedWorkspaces(userId int64) ([]Workspace, error) {
+	return GetInvitedWorkspacesContext(context.Background(), userId)
+}
+
+func GetInvitedWorkspacesContext(ctx context.Context, userId int64) ([]Workspace, error) {
+	return queryWorkspaces(ctx, "SELECT "+WORKSPACE_COLUMNS+" FROM workspaces WHERE id IN (SELECT workspace_id FROM workspace_members WHERE user_id = ? AND accepted_at IS NULL) ORDER BY id", userId)
+}
+
+func queryWorkspaces(ctx context.Context, query string, args ...any) ([]Workspace, error) {
+	var workspaces []Workspace
+
+	rows, err := utils.SqliteInstance.DB.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying workspaces: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var workspace Workspace
+
+		err := rows.Scan(&workspace.Id, &workspace.Name, &workspace.CreatedAt, &workspace.UpdatedAt)
+
+		if err != nil {
+			return nil, fmt.Errorf("Scanning workspace: %w", err)
+		}
+
+		workspaces = append(workspaces, workspace)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying workspaces: %w", err)
+	}
+
+	return workspaces, nil
+}
+
+func (w *Workspace) Validate() utils.ValidationErrors {
+	var errs utils.ValidationErrors
+
+	if strings.TrimSpace(w.Name) == "" {
+		errs.Add("name", "is required")
+	}
+
+	return errs
+}
+
+func (w *Workspace) SaveAs(actorId int64) error {
+	return w.SaveContext(context.Background(), actorId)
+}
+
+// SaveContext creates the workspace with actorId as first admin, or renames
+// it when actorId administrates it.
+func (w *Workspace) SaveContext(ctx context.Context, actorId int64) (err error) {
+	if errs := w.Validate(); len(errs) > 0 {
+		return errs
+	}
+
+	if w.Id != 0 {
+		err = w.authorize(ctx, utils.SqliteInstance.DB, actorId, permission.WRITE_ACTION)
+
+		if err != nil {
+			return err
+		}
+
+		w.UpdatedAt = time.Now()
+		_, err = utils.SqliteInstance.DB.ExecContext(ctx, "UPDATE workspaces SET name = ?, updated_at = ? WHERE id = ?", w.Name, w.UpdatedAt, w.Id)
+
+		return err
+	}
+
+	tx, err := utils.SqliteInstance.BeginTx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil && !tx.Committed {
+			tx.Rollback()
+			w.Id = 0
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO workspaces (name, created_at, updated_at) VALUES (?, ?, ?)", w.Name, w.CreatedAt, w.UpdatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	w.Id, err = res.LastInsertId()
+
+	if err != nil {
+		return err
+	}
+
+	if actorId != 0 {
+		err = setRole(ctx, tx, w.Id, actorId, permission.ADMIN_ROLE)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = accept(ctx, tx, w.Id, actorId)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (w *Workspace) DeleteAs(actorId int64) error {
+	return w.DeleteContext(context.Background(), actorId)
+}
+
+// DeleteContext removes the workspace and its members. Its lists are kept
+// and stay shared with their own members.
+func (w *Workspace) DeleteContext(ctx context.Context, actorId int64) (err error) {
+	err = w.authorize(ctx, utils.SqliteInstance.DB, actorId, permission.DELETE_ACTION)
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := utils.SqliteInstance.BeginTx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil && !tx.Committed {
+			tx.Rollback()
+		}
+	}()
+
+	for _, query := range []string{
+		"UPDATE lists SET workspace_id = NULL WHERE workspace_id = ?",
+		"DELETE FROM workspace_members WHERE workspace_id = ?",
+		"DELETE FROM workspaces WHERE id = ?",
+	} {
+		_, err = tx.ExecContext(ctx, query, w.Id)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (w *Workspace) Role(userId int64) (string, error) {
+	return w.RoleContext(context.Background(), userId)
+}
+
+// RoleContext returns the role of the user in the workspace, "" when the
+// user is not a member or has not accepted the membership yet.
+func (w *Workspace) RoleContext(ctx context.Context, userId int64) (string, error) {
+	return role(ctx, utils.SqliteInstance.DB, w.Id, userId)
+}
+
+func role(ctx context.Context, exec utils.Executor, workspaceId int64, userId int64) (string, error) {
+	var role string
+
+	row := exec.QueryRowContext(ctx, "SELECT role FROM workspace_members WHERE workspace_id = ? AND user_id = ? AND accepted_at IS NOT NULL", workspaceId, userId)
+	err := row.Scan(&role)
+
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("Reading role on workspace %d: %w", workspaceId, err)
+	}
+
+	return role, nil
+}
+
+// setRole gives role to the user, a new member is pending until it accepts
+// the membership while an accepted one keeps it.
+func setRole(ctx context.Context, exec utils.Executor, workspaceId int64, userId int64, role string) error {
+	_, err := exec.ExecContext(ctx, "INSERT INTO workspace_members (workspace_id, user_id, role, created_at) VALUES (?, ?, ?, ?) ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = excluded.role", workspaceId, userId, role, time.Now())
+
+	return err
+}
+
+// accept marks the pending membership of the user as accepted, it tells
+// whether there was one.
+func accept(ctx context.Context, exec utils.Executor, workspaceId int64, userId int64) (bool, error) {
+	res, err := exec.ExecContext(ctx, "UPDATE workspace_members SET accepted_at = ? WHERE workspace_id = ? AND user_id = ? AND accepted_at IS NULL", time.Now(), workspaceId, userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	count, err := res.RowsAffected()
+
+	return count == 1, err
+}
+
+func (w *Workspace) authorize(ctx context.Context, exec utils.Executor, actorId int64, action string) error {
+	if actorId == 0 {
+		return nil
+	}
+
+	role, err := role(ctx, exec, w.Id, actorId)
+
+	if err != nil {
+		return err
+	}
+
+	if !permission.Authorize(permission.WORKSPACE_RESOURCE, role, action) {
+		return fmt.Errorf("%w: user %d can not %s workspace %d", permission.ErrForbidden, actorId, action, w.Id)
+	}
+
+	return nil
+}
+
+// isLastAdmin tells whether the user is the only admin left in the
+// workspace.
+func (w *Workspace) isLastAdmin(ctx context.Context, userId int64) (bool, error) {
+	var admins int64
+
+	current, err := role(ctx, utils.SqliteInstance.DB, w.Id, userId)
+
+	if err != nil || current != permission.ADMIN_ROLE {
+		return false, err
+	}
+
+	row := utils.SqliteInstance.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM workspace_members WHERE workspace_id = ? AND role = ? AND accepted_at IS NOT NULL", w.Id, permission.ADMIN_ROLE)
+	err = row.Scan(&admins)
+
+	if err != nil {
+		return false, fmt.Errorf("Counting admins of workspace %d: %w", w.Id, err)
+	}
+
+	return admins == 1, nil
+}
+
+func (w *Workspace) Members() ([]Member, error) {
+	return w.MembersContext(context.Background())
+}
+
+func (w *Workspace) MembersContext(ctx context.Context) ([]Member, error) {
+	var members []Member
+
+	rows, err := utils.SqliteInstance.DB.QueryContext(ctx, "SELECT workspace_id, user_id, role, created_at, accepted_at FROM workspace_members WHERE workspace_id = ? ORDER BY user_id", w.Id)
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying members: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var member Member
+		var createdAt, acceptedAt sql.NullTime
+
+		err := rows.Scan(&member.WorkspaceId, &member.UserId, &member.Role, &createdAt, &acceptedAt)
+
+		if err != nil {
+			return nil, fmt.Errorf("Scanning member: %w", err)
+		}
+
+		member.CreatedAt = createdAt.Time
+
+		if acceptedAt.Valid {
+			member.AcceptedAt = &acceptedAt.Time
+		}
+
+		members = append(members, member)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, fmt.Errorf("Querying members: %w", err)
+	}
+
+	return members, nil
+}
+
+func (w *Workspace) AddMember(actorId int64, userId int64, role string) error {
+	return w.AddMemberContext(context.Background(), actorId, userId, role)
+}
+
+// AddMemberContext gives role in the workspace to the user, replacing the
+// previous one. A new member gets no rights until it accepts the membership.
+// A workspace always keeps an admin.
+func (w *Workspace) AddMemberContext(ctx context.Context, actorId int64, userId int64, role string) error {
+	if !permission.IsWorkspaceRole(role) {
+		return utils.ValidationErrors{{Field: "role", Message: "must be one of " + strings.Join(permission.WORKSPACE_ROLES, ", ")}}
+	}
+
+	err := w.authorize(ctx, utils.SqliteInstance.DB, actorId, permission.SHARE_ACTION)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = userLib.GetUserContext(ctx, userId)
+
+	if err != nil {
+		return err
+	}
+
+	if role != permission.ADMIN_ROLE {
+		last, err := w.isLastAdmin(ctx, userId)
+
+		if err != nil {
+			return err
+		}
+
+		if last {
+			return utils.ValidationErrors{{Field: "user", Message: "is the last admin"}}
+		}
+	}
+
+	return setRole(ctx, utils.SqliteInstance.DB, w.Id, userId, role)
+}
+
+func (w *Workspace) Accept(userId int64) error {
+	return w.AcceptContext(context.Background(), userId)
+}
+
+// AcceptContext makes the user a member of the workspace it was added to,
+// users decline by removing themselves.
+func (w *Workspace) AcceptContext(ctx context.Context, userId int64) error {
+	accepted, err := accept(ctx, utils.SqliteInstance.DB, w.Id, userId)
+
+	if err != nil {
+		return err
+	}
+
+	if !accepted {
+		return fmt.Errorf("%w: user %d in workspace %d", ErrInvitationNotFound, userId, w.Id)
+	}
+
+	return nil
+}
+
+func (w *Workspace) RemoveMember(actorId int64, userId int64) error {
+	return w.RemoveMemberContext(context.Background(), actorId, userId)
+}
+
+// RemoveMemberContext removes the user from the workspace, users may always
+// leave a workspace unless they are its last admin.
+func (w *Workspace) RemoveMemberContext(ctx context.Context, actorId int64, userId int64) error {
+	if actorId != userId {
+		err := w.authorize(ctx, utils.SqliteInstance.DB, actorId, permission.SHARE_ACTION)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	last, err := w.isLastAdmin(ctx, userId)
+
+	if err != nil {
+		return err
+	}
+
+	if last {
+		return utils.ValidationErrors{{Field: "user", Message: "is the last admin"}}
+	}
+
+	_, err = utils.SqliteInstance.DB.ExecContext(ctx, "DELETE FROM workspace_members WHERE workspace_id = ? AND user_id = ?", w.Id, userId)
+
+	return err
+}
+
+func (w *Workspace) Lists() ([]listLib.List, error) {
+	return listLib.GetListsByWorkspaceId(w.Id)
+}
@@ -0,0 +1,227 @@
+package workspace
+
+import (
+	"errors"
+	"testing"
+	"time"
+	listLib "todolist/list"
+	"todolist/permission"
+	taskLib "todolist/task"
+	userLib "todolist/user"
+	"todolist/utils"
+
+	fakerLib "github.com/jaswdr/faker"
+)
+
+var faker = fakerLib.New()
+
+func newUser(t *testing.T, email string) userLib.User {
+	user := userLib.NewUser(faker.Person().FirstName(), faker.Person().LastName(), email, nil)
+	user.Birthdate = time.Now().AddDate(-30, 0, 0)
+
+	if err := user.Save(); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	return user
+}
+
+func TestSaveWorkspace(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	admin := newUser(t, "admin@example.com")
+	member := newUser(t, "member@example.com")
+	workspace := NewWorkspace("Acme")
+
+	if err := workspace.SaveAs(admin.Id); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+
+	if role, _ := workspace.Role(admin.Id); role != permission.ADMIN_ROLE {
+		t.Error("Role should be", permission.ADMIN_ROLE, "but got", role)
+	}
+
+	workspace.AddMember(admin.Id, member.Id, permission.MEMBER_ROLE)
+
+	if workspaces, _ := GetWorkspacesByUserId(member.Id); len(workspaces) != 0 {
+		t.Error("Pending member should not see the workspace but got", workspaces)
+	}
+	if invited, _ := GetInvitedWorkspaces(member.Id); len(invited) != 1 || invited[0].Id != workspace.Id {
+		t.Error("Pending member should be invited to the workspace but got", invited)
+	}
+	if role, _ := workspace.Role(member.Id); role != "" {
+		t.Error("Pending member should have no role but got", role)
+	}
+	if err := workspace.Accept(member.Id); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if err := workspace.Accept(member.Id); !errors.Is(err, ErrInvitationNotFound) {
+		t.Error("Error should be", ErrInvitationNotFound, "but got", err)
+	}
+
+	workspace.Name = "Acme Inc"
+
+	if err := workspace.SaveAs(member.Id); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Member should not rename the workspace but got", err)
+	}
+	if err := workspace.SaveAs(admin.Id); err != nil {
+		t.Error("Admin should rename the workspace but got", err)
+	}
+
+	workspaces, _ := GetWorkspacesByUserId(member.Id)
+
+	if len(workspaces) != 1 || workspaces[0].Name != "Acme Inc" {
+		t.Error("Member should see the workspace but got", workspaces)
+	}
+
+	invalid := NewWorkspace(" ")
+
+	if err := invalid.SaveAs(admin.Id); err == nil {
+		t.Error("Should return an error without a name")
+	}
+
+	if _, err := GetWorkspace(42); !errors.Is(err, ErrWorkspaceNotFound) {
+		t.Error("Error should be", ErrWorkspaceNotFound, "but got", err)
+	}
+}
+
+func TestMembers(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	admin := newUser(t, "admin@example.com")
+	member := newUser(t, "member@example.com")
+	guest := newUser(t, "guest@example.com")
+	workspace := NewWorkspace("Acme")
+	workspace.SaveAs(admin.Id)
+
+	if err := workspace.AddMember(admin.Id, member.Id, "owner"); err == nil {
+		t.Error("Should return an error with a list role")
+	}
+	if err := workspace.AddMember(admin.Id, 42, permission.GUEST_ROLE); !errors.Is(err, userLib.ErrUserNotFound) {
+		t.Error("Error should be", userLib.ErrUserNotFound, "but got", err)
+	}
+	if err := workspace.AddMember(admin.Id, member.Id, permission.MEMBER_ROLE); err != nil {
+		t.Fatal("Error should be nil but got", err)
+	}
+	if err := workspace.AddMember(member.Id, guest.Id, permission.GUEST_ROLE); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Member should not add members but got", err)
+	}
+
+	workspace.AddMember(admin.Id, guest.Id, permission.GUEST_ROLE)
+
+	if err := workspace.AddMember(admin.Id, admin.Id, permission.MEMBER_ROLE); err == nil {
+		t.Error("Last admin should not be demoted")
+	}
+	if err := workspace.RemoveMember(admin.Id, admin.Id); err == nil {
+		t.Error("Last admin should not leave")
+	}
+	if err := workspace.RemoveMember(guest.Id, guest.Id); err != nil {
+		t.Error("Guest should leave but got", err)
+	}
+
+	members, _ := workspace.Members()
+
+	if len(members) != 2 || members[1].UserId != member.Id || members[1].Role != permission.MEMBER_ROLE {
+		t.Error("Members should be the admin and the member but got", members)
+	}
+
+	if err := workspace.DeleteAs(member.Id); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Member should not delete the workspace but got", err)
+	}
+	if err := workspace.DeleteAs(admin.Id); err != nil {
+		t.Error("Admin should delete the workspace but got", err)
+	}
+	if role, _ := workspace.Role(member.Id); role != "" {
+		t.Error("Members should be removed but got", role)
+	}
+}
+
+func TestWorkspaceLists(t *testing.T) {
+	utils.SqliteInstance, _ = utils.ConnectDB(true)
+	defer utils.SqliteInstance.Close()
+
+	admin := newUser(t, "admin@example.com")
+	member := newUser(t, "member@example.com")
+	guest := newUser(t, "guest@example.com")
+	outsider := newUser(t, "outsider@example.com")
+	workspace := NewWorkspace("Acme")
+	workspace.SaveAs(admin.Id)
+	workspace.AddMember(admin.Id, member.Id, permission.MEMBER_ROLE)
+	workspace.AddMember(admin.Id, guest.Id, permission.GUEST_ROLE)
+
+	list := listLib.NewList("Roadmap", member.Id)
+	list.WorkspaceId = workspace.Id
+
+	if err := list.SaveAs(member.Id); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Pending member should not create a list but got", err)
+	}
+
+	workspace.Accept(member.Id)
+	workspace.Accept(guest.Id)
+
+	if err := list.SaveAs(member.Id); err != nil {
+		t.Fatal("Member should create a list but got", err)
+	}
+
+	other := listLib.NewList("Secret", guest.Id)
+	other.WorkspaceId = workspace.Id
+
+	if err := other.SaveAs(guest.Id); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Guest should not create a list but got", err)
+	}
+
+	for _, c := range []struct {
+		user userLib.User
+		role string
+	}{
+		{admin, permission.OWNER_ROLE},
+		{member, permission.OWNER_ROLE},
+		{guest, ""},
+		{outsider, ""},
+	} {
+		if role, _ := list.Role(c.user.Id); role != c.role {
+			t.Error(c.user.Email, "role should be", c.role, "but got", role)
+		}
+	}
+
+	task := taskLib.NewTask("Plan Q3")
+	task.UserId = member.Id
+	task.ListId = list.Id
+	task.Save()
+
+	task.Priority = 2
+
+	if err := task.SaveAs(admin.Id); err != nil {
+		t.Error("Admin should edit the workspace tasks but got", err)
+	}
+	if err := task.SaveAs(guest.Id); !errors.Is(err, permission.ErrForbidden) {
+		t.Error("Guest should not edit the workspace tasks but got", err)
+	}
+
+	list.Share(member.Id, guest.Id, permission.VIEWER_ROLE)
+
+	if _, err := taskLib.GetTaskAs(task.Id, guest.Id); err != nil {
+		t.Error("Guest should read the tasks of a list shared with them but got", err)
+	}
+
+	tasks, _ := taskLib.GetVisibleTasks(admin.Id)
+
+	if len(tasks) != 1 || tasks[0].Id != task.Id {
+		t.Error("Admin should see the workspace tasks but got", tasks)
+	}
+
+	lists, _ := workspace.Lists()
+
+	if len(lists) != 1 || lists[0].Id != list.Id {
+		t.Error("Workspace should have the list but got", lists)
+	}
+
+	workspace.DeleteAs(admin.Id)
+	saved, _ := listLib.GetList(list.Id)
+
+	if saved.WorkspaceId != 0 {
+		t.Error("List should leave the deleted workspace but is in", saved.WorkspaceId)
+	}
+}